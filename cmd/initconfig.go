@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra" // 命令行框架
+
+	"syslog_go/pkg/template" // 自定义变量配置
+)
+
+// 命令行参数
+var (
+	initConfigOutput string // 示例配置的输出路径，"-" 为标准输出
+	initConfigForce  bool   // 输出文件已存在时覆盖
+)
+
+// initConfigCmd 生成自定义变量配置示例
+var initConfigCmd = &cobra.Command{
+	Use:   "init-config",
+	Short: "生成带注释的自定义变量配置示例 (template.yml)",
+	Long: `生成带注释的自定义变量配置示例
+
+示例包含每种自定义变量类型 (random_choice、random_int、random_string、random_float、pattern)，
+由程序中的配置结构序列化得到，生成的文件可以直接被 send 和 mock 加载。
+
+示例:
+  # 在当前目录生成 template.yml（send 和 mock 默认加载该文件）
+  syslog_go init-config
+
+  # 输出到指定路径，已存在时覆盖
+  syslog_go init-config -o ./conf/vars.yml --force
+  syslog_go mock --vars-file ./conf/vars.yml -m "状态: {{CUSTOM_STATUS}}" -n 5
+
+  # 输出到标准输出
+  syslog_go init-config -o -`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if initConfigOutput == "-" {
+			if err := template.WriteSampleConfig(os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "生成示例配置失败: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if err := writeSampleConfig(initConfigOutput, initConfigForce); err != nil {
+			fmt.Fprintf(os.Stderr, "生成示例配置失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已生成示例配置 %s\n", initConfigOutput)
+	},
+}
+
+// writeSampleConfig 将示例自定义变量配置写入文件
+// 参数：
+//   - path: 输出文件路径
+//   - force: 为false时文件已存在则返回错误
+func writeSampleConfig(path string, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s 已存在，使用 --force 覆盖", path)
+	}
+	if err != nil {
+		return err
+	}
+	if err := template.WriteSampleConfig(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// init 注册 init-config 命令
+func init() {
+	rootCmd.AddCommand(initConfigCmd)
+
+	initConfigCmd.Flags().StringVarP(&initConfigOutput, "output", "o", template.DefaultConfigFile, "输出文件路径，- 为标准输出")
+	initConfigCmd.Flags().BoolVar(&initConfigForce, "force", false, "输出文件已存在时覆盖")
+}
@@ -3,14 +3,18 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"syslog_go/pkg/config"
+	"syslog_go/pkg/logging"
 	"syslog_go/pkg/sender"
+	"syslog_go/pkg/syslog"
 	"syslog_go/pkg/template"
 )
 
@@ -20,6 +24,15 @@ var (
 	mockCount    int
 	mockAppend   bool
 	mockTemplate bool
+	mockVarsFile string
+	mockTplFile  string
+	mockFormat   string
+	mockFacility string
+	mockSeverity string
+	mockNow      string
+	mockListVars bool
+	mockSeed     int64
+	mockAnnotate bool
 )
 
 // mockCmd 生成模拟数据
@@ -28,7 +41,7 @@ var mockCmd = &cobra.Command{
 	Short: "生成模拟数据",
 	Long: `生成模拟数据
 
-支持的模板变量:
+支持的模板变量 (完整列表及示例见 --list-variables):
 1. {{RANDOM_STRING:选项1,选项2,...}} - 从给定选项中随机选择，支持权重
 2. {{RANDOM_INT:最小值-最大值}} - 生成指定范围内的随机整数
 3. {{ENUM:选项1,选项2,...}} - 从选项列表中随机选择一个
@@ -42,68 +55,57 @@ var mockCmd = &cobra.Command{
 7. {{RANDOM_IPV6}} - 生成标准格式的IPv6地址
    {{RANDOM_IPV6:internal}} - 生成内网IPv6地址 (fd00::/8)
    {{RANDOM_IPV6:external}} - 生成外网IPv6地址 (2000::/3)
-   {{RANDOM_IPV6:compressed}} - 生成压缩格式的IPv6地址（包含::）`,
+   {{RANDOM_IPV6:compressed}} - 生成压缩格式的IPv6地址（包含::）
+8. {{JSON:键1=变量1,键2=变量2[:参数]}} - 生成JSON对象，值由子变量求值并转义
+   {{JSON:src=RANDOM_IP:internal,user=ENUM:alice,bob}} - 生成 {"src":"10.1.2.3","user":"bob"}
+9. {{ENV:变量名}} - 读取环境变量，未设置时为空
+   {{ENV:BUILD_ID:unknown}} - 未设置时使用默认值unknown
+10. {{FILE:/path/to/list.txt}} - 从文件中随机选择一行，文件首次使用时读入并缓存
+11. {{PRI:local0.err}} - 写在消息开头时设置该条消息的Facility和Severity，发送时移除（mock原样输出）
+12. {{LEVEL}} - 与该条消息Severity对应的日志级别 DEBUG/INFO/WARN/ERROR/FATAL，发送时替换（mock原样输出）
+13. {{PROCESS}} - 生成常见进程名称，如 sshd
+    {{PROCESS_PID}} - 生成进程名称和进程号，如 nginx[12345]
+
+指定 --format 时输出带优先级、时间戳和主机名的完整syslog行（PRI指令和LEVEL在此时处理），
+可用 send -D 文件 --raw 原样重放:
+  syslog_go mock -m '[{{LEVEL}}] user={{ENUM:alice,bob}}' -n 1000 --format rfc5424 --severity err -o app.log`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 如果指定了生成模板文件
 		if mockTemplate {
-			// 检查模板文件是否已存在
-			if _, err := os.Stat("template.yml"); err == nil {
-				fmt.Println("template.yml 已存在，跳过生成")
+			// 未指定 --vars-file 时生成到当前目录
+			varsFile := mockVarsFile
+			if varsFile == "" {
+				varsFile = template.DefaultConfigFile
+			}
+
+			// 模板文件已存在时不覆盖
+			if _, err := os.Stat(varsFile); err == nil {
+				fmt.Printf("%s 已存在，跳过生成\n", varsFile)
 				return
 			}
 
-			// 生成示例模板文件
-			template := `# 自定义变量配置示例
-variables:
-  # 随机选择类型变量示例
-  CUSTOM_STATUS:
-    type: "random_choice"
-    values:
-      - "正常"
-      - "警告"
-      - "错误"
-      - "严重"
-
-  # 随机整数类型变量示例
-  CUSTOM_SCORE:
-    type: "random_int"
-    min: 0
-    max: 100
-
-  # 随机字符串类型变量示例
-  CUSTOM_ID:
-    type: "random_string"
-    length: 8
-
-  # 自定义IP地址池
-  CUSTOM_SERVER_IP:
-    type: "random_choice"
-    values:
-      - "192.168.1.10"
-      - "192.168.1.11"
-      - "192.168.1.12"
-      - "192.168.1.13"
-
-  # 自定义端口列表
-  CUSTOM_PORT:
-    type: "random_choice"
-    values:
-      - "8080"
-      - "8443"
-      - "9000"
-      - "9443"
-`
-			// 写入模板文件
-			if err := os.WriteFile("template.yml", []byte(template), 0644); err != nil {
+			// 生成示例模板文件，与 init-config 的输出相同
+			if err := writeSampleConfig(varsFile, false); err != nil {
 				fmt.Fprintf(os.Stderr, "生成模板文件失败: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Println("已生成模板文件 template.yml")
+			fmt.Printf("已生成模板文件 %s\n", varsFile)
+			return
+		}
+
+		// 列出支持的模板变量，包括 --vars-file 或当前目录template.yml中的自定义变量
+		if mockListVars {
+			configPath, err := template.ResolveConfigPath(mockVarsFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			printVariables(template.NewEngine(configPath, false))
 			return
 		}
 
 		// 如果没有提供任何参数，显示帮助信息
-		if len(args) == 0 && mockMessage == "" && mockOutput == "" && mockCount == 1 && !mockAppend {
+		if len(args) == 0 && mockMessage == "" && mockTplFile == "" && mockOutput == "" && mockCount == 1 && !mockAppend {
 			cmd.Help()
 			return
 		}
@@ -116,27 +118,62 @@ variables:
 			}
 		}
 
-		if mockMessage == "" {
-			fmt.Fprintln(os.Stderr, "错误: 必须使用 -m/--message 指定消息模板")
+		if mockMessage == "" && mockTplFile == "" {
+			fmt.Fprintln(os.Stderr, "错误: 必须使用 -m/--message 或 --template-file 指定消息模板")
+			os.Exit(1)
+		}
+
+		// 指定格式时预先解析优先级，mock默认原样输出模板结果
+		priority, err := mockPriority()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		clock, err := mockClock()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 			os.Exit(1)
 		}
 
 		// 创建模板引擎
-		// 检查当前目录下是否存在template.yml
-		configPath := "template.yml"
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			configPath = "" // 如果文件不存在，使用空字符串
+		// 使用 --vars-file 指定的配置，未指定时检查当前目录下是否存在template.yml
+		configPath, err := template.ResolveConfigPath(mockVarsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
 		}
 		verbose := viper.GetBool("verbose")
 		engine := template.NewEngine(configPath, verbose)
 
-		// 加载消息模板
-		engine.LoadTemplate("message", mockMessage)
+		// 指定种子时随机变量可重复生成；写说明行时未指定种子则随机选取一个并记录，同样可以据此重新生成
+		seed := mockSeed
+		if mockAnnotate && !cmd.Flags().Changed("seed") {
+			seed = time.Now().UnixNano()
+		}
+		if mockAnnotate || cmd.Flags().Changed("seed") {
+			engine.SetSeed(seed)
+		}
+
+		// 加载消息模板，-m @文件 时从文件读取
+		if mockMessage != "" {
+			text, err := readMessageTemplate(mockMessage)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			engine.LoadTemplate("message", text)
+		} else if err := engine.LoadStructuredTemplate("message", mockTplFile); err != nil {
+			fmt.Fprintf(os.Stderr, "加载模板文件失败: %v\n", err)
+			os.Exit(1)
+		}
 
 		// 生成指定数量的消息
 		var messages []string
 		for i := 0; i < mockCount; i++ {
 			msg, err := engine.GenerateMessage("message")
+			if err == nil && mockFormat != "" {
+				msg, err = frameMockMessage(msg, priority, clock)
+			}
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "生成第 %d 条消息时出错: %v\n", i+1, err)
 				os.Exit(1)
@@ -144,6 +181,15 @@ variables:
 			messages = append(messages, msg)
 		}
 
+		// 按需在末尾添加说明行，记录生成数量、种子和模板
+		if mockAnnotate {
+			source := mockMessage
+			if source == "" {
+				source = "@" + mockTplFile
+			}
+			messages = append(messages, template.FormatAnnotation(mockCount, seed, source))
+		}
+
 		// 将结果写入文件或输出到标准输出
 		output := strings.Join(messages, "\n") + "\n"
 		if mockOutput != "" {
@@ -178,9 +224,140 @@ variables:
 	},
 }
 
+// printVariables 列出内置变量和已加载的自定义变量的用法、说明和示例
+func printVariables(engine *template.Engine) {
+	printGroup := func(title string, infos []template.VariableInfo) {
+		fmt.Printf("%s:\n", title)
+		for _, v := range infos {
+			usage := v.Usage
+			if len(v.Aliases) > 0 {
+				usage += " (别名: " + strings.Join(v.Aliases, ", ") + ")"
+			}
+			fmt.Printf("  %s\n      %s\n      示例: %s\n", usage, v.Description, v.Example)
+		}
+	}
+
+	printGroup("内置变量", template.BuiltinVariables())
+	if custom := engine.CustomVariables(); len(custom) > 0 {
+		fmt.Println()
+		printGroup("自定义变量", custom)
+	}
+}
+
+// mockPriority 校验mock的 --format 并按 --facility、--severity 计算优先级
+// 未指定格式时原样输出，不需要优先级
+func mockPriority() (int, error) {
+	switch strings.ToLower(mockFormat) {
+	case "":
+		return 0, nil
+	case "rfc3164", "rfc5424", "5424", "json":
+	default:
+		return 0, fmt.Errorf("不支持的格式: %s (可选 rfc3164/rfc5424/json)", mockFormat)
+	}
+	facility, err := syslog.ParseFacility(mockFacility)
+	if err != nil {
+		return 0, err
+	}
+	severity, err := syslog.ParseSeverity(mockSeverity)
+	if err != nil {
+		return 0, err
+	}
+	return facility*8 + severity, nil
+}
+
+// mockClock 按 --now 返回--format输出时间戳使用的时钟，未指定时为系统时间
+func mockClock() (syslog.Clock, error) {
+	if mockNow == "" {
+		return syslog.SystemClock, nil
+	}
+	now, err := time.Parse(time.RFC3339Nano, mockNow)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 --now 时间 %s (格式如 2024-01-02T15:04:05Z): %w", mockNow, err)
+	}
+	return syslog.FixedClock(now), nil
+}
+
+// frameMockMessage 将模板生成的内容包装为完整的syslog行
+// 与发送时一样处理开头的PRI指令和LEVEL变量，输出的行可以用 send --raw 原样重放
+func frameMockMessage(content string, priority int, clock syslog.Clock) (string, error) {
+	if pri, rest, ok, err := template.CutPriDirective(content); err != nil {
+		return "", err
+	} else if ok {
+		priority, content = pri, rest
+	}
+	content = template.FillLevel(content, priority%8)
+
+	hostname := "localhost"
+	if h, err := os.Hostname(); err == nil {
+		hostname = h
+	}
+	msg := syslog.NewMessageWithClock(clock, priority, hostname, "syslog_go", content, syslog.ParseFormat(mockFormat))
+	return msg.Format(), nil
+}
+
+// readMessageTemplate 解析 -m/--message 的值
+// 以 @ 开头时读取其后路径的文件内容作为模板（去掉末尾的一个换行），
+// 以 @@ 开头时表示以 @ 开头的字面消息，其他值原样返回
+func readMessageTemplate(value string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	if strings.HasPrefix(value, "@@") {
+		return value[1:], nil
+	}
+	path := value[1:]
+	if path == "" {
+		return "", fmt.Errorf("-m @ 后缺少模板文件路径")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取消息模板文件失败: %w", err)
+	}
+	content := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(content, "\r"), nil
+}
+
+// reloadOnHangup 收到SIGHUP时重新加载发送器的模板，结果输出到标准错误（JSON日志格式下作为reload事件输出）
+// 返回值：
+//   - func(): 停止监听SIGHUP
+func reloadOnHangup(s *sender.Sender) func() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	stop := make(chan struct{})
+	go func() {
+		logger := s.Logger()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-hup:
+			}
+			err := s.ReloadTemplates()
+			switch {
+			case logger.JSON() && err != nil:
+				logger.Error("重新加载模板失败: "+err.Error(), "event", "reload", "error", err.Error())
+			case logger.JSON():
+				logger.Info("已重新加载模板", "event", "reload")
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "重新加载模板失败，继续使用原来的模板: %v\n", err)
+			default:
+				fmt.Fprintln(os.Stderr, "已重新加载模板")
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(hup)
+		close(stop)
+	}
+}
+
 var (
-	message string
-	cfg     *config.Config
+	message           string
+	severityTemplates []string
+	dataFiles         []string
+	structuredData    []string
+	fields            []string
+	cfg               *config.Config
 )
 
 // rootCmd 代表发送命令
@@ -226,7 +403,7 @@ var sendCmd = &cobra.Command{
 	Long: `发送Syslog消息
 
 主要功能:
-✓ 支持UDP/TCP协议
+✓ 支持UDP/TCP/TLS协议
 ✓ 兼容RFC3164/5424格式
 ✓ 可配置发送速率(EPS)
 ✓ 支持模板化消息生成
@@ -239,19 +416,134 @@ var sendCmd = &cobra.Command{
 		// 从命令行参数更新配置
 		cfg.Target = viper.GetString("target")
 		cfg.SourceIP = viper.GetString("source_ip")
+		cfg.SourcePort = viper.GetInt("source_port")
+		cfg.Spoof = viper.GetBool("spoof")
 		cfg.Protocol = viper.GetString("protocol")
+		// --target带scheme（如 tcp://host:601）且未显式指定--protocol时，由scheme决定协议
+		if !viper.IsSet("protocol") && config.HasTargetScheme(cfg.Target) {
+			cfg.Protocol = ""
+		}
+		cfg.UseTLS = viper.GetBool("use_tls")
+		// 只指定--tls时默认使用TCP
+		if cfg.UseTLS && !viper.IsSet("protocol") && !config.HasTargetScheme(cfg.Target) {
+			cfg.Protocol = "tcp"
+		}
+		cfg.TLSCAFile = viper.GetString("tls_ca_file")
+		cfg.TLSCertFile = viper.GetString("tls_cert_file")
+		cfg.TLSKeyFile = viper.GetString("tls_key_file")
+		cfg.TLSServerName = viper.GetString("tls_server_name")
+		cfg.InsecureSkipVerify = viper.GetBool("insecure_skip_verify")
 		cfg.EPS = viper.GetInt("eps")
 		cfg.Duration = viper.GetDuration("duration")
+		cfg.BatchSize = viper.GetInt("batch_size")
+		cfg.Rounds = viper.GetInt("rounds")
+		cfg.RoundSize = viper.GetInt("round_size")
+		cfg.Total = viper.GetInt64("total")
+		cfg.Over = viper.GetDuration("over")
+		// 按总量发送时由总量和时长换算EPS，不能再单独指定速率和持续时间
+		if cfg.Total > 0 || cfg.Over > 0 {
+			if viper.IsSet("eps") || viper.IsSet("duration") {
+				fmt.Fprintf(os.Stderr, "错误: --total/--over 已决定发送速率和时长，不能与 --eps 或 --duration 同时指定\n")
+				os.Exit(1)
+			}
+			cfg.ApplyTotal()
+		}
+		cfg.InterArrival = viper.GetString("inter_arrival")
+		cfg.MaxEPS = viper.GetInt("max_eps")
+		cfg.Burst = viper.GetInt("burst")
+		cfg.Limiter = strings.ToLower(viper.GetString("limiter"))
+		cfg.LoadGen = viper.GetBool("loadgen")
+		cfg.TargetReceivedEPS = viper.GetInt("target_received_eps")
+		cfg.FeedbackURL = viper.GetString("feedback_url")
+		cfg.FeedbackInterval = viper.GetDuration("feedback_interval")
+		cfg.Concurrency = viper.GetInt("concurrency")
+		cfg.MaxConcurrency = viper.GetInt("max_concurrency")
+		cfg.MaxFailures = viper.GetString("max_failures")
+		cfg.RequireEPSTolerance = viper.GetString("require_eps_tolerance")
+		cfg.Timeout = viper.GetDuration("timeout")
+		cfg.BufferSize = viper.GetInt("buffer_size")
 		cfg.Format = viper.GetString("format")
-		cfg.DataFile = viper.GetString("data_file")
-		cfg.Facility = viper.GetInt("facility")
-		cfg.Severity = viper.GetInt("severity")
+		cfg.TimeLayout = viper.GetString("time_layout")
+		// 命令行指定的数据文件覆盖配置文件中的data_file
+		if len(dataFiles) > 0 {
+			cfg.DataFile = ""
+			cfg.DataFiles = dataFiles
+		} else {
+			cfg.DataFile = viper.GetString("data_file")
+		}
+		cfg.TemplateFile = viper.GetString("template_file")
+		cfg.Raw = viper.GetBool("raw")
+		cfg.LogFormat = viper.GetString("log_format")
+		cfg.Origin = viper.GetBool("origin")
+		cfg.FQDN = viper.GetBool("fqdn")
+		cfg.ProcID = viper.GetString("proc_id")
+		// 命令行指定的结构化数据覆盖配置文件中的structured_data
+		if len(structuredData) > 0 {
+			cfg.StructuredData = structuredData
+		}
+		// 命令行指定的附加字段覆盖配置文件中的fields
+		if len(fields) > 0 {
+			cfg.Fields = fields
+		}
+		cfg.EnterpriseID = viper.GetInt("enterprise_id")
+		// facility/severity标志未注册时保留默认值（local0.info），避免被置为0
+		if viper.IsSet("facility") {
+			cfg.Facility = viper.GetInt("facility")
+		}
+		if viper.IsSet("severity") {
+			cfg.Severity = viper.GetInt("severity")
+		}
+		cfg.RetryCount = viper.GetInt("retry_count")
+		cfg.ReconnectEvery = viper.GetInt("reconnect_every")
+		cfg.RetryInterval = viper.GetDuration("retry_interval")
 		cfg.Verbose = viper.GetBool("verbose")
+		cfg.Quiet = viper.GetBool("quiet")
+		cfg.DryRun = viper.GetBool("dry_run")
+		cfg.NullSink = viper.GetBool("null_sink")
+		cfg.EnableStats = viper.GetBool("enable_stats")
+		cfg.StatsInterval = viper.GetDuration("stats_interval")
 		cfg.Encoding = strings.ToLower(viper.GetString("charset"))
+		cfg.AppendNewline = strings.ToLower(viper.GetString("append_newline"))
+		cfg.Compress = viper.GetBool("compress")
+		cfg.Framing = strings.ToLower(viper.GetString("framing"))
+		cfg.VarsFile = viper.GetString("vars_file")
+
+		cfg.SeverityMix = viper.GetString("severity_mix")
+		cfg.FacilityMix = viper.GetString("facility_mix")
+		if len(severityTemplates) > 0 {
+			cfg.SeverityTemplates = make(map[string]string, len(severityTemplates))
+			for _, item := range severityTemplates {
+				severity, tmpl, ok := strings.Cut(item, "=")
+				if !ok {
+					fmt.Fprintf(os.Stderr, "错误: 无效的Severity模板 %q，格式应为 Severity=模板\n", item)
+					os.Exit(1)
+				}
+				cfg.SeverityTemplates[strings.TrimSpace(severity)] = tmpl
+			}
+		}
 
-		// 如果指定了消息内容，直接设置到配置中
+		// 如果指定了消息内容，直接设置到配置中，-m @文件 时从文件读取
 		if message != "" {
-			cfg.Message = message
+			text, err := readMessageTemplate(message)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.Message = text
+		}
+
+		// 验证配置
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "配置验证失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 显式指定的自定义变量配置文件必须存在
+		if cfg.VarsFile != "" {
+			if _, err := template.ResolveConfigPath(cfg.VarsFile); err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
 		// 创建并启动发送器
@@ -260,12 +552,34 @@ var sendCmd = &cobra.Command{
 			fmt.Fprintf(os.Stderr, "发送器创建失败: %v\n", err)
 			os.Exit(1)
 		}
+		// 结束时关闭连接，压缩连接需要在关闭前写出zlib流结尾
+		defer s.Stop()
+		// 收到SIGHUP时重新加载模板文件和自定义变量配置
+		defer reloadOnHangup(s)()
+
+		if !cfg.Quiet {
+			logger := s.Logger()
+			logger.Info("开始发送Syslog消息到 "+cfg.Target,
+				"event", "start", "target", cfg.Target, "eps", cfg.EPS, "inter_arrival", cfg.InterArrival, "max_eps", cfg.MaxEPS, "duration", cfg.Duration)
+			if !logger.JSON() {
+				if cfg.InterArrival != "" && cfg.MaxEPS > 0 {
+					fmt.Printf("消息间隔分布: %s, 速率上限: %d EPS, 持续时间: %v\n", cfg.InterArrival, cfg.MaxEPS, cfg.Duration)
+				} else if cfg.InterArrival != "" {
+					fmt.Printf("消息间隔分布: %s, 持续时间: %v\n", cfg.InterArrival, cfg.Duration)
+				} else if cfg.Total > 0 {
+					fmt.Printf("发送总量: %d 条, 计划时长: %v (%.2f EPS)\n", cfg.Total, cfg.Over, cfg.TotalRate())
+				} else if cfg.EPS == 0 {
+					fmt.Printf("发送速率: 不限速 (空输出), 持续时间: %v\n", cfg.Duration)
+				} else {
+					fmt.Printf("发送速率: %d EPS, 持续时间: %v\n", cfg.EPS, cfg.Duration)
+				}
+			}
+		}
 
-		fmt.Printf("开始发送Syslog消息到 %s\n", cfg.Target)
-		fmt.Printf("发送速率: %d EPS, 持续时间: %v\n", cfg.EPS, cfg.Duration)
-
-		if err := s.Start(); err != nil {
+		if _, err := s.Start(); err != nil {
 			fmt.Fprintf(os.Stderr, "发送失败: %v\n", err)
+			// os.Exit不执行defer，先关闭连接（写出压缩流结尾、排空服务器回复）再退出
+			s.Stop()
 			os.Exit(1)
 		}
 	},
@@ -279,40 +593,151 @@ func init() {
 	rootCmd.AddCommand(mockCmd)
 	rootCmd.AddCommand(sendCmd)
 
+	// 全局标志
+	rootCmd.PersistentFlags().String("log-format", logging.FormatText, "日志格式 (text/json)，json时统计和诊断信息以JSON行输出，便于自动化解析")
+	viper.BindPFlag("log_format", rootCmd.PersistentFlags().Lookup("log-format"))
+
 	// 添加命令行参数
-	mockCmd.Flags().StringVarP(&mockMessage, "message", "m", "", "指定消息模板 (支持模板变量，使用 {{变量名:参数}} 格式)")
+	mockCmd.Flags().StringVarP(&mockMessage, "message", "m", "", "指定消息模板 (支持模板变量，使用 {{变量名:参数}} 格式)，@文件 从文件读取模板")
 	mockCmd.Flags().StringVarP(&mockOutput, "output", "o", "", "输出文件路径 (默认输出到标准输出)")
 	mockCmd.Flags().IntVarP(&mockCount, "count", "n", 1, "生成消息的数量")
 	mockCmd.Flags().BoolVarP(&mockAppend, "append", "a", false, "追加到输出文件 (默认覆盖文件)")
 	mockCmd.Flags().BoolVarP(&mockTemplate, "template", "t", false, "生成自定义模板文件 template.yml")
+	mockCmd.Flags().StringVar(&mockTplFile, "template-file", "", "结构化模板文件 (YAML/JSON，包含format和fields)")
+	mockCmd.Flags().StringVar(&mockVarsFile, "vars-file", "", "自定义变量配置文件 (默认使用当前目录下的 template.yml)")
+	mockCmd.Flags().BoolVar(&mockListVars, "list-variables", false, "列出支持的模板变量及其用法和示例 (包括已加载的自定义变量)")
+	mockCmd.Flags().StringVarP(&mockFormat, "format", "f", "", "输出完整的syslog行 (rfc3164/rfc5424) 或JSON事件 (json)，默认原样输出模板结果")
+	mockCmd.Flags().StringVar(&mockFacility, "facility", "local0", "--format 输出使用的Facility (名称或0-23)")
+	mockCmd.Flags().StringVar(&mockSeverity, "severity", "info", "--format 输出使用的Severity (名称或0-7)")
+	mockCmd.Flags().StringVar(&mockNow, "now", "", "--format 输出使用的固定时间戳 (RFC3339，如 2024-01-02T15:04:05Z)，便于生成可重复的输出")
+	mockCmd.Flags().Int64Var(&mockSeed, "seed", 0, "随机变量使用的种子，相同种子和模板生成相同的随机值 (时间和SEQ等变量除外)")
+	mockCmd.Flags().BoolVar(&mockAnnotate, "annotate", false, "在末尾添加说明行 '# generated N lines seed=... template=...'，未指定 --seed 时记录随机选取的种子；send -D 读取时跳过该行")
 	mockCmd.Flags().BoolP("verbose", "v", false, "显示详细信息")
 	viper.BindPFlag("verbose", mockCmd.Flags().Lookup("verbose"))
 
 	// 发送命令标志
-	sendCmd.Flags().StringVarP(&message, "message", "m", "", "指定消息内容 (支持模板变量，使用 {{变量名:参数}} 格式，详见mock命令)")
-	sendCmd.Flags().StringP("target", "t", "localhost:514", "目标服务器地址")
-	sendCmd.Flags().StringP("source-ip", "s", "", "源IP地址")
-	sendCmd.Flags().StringP("protocol", "p", "udp", "传输协议 (udp/tcp)")
+	sendCmd.Flags().StringVarP(&message, "message", "m", "", "指定消息内容 (支持模板变量，使用 {{变量名:参数}} 格式，详见mock命令)，@文件 从文件读取模板")
+	sendCmd.Flags().StringP("target", "t", "localhost:514", "目标服务器地址 (支持 udp://、tcp://、unix:///dev/log 等scheme推断协议；逗号分隔多个目标或用 10.0.0.[1-10]:514 展开范围，每条消息发送到所有目标)")
+	sendCmd.Flags().StringP("source-ip", "s", "", "源IP地址 (本机地址或网卡别名直接绑定)")
+	sendCmd.Flags().Int("source-port", 0, "源端口，用于测试按源端口匹配的防火墙规则 (默认 0 由系统分配，固定端口时通常只能使用一个连接)")
+	sendCmd.Flags().Bool("spoof", false, "允许对非本机源IP使用原始套接字伪造 (需要root权限)")
+	sendCmd.Flags().StringP("protocol", "p", "udp", "传输协议 (udp/tcp/tls/unix，tls即启用TLS的tcp)，显式指定时覆盖--target中的scheme")
+	sendCmd.Flags().Bool("tls", false, "TCP连接使用TLS加密 (RFC5425，也可用 tls:// 目标或 -p tls)")
+	sendCmd.Flags().String("tls-ca", "", "验证服务器证书的CA证书文件 (PEM，默认使用系统根证书)")
+	sendCmd.Flags().String("tls-cert", "", "客户端证书文件 (PEM，服务器要求双向认证时使用，需同时指定--tls-key)")
+	sendCmd.Flags().String("tls-key", "", "客户端私钥文件 (PEM)")
+	sendCmd.Flags().String("tls-server-name", "", "验证证书时使用的服务器名 (默认取目标地址中的主机名)")
+	sendCmd.Flags().Bool("tls-insecure", false, "不验证服务器证书 (只用于测试)")
 	sendCmd.Flags().IntP("eps", "e", 10, "每秒事件数")
 	sendCmd.Flags().DurationP("duration", "d", 60*time.Second, "发送持续时间")
-	sendCmd.Flags().StringP("format", "f", "rfc3164", "日志格式 (rfc3164/rfc5424)")
-	sendCmd.Flags().StringP("data-file", "D", "", "数据文件")
+	sendCmd.Flags().String("inter-arrival", "", "消息间隔分布，代替EPS匀速发送 (exp:mean=100ms 或 uniform:min=50ms,max=150ms)")
+	sendCmd.Flags().Int("max-eps", 0, "按 --inter-arrival 发送时的速率上限，快于上限的突发顺延发送 (0为不限制)")
+	sendCmd.Flags().String("limiter", config.LimiterInterval, "限速方式 (interval 固定间隔/token-bucket 令牌桶，暂停后可补发最多 --burst 条)")
+	sendCmd.Flags().Int("burst", 0, "发送暂停后一次最多补发的消息数 (固定间隔下只在EPS超过1000时生效，0为4ms的量；令牌桶下为桶容量，0为EPS)")
+	sendCmd.Flags().Int("target-received-eps", 0, "期望服务端实际收到的速率，大于0时根据 --feedback-url 的反馈自动调节EPS")
+	sendCmd.Flags().String("feedback-url", "", "反馈地址，返回服务端收到的消息总数 (如 http://127.0.0.1:9514/metrics，对应 server --metrics-addr)")
+	sendCmd.Flags().Duration("feedback-interval", 2*time.Second, "读取反馈并调节EPS的间隔")
+	sendCmd.Flags().Bool("loadgen", false, "负载生成模式：固定速率产生发送票据由工作协程池消费，分别统计提供负载和实际发送")
+	sendCmd.Flags().Int("buffer-size", 1000, "loadgen模式下发送票据队列的容量")
+	sendCmd.Flags().Int("concurrency", 1, "发送工作协程数（每个协程使用连接池中的一个连接）")
+	sendCmd.Flags().Int("max-concurrency", 0, "实际速率持续低于目标EPS时自动增加工作协程，最多到该数量 (0 表示只告警)")
+	sendCmd.Flags().String("max-failures", "", "失败熔断：失败总数超过N条 (如 100) 或最近一段时间内失败比例超过阈值 (如 5%、5%/30s，默认窗口10s) 时提前结束并以非0退出")
+	sendCmd.Flags().String("require-eps-tolerance", "", "实际平均速率偏离目标速率超过容差 (如 5%) 时以非0退出，用于在CI中确认达到了预期负载")
+	sendCmd.Flags().Int("batch-size", 1, "每次系统调用发送的消息条数 (大于1时批量发送，伪造源IP的UDP使用sendmmsg)")
+	sendCmd.Flags().Int("rounds", 0, "先生成固定的消息集合，按速率逐字节相同地重放N轮后结束 (忽略 --duration)")
+	sendCmd.Flags().Int("round-size", 0, "每轮的消息条数 (默认：只使用数据文件时为文件总行数，否则为EPS)")
+	sendCmd.Flags().Int64("total", 0, "在 --over 时长内匀速发送的消息总量，发送完即结束 (代替 --eps 和 --duration)")
+	sendCmd.Flags().Duration("over", 0, "发送 --total 条消息的计划时长 (如 1h)，速率为 总量/秒数")
+	sendCmd.Flags().StringP("format", "f", "rfc3164", "日志格式 (rfc3164/rfc5424/json)，json时每条消息为一个JSON对象")
+	sendCmd.Flags().String("time-layout", "", "RFC3164时间戳的Go时间格式 (如 'Jan _2 15:04:05' 或 'Jan 02 15:04:05 MST')，默认 'Jan 02 15:04:05'")
+	sendCmd.Flags().Bool("raw", false, "原样发送消息内容，不添加优先级和时间戳等头部 (适合重放抓包的完整syslog行)")
+	sendCmd.Flags().Bool("fqdn", false, "消息的主机名使用本机的完全限定域名 (启动时解析一次，失败时使用短主机名)")
+	sendCmd.Flags().String("proc-id", "", "消息的进程ID (RFC5424 PROCID、RFC3164 TAG[PID])：固定值、self (发送进程的PID) 或模板 (如 '{{SEQ}}')，默认不输出")
+	sendCmd.Flags().Bool("origin", false, "为每条RFC5424消息添加origin结构化数据 (软件名、版本和源IP)，部分严格的接收端会拒绝未知的SD-ID")
+	sendCmd.Flags().Int("enterprise-id", config.DefaultEnterpriseID, "结构化数据SD-ID使用的私有企业号 (如 origin@<企业号>)，默认值为RFC 5612的示例企业号")
+	sendCmd.Flags().StringArrayVar(&fields, "field", nil, "为每条JSON格式 (--format json) 的消息添加一个顶层字段，格式 键=值，可重复指定，值支持模板变量 (如 'user={{ENUM:alice,bob}}')")
+	sendCmd.Flags().StringArrayVar(&structuredData, "sd", nil, "为每条RFC5424消息添加一个结构化数据元素，可重复指定并按顺序输出，参数值支持模板变量 (如 'meta@32473 seq=\"{{SEQ}}\"')")
+	sendCmd.Flags().StringArrayVarP(&dataFiles, "data-file", "D", nil, "数据文件，可重复指定或使用通配符 (如 logs/*.log)，用 路径=权重 按权重混合读取")
+	sendCmd.Flags().String("template-file", "", "结构化模板文件 (YAML/JSON，包含format和fields)")
 	sendCmd.Flags().StringP("charset", "c", "utf-8", "字符集/编码 (utf-8/gbk)")
+	sendCmd.Flags().Duration("timeout", 5*time.Second, "建立连接和单次写入的超时时间 (写入超时的消息计为失败，连接会被重建)")
+	sendCmd.Flags().Int("retry-count", 3, "初始化连接失败时的重试次数")
+	sendCmd.Flags().Int("reconnect-every", 0, "每个连接写入N条消息后关闭并重新建立，用于压测接收端的连接建立和拆除 (0表示一直复用)")
+	sendCmd.Flags().Duration("retry-interval", time.Second, "重试基础间隔 (指数退避并带随机抖动)")
+	sendCmd.Flags().String("append-newline", config.NewlineAuto, "消息末尾追加换行 (auto/always/never，auto时仅TCP追加)")
+	sendCmd.Flags().Bool("compress", false, "TCP连接使用zlib压缩 (需LF分帧，verbose模式下输出压缩率)")
+	sendCmd.Flags().String("framing", config.FramingLF, "TCP分帧方式 (lf/octet，octet时每条消息前加 \"长度 空格\"，消息可包含换行)")
+	sendCmd.Flags().String("vars-file", "", "自定义变量配置文件 (默认使用当前目录下的 template.yml)")
+	sendCmd.Flags().String("severity-mix", "", "按权重随机选择Severity，如 info=70,warning=20,err=10 (名称或0-7数值)")
+	sendCmd.Flags().String("facility-mix", "", "按权重随机选择Facility，如 local0=80,auth=20 (名称或0-23数值)，最终统计对照实际分布")
+	sendCmd.Flags().StringArrayVar(&severityTemplates, "severity-template", nil, "为指定Severity使用专用消息模板，格式 Severity=模板，可重复指定")
 	// sendCmd.Flags().IntP("facility", "L", 16, "Syslog Facility (0-23)")
 	// sendCmd.Flags().IntP("severity", "S", 6, "Syslog Severity (0-7)")
+	sendCmd.Flags().Bool("enable-stats", true, "启用周期统计输出 (--enable-stats=false 关闭，最终统计不受影响)")
+	sendCmd.Flags().Duration("stats-interval", 5*time.Second, "周期统计的输出间隔 (为0时只输出最终统计)")
+	sendCmd.Flags().BoolP("quiet", "q", false, "静默模式，不输出统计信息")
+	sendCmd.Flags().Bool("dry-run", false, "演练模式，按配置的速率和时长生成消息并输出到标准输出，不发送到网络")
+	sendCmd.Flags().Bool("null-sink", false, "空输出：消息写入丢弃数据的连接并计数，不访问网络，用于测量消息生成和限速的吞吐量；此时 -e 0 表示不限速")
 	sendCmd.Flags().BoolP("verbose", "v", false, "显示详细信息")
 
 	// 绑定标志到viper
 	viper.BindPFlag("target", sendCmd.Flags().Lookup("target"))
 	viper.BindPFlag("source_ip", sendCmd.Flags().Lookup("source-ip"))
+	viper.BindPFlag("source_port", sendCmd.Flags().Lookup("source-port"))
+	viper.BindPFlag("spoof", sendCmd.Flags().Lookup("spoof"))
 	viper.BindPFlag("protocol", sendCmd.Flags().Lookup("protocol"))
+	viper.BindPFlag("use_tls", sendCmd.Flags().Lookup("tls"))
+	viper.BindPFlag("tls_ca_file", sendCmd.Flags().Lookup("tls-ca"))
+	viper.BindPFlag("tls_cert_file", sendCmd.Flags().Lookup("tls-cert"))
+	viper.BindPFlag("tls_key_file", sendCmd.Flags().Lookup("tls-key"))
+	viper.BindPFlag("tls_server_name", sendCmd.Flags().Lookup("tls-server-name"))
+	viper.BindPFlag("insecure_skip_verify", sendCmd.Flags().Lookup("tls-insecure"))
 	viper.BindPFlag("eps", sendCmd.Flags().Lookup("eps"))
 	viper.BindPFlag("duration", sendCmd.Flags().Lookup("duration"))
+	viper.BindPFlag("batch_size", sendCmd.Flags().Lookup("batch-size"))
+	viper.BindPFlag("rounds", sendCmd.Flags().Lookup("rounds"))
+	viper.BindPFlag("round_size", sendCmd.Flags().Lookup("round-size"))
+	viper.BindPFlag("total", sendCmd.Flags().Lookup("total"))
+	viper.BindPFlag("over", sendCmd.Flags().Lookup("over"))
+	viper.BindPFlag("inter_arrival", sendCmd.Flags().Lookup("inter-arrival"))
+	viper.BindPFlag("max_eps", sendCmd.Flags().Lookup("max-eps"))
+	viper.BindPFlag("limiter", sendCmd.Flags().Lookup("limiter"))
+	viper.BindPFlag("burst", sendCmd.Flags().Lookup("burst"))
+	viper.BindPFlag("target_received_eps", sendCmd.Flags().Lookup("target-received-eps"))
+	viper.BindPFlag("feedback_url", sendCmd.Flags().Lookup("feedback-url"))
+	viper.BindPFlag("feedback_interval", sendCmd.Flags().Lookup("feedback-interval"))
+	viper.BindPFlag("loadgen", sendCmd.Flags().Lookup("loadgen"))
+	viper.BindPFlag("buffer_size", sendCmd.Flags().Lookup("buffer-size"))
+	viper.BindPFlag("concurrency", sendCmd.Flags().Lookup("concurrency"))
+	viper.BindPFlag("max_concurrency", sendCmd.Flags().Lookup("max-concurrency"))
+	viper.BindPFlag("max_failures", sendCmd.Flags().Lookup("max-failures"))
+	viper.BindPFlag("require_eps_tolerance", sendCmd.Flags().Lookup("require-eps-tolerance"))
 	viper.BindPFlag("format", sendCmd.Flags().Lookup("format"))
-	viper.BindPFlag("data_file", sendCmd.Flags().Lookup("data-file"))
+	viper.BindPFlag("time_layout", sendCmd.Flags().Lookup("time-layout"))
+	viper.BindPFlag("raw", sendCmd.Flags().Lookup("raw"))
+	viper.BindPFlag("origin", sendCmd.Flags().Lookup("origin"))
+	viper.BindPFlag("fqdn", sendCmd.Flags().Lookup("fqdn"))
+	viper.BindPFlag("proc_id", sendCmd.Flags().Lookup("proc-id"))
+	viper.BindPFlag("enterprise_id", sendCmd.Flags().Lookup("enterprise-id"))
+	viper.BindPFlag("template_file", sendCmd.Flags().Lookup("template-file"))
 	viper.BindPFlag("charset", sendCmd.Flags().Lookup("charset"))
+	viper.BindPFlag("timeout", sendCmd.Flags().Lookup("timeout"))
+	viper.BindPFlag("retry_count", sendCmd.Flags().Lookup("retry-count"))
+	viper.BindPFlag("reconnect_every", sendCmd.Flags().Lookup("reconnect-every"))
+	viper.BindPFlag("retry_interval", sendCmd.Flags().Lookup("retry-interval"))
+	viper.BindPFlag("append_newline", sendCmd.Flags().Lookup("append-newline"))
+	viper.BindPFlag("compress", sendCmd.Flags().Lookup("compress"))
+	viper.BindPFlag("framing", sendCmd.Flags().Lookup("framing"))
+	viper.BindPFlag("vars_file", sendCmd.Flags().Lookup("vars-file"))
+	viper.BindPFlag("severity_mix", sendCmd.Flags().Lookup("severity-mix"))
+	viper.BindPFlag("facility_mix", sendCmd.Flags().Lookup("facility-mix"))
 	// viper.BindPFlag("facility", sendCmd.Flags().Lookup("facility"))
 	// viper.BindPFlag("severity", sendCmd.Flags().Lookup("severity"))
+	viper.BindPFlag("enable_stats", sendCmd.Flags().Lookup("enable-stats"))
+	viper.BindPFlag("stats_interval", sendCmd.Flags().Lookup("stats-interval"))
+	viper.BindPFlag("quiet", sendCmd.Flags().Lookup("quiet"))
+	viper.BindPFlag("dry_run", sendCmd.Flags().Lookup("dry-run"))
+	viper.BindPFlag("null_sink", sendCmd.Flags().Lookup("null-sink"))
 	viper.BindPFlag("verbose", sendCmd.Flags().Lookup("verbose"))
 	viper.BindPFlag("message", sendCmd.Flags().Lookup("message"))
 }
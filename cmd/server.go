@@ -2,26 +2,57 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal" // 提供信号处理功能
-	"syscall"    // 系统调用包
+	"sort"
+	"strings"
+	"syscall" // 系统调用包
+	"time"
 
 	"github.com/spf13/cobra" // 命令行框架
+	"github.com/spf13/viper" // 读取全局的日志格式
+
+	"syslog_go/pkg/logging" // 诊断日志
 	"syslog_go/pkg/server"  // Syslog服务器实现
 )
 
 // 命令行参数
 var (
-	serverHost string // 服务器监听的主机地址
-	serverPort int    // 服务器监听的端口号
+	serverHost    string // 服务器监听的主机地址
+	serverPort    int    // 服务器监听的端口号
+	serverUDPPort int    // UDP监听端口，未指定时与serverPort相同
+	serverTCPPort int    // TCP监听端口，未指定时与serverPort相同
+
+	serverLogTemplate string // 解析后消息的输出模板
+	serverBufferSize  int    // 单次读取的缓冲区大小
+	serverReqFormat   string // 要求的消息格式
+	serverLenient     bool   // 宽松解析优先级格式有误的消息
+
+	serverOutput       string   // 消息输出文件
+	serverOutputFormat string   // 输出文件格式
+	serverSample       int      // 抽样间隔
+	serverQuiet        bool     // 静默模式
+	serverMetricsAddr  string   // HTTP计数器接口的监听地址
+	serverHealthAddr   string   // 健康检查接口的监听地址
+	serverSeqField     string   // 消息序号字段名
+	serverAck          string   // TCP连接的确认模式
+	serverTLSCert      string   // TLS服务器证书文件
+	serverTLSKey       string   // TLS服务器私钥文件
+	serverAllowSrc     []string // 允许的来源地址
+	serverDenySrc      []string // 拒绝的来源地址
+
+	serverStatsInterval time.Duration // 定期输出按格式统计的间隔
+	serverStatsJSON     string        // 停止时写出JSON统计摘要的路径，"-" 为标准输出
+	serverIdleTimeout   time.Duration // 超过该时长未收到消息时自动停止
 )
 
 // serverCmd 表示服务器命令
 // 它实现了一个可以同时监听UDP和TCP的Syslog服务器
 var serverCmd = &cobra.Command{
 	// 命令名称
-	Use:   "server",
+	Use: "server",
 	// 简短描述
 	Short: "启动Syslog测试服务器",
 	// 详细描述和使用示例
@@ -38,12 +69,135 @@ var serverCmd = &cobra.Command{
   syslog_go server -H 0.0.0.0 -p 514
 
   # 仅本地监听1514端口
-  syslog_go server -H 127.0.0.1 -p 1514`,
+  syslog_go server -H 127.0.0.1 -p 1514
+
+  # UDP和TCP使用不同端口（如UDP 514、TCP 601）
+  syslog_go server -H 0.0.0.0 --udp-port 514 --tcp-port 601
+
+  # 只监听TCP
+  syslog_go server -p 1514 --udp-port 0
+
+  # 自定义输出格式（Go text/template，字段来自解析后的消息）
+  syslog_go server -p 1514 --log-template '{{.Hostname}} {{.Content}}'
+
+  # 原样保存收到的消息，之后可用 send --data-file capture.log --raw 重放
+  syslog_go server -p 1514 --quiet --output capture.log --output-format raw
+
+  # 高速率压测时每100条只输出1条JSON记录
+  syslog_go server -p 1514 --quiet --output sample.jsonl --output-format json --sample 100
+
+  # 统计UDP丢包和乱序（发送端在消息中带上连续序号）
+  syslog_go server -p 1514 --quiet --seq-field seq
+  syslog_go send -t 127.0.0.1:1514 -m 'seq={{SEQ}} test' -e 5000
+
+  # 多个发送端共用接收端时只检查来自10.0.0.0/24的消息（10.0.0.9除外），其他来源的消息只计数
+  syslog_go server -p 1514 --allow-src 10.0.0.0/24 --deny-src 10.0.0.9
+
+  # TCP端口改为TLS（RFC5425），发送端用 send -t tls://127.0.0.1:6514 --tls-ca cert.pem 测试
+  syslog_go server -p 6514 --udp-port 0 --tls-cert cert.pem --tls-key key.pem
+
+  # 兼容优先级写成 "13: msg" 或 "< 13 >msg" 等不规范形式的设备
+  syslog_go server -p 1514 --lenient
+
+  # 每10秒输出一次按格式（RFC3164/RFC5424/无法解析）统计的消息数和字节数
+  syslog_go server -p 1514 --quiet --stats-interval 10s
+
+  # 停止时将统计摘要（总数、字节数、各格式数量、时长、平均EPS、按来源计数）写成JSON，供测试脚本断言
+  syslog_go server -p 1514 --quiet --stats-json summary.json
+
+  # 作为测试脚本的接收端，30秒未收到消息时自动停止并输出统计，不需要外部进程结束它
+  syslog_go server -p 1514 --quiet --idle-timeout 30s --stats-json summary.json`,
 	// 命令执行函数
 	Run: func(cmd *cobra.Command, args []string) {
 		// 创建服务器实例
 		// NewServer函数接收主机地址和端口参数
 		srv := server.NewServer(serverHost, serverPort)
+		if cmd.Flags().Changed("udp-port") {
+			if err := srv.SetUDPPort(serverUDPPort); err != nil {
+				fmt.Printf("设置UDP端口失败: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if cmd.Flags().Changed("tcp-port") {
+			if err := srv.SetTCPPort(serverTCPPort); err != nil {
+				fmt.Printf("设置TCP端口失败: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := srv.SetLogTemplate(serverLogTemplate); err != nil {
+			fmt.Printf("设置日志模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetBufferSize(serverBufferSize); err != nil {
+			fmt.Printf("设置缓冲区大小失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetAckMode(serverAck); err != nil {
+			fmt.Printf("设置确认模式失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetTLS(serverTLSCert, serverTLSKey); err != nil {
+			fmt.Printf("设置TLS失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetRequireFormat(serverReqFormat); err != nil {
+			fmt.Printf("设置要求格式失败: %v\n", err)
+			os.Exit(1)
+		}
+		// 宽松解析会掩盖格式问题，一致性测试时不允许启用
+		if serverLenient && serverReqFormat != "" {
+			fmt.Println("--lenient 不能与 --require-format 同时使用")
+			os.Exit(1)
+		}
+		srv.SetLenient(serverLenient)
+		if err := srv.SetOutputFormat(serverOutputFormat); err != nil {
+			fmt.Printf("设置输出格式失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetSample(serverSample); err != nil {
+			fmt.Printf("设置抽样间隔失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetSourceFilter(serverAllowSrc, serverDenySrc); err != nil {
+			fmt.Printf("设置来源过滤失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetIdleTimeout(serverIdleTimeout); err != nil {
+			fmt.Printf("设置空闲超时失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err := srv.SetOutput(serverOutput); err != nil {
+			fmt.Printf("设置输出文件失败: %v\n", err)
+			os.Exit(1)
+		}
+		// 服务器日志沿用原有的带时间文本格式，启动前后的提示和统计不带时间
+		logFormat := viper.GetString("log_format")
+		srvLogger, err := logging.New(logFormat, os.Stderr, os.Stderr, true)
+		if err != nil {
+			fmt.Printf("设置日志格式失败: %v\n", err)
+			os.Exit(1)
+		}
+		logger, _ := logging.New(logFormat, os.Stdout, os.Stdout, false)
+		srv.SetLogger(srvLogger)
+		srv.SetQuiet(serverQuiet)
+		srv.SetMetricsAddr(serverMetricsAddr)
+		srv.SetHealthAddr(serverHealthAddr)
+		if err := srv.SetSeqField(serverSeqField); err != nil {
+			fmt.Printf("设置序号字段失败: %v\n", err)
+			os.Exit(1)
+		}
+		// 启动前创建摘要文件，路径不可写时立即报错，而不是在测试结束时才发现
+		var statsJSON *os.File
+		switch serverStatsJSON {
+		case "":
+		case "-":
+			statsJSON = os.Stdout
+		default:
+			if statsJSON, err = os.Create(serverStatsJSON); err != nil {
+				fmt.Printf("创建JSON统计摘要文件失败: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
 		// 启动服务器
 		// Start方法会初始化并启动UDP和TCP监听器
@@ -53,15 +207,56 @@ var serverCmd = &cobra.Command{
 		}
 
 		// 创建信号通道并等待中断信号
-		// 这允许服务器在收到Ctrl+C或终止信号时优雅关闭
+		// 这允许服务器在收到Ctrl+C或终止信号时优雅关闭，设置了空闲超时时服务器也会自动停止
+		// 先取消main中注册的立即退出处理，保证停止后能输出截断和序号等统计
 		sigChan := make(chan os.Signal, 1)
+		signal.Reset(syscall.SIGINT, syscall.SIGTERM)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-		<-sigChan // 阻塞等待信号
+		stopStats := make(chan struct{})
+		if serverStatsInterval > 0 {
+			go reportStats(logger, srv, serverStatsInterval, stopStats)
+		}
+		// 阻塞等待信号或空闲超时
+		select {
+		case <-sigChan:
+		case <-srv.Done():
+		}
+		close(stopStats)
 
 		// 优雅关闭服务器
-		// Stop方法会关闭所有监听器
-		fmt.Println("正在关闭服务器...")
+		// Stop方法会关闭所有监听器，空闲超时已停止时直接返回
+		logger.Info("正在关闭服务器...")
 		srv.Stop()
+		if logger.JSON() {
+			stats := srv.Stats()
+			logger.Info("服务器已停止", "event", "summary", "received", srv.Received(), "truncated", srv.Truncated(),
+				"nonconforming", srv.NonConforming(), "messages_dropped", srv.MessagesDropped(), "parse_errors", srv.ParseErrors(),
+				"filtered", srv.Filtered(), "lenient", srv.LenientParsed(), "rfc3164", stats.RFC3164, "rfc5424", stats.RFC5424, "unparsed", stats.Unparsed)
+		} else {
+			printFormatStats(logger, srv.Stats())
+			printParseErrors(srv.ParseErrors())
+			if n := srv.Truncated(); n > 0 {
+				fmt.Printf("共有 %d 条消息可能被截断\n", n)
+			}
+			if serverReqFormat != "" {
+				fmt.Printf("不符合 %s 格式的消息: %d 条\n", serverReqFormat, srv.NonConforming())
+			}
+			if serverLenient {
+				fmt.Printf("宽松解析的消息: %d 条\n", srv.LenientParsed())
+			}
+			if len(serverAllowSrc) > 0 || len(serverDenySrc) > 0 {
+				fmt.Printf("因来源地址不允许而丢弃的消息: %d 条\n", srv.Filtered())
+			}
+		}
+		if field := srv.SeqField(); field != "" {
+			printSeqStats(logger, field, srv.SeqStats())
+		}
+		if statsJSON != nil {
+			if err := writeStatsJSON(statsJSON, srv.Summary()); err != nil {
+				fmt.Printf("写入JSON统计摘要失败: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	},
 }
 
@@ -76,4 +271,122 @@ func init() {
 	serverCmd.Flags().StringVarP(&serverHost, "host", "H", "127.0.0.1", "监听地址")
 	// -p, --port: 指定服务器监听的端口，默认为514
 	serverCmd.Flags().IntVarP(&serverPort, "port", "p", 514, "监听端口")
-}
\ No newline at end of file
+	// --udp-port/--tcp-port: 分别指定UDP和TCP端口，0表示不监听该协议
+	serverCmd.Flags().IntVar(&serverUDPPort, "udp-port", 0, "UDP监听端口，默认与--port相同，0表示不监听UDP")
+	serverCmd.Flags().IntVar(&serverTCPPort, "tcp-port", 0, "TCP监听端口，默认与--port相同，0表示不监听TCP")
+	// --log-template: 自定义解析后消息的输出格式，默认使用内置格式
+	// --buffer-size: 单次读取的缓冲区大小，超出部分可能被截断
+	serverCmd.Flags().IntVar(&serverBufferSize, "buffer-size", server.DefaultBufferSize, "读取缓冲区大小（字节）")
+	// --require-format: 只接受指定格式，其他消息计为一致性失败
+	serverCmd.Flags().StringVar(&serverReqFormat, "require-format", "", "要求的消息格式 (rfc3164/rfc5424)，不符合的消息计为一致性失败")
+	// --lenient: 兼容优先级格式不规范的设备，默认严格解析
+	serverCmd.Flags().BoolVar(&serverLenient, "lenient", false, "宽松解析：容忍PRI前后或尖括号内的空白、缺少尖括号的 13: 形式以及PRI后没有有效头部的消息，不能与 --require-format 同时使用")
+	// --tls-cert/--tls-key: 同时指定时TCP端口作为TLS接收端（RFC5425），默认不启用
+	serverCmd.Flags().StringVar(&serverTLSCert, "tls-cert", "", "TLS服务器证书文件 (PEM)，与 --tls-key 同时指定时TCP端口只接受TLS连接 (RFC5425)")
+	serverCmd.Flags().StringVar(&serverTLSKey, "tls-key", "", "TLS服务器私钥文件 (PEM)")
+	// --ack: 为需要应用层确认的发送端回复确认，默认不回复
+	serverCmd.Flags().StringVar(&serverAck, "ack", "none", "TCP确认模式: none；byte 每成功解析一条消息回复0x06 (byte=N 指定字节)；relp 作为RELP接收端回复rsp帧")
+	// --output/--output-format: 将消息写入文件，raw格式可直接用于重放
+	serverCmd.Flags().StringVarP(&serverOutput, "output", "o", "", "消息输出文件 (追加写入)")
+	serverCmd.Flags().StringVar(&serverOutputFormat, "output-format", server.OutputText, "输出文件格式 (raw/text/json)")
+	// --sample: 每N条消息只输出1条，降低高速率下的输出量
+	serverCmd.Flags().IntVar(&serverSample, "sample", 1, "抽样输出，每N条消息输出1条 (控制台和输出文件)")
+	// --quiet: 不在控制台输出逐条消息
+	serverCmd.Flags().BoolVarP(&serverQuiet, "quiet", "q", false, "静默模式，不在控制台输出逐条消息")
+	// --metrics-addr: 通过HTTP暴露收到的消息数，供发送端的EPS自动调节读取
+	serverCmd.Flags().StringVar(&serverMetricsAddr, "metrics-addr", "", "HTTP计数器接口监听地址 (如 127.0.0.1:9514)，GET /metrics 返回收到的消息数，同时提供 /healthz 和 /readyz")
+	// --health-addr: 单独的健康检查接口，供Kubernetes等编排系统等待接收端就绪
+	serverCmd.Flags().StringVar(&serverHealthAddr, "health-addr", "", "健康检查接口监听地址 (如 0.0.0.0:8080)，监听器就绪后 /healthz 和 /readyz 返回200，停止时 /readyz 返回503")
+	// --seq-field: 按来源统计消息序号的缺口和乱序，只写 --seq-field 时字段名为seq
+	serverCmd.Flags().StringVar(&serverSeqField, "seq-field", "", "从消息中提取 字段名=N 形式的序号，按来源统计丢包和乱序 (只写 --seq-field 时为seq)")
+	serverCmd.Flags().Lookup("seq-field").NoOptDefVal = server.DefaultSeqField
+	// --stats-interval: 定期输出按格式统计，停止时总会输出一次
+	serverCmd.Flags().DurationVar(&serverStatsInterval, "stats-interval", 0, "每隔指定时间输出按格式统计的消息数和字节数 (如 10s)，0表示只在停止时输出")
+	serverCmd.Flags().StringSliceVar(&serverAllowSrc, "allow-src", nil, "只接收来自这些CIDR或IP的消息 (逗号分隔或重复指定)，其他来源的消息丢弃并计数")
+	serverCmd.Flags().StringSliceVar(&serverDenySrc, "deny-src", nil, "丢弃来自这些CIDR或IP的消息并计数 (逗号分隔或重复指定)，优先于 --allow-src")
+	serverCmd.Flags().StringVar(&serverStatsJSON, "stats-json", "", "停止时将统计摘要以JSON写入指定文件，- 为标准输出")
+	// --idle-timeout: 作为脚本中的接收端时，发送结束后自动停止
+	serverCmd.Flags().DurationVar(&serverIdleTimeout, "idle-timeout", 0, "超过指定时长 (如 30s) 未收到消息时自动停止，与收到中断信号时相同地输出统计，0表示不自动停止")
+	serverCmd.Flags().StringVar(&serverLogTemplate, "log-template", "", "消息输出模板 (Go text/template，如 '{{.Hostname}} {{.Content}}')")
+}
+
+// printSeqStats 输出各来源的序号统计，JSON格式下每个来源输出一个事件
+func printSeqStats(logger *logging.Logger, field string, stats []server.SeqStats) {
+	if logger.JSON() {
+		for _, st := range stats {
+			logger.Info("序号统计", "event", "seq_stats", "field", field, "source", st.Source, "received", st.Received,
+				"first", st.First, "max", st.Max, "lost", st.Lost, "loss_rate", st.LossRate(), "gaps", st.Gaps, "reordered", st.Reordered)
+		}
+		return
+	}
+
+	fmt.Printf("\n=== 序号统计 (字段 %s) ===\n", field)
+	if len(stats) == 0 {
+		fmt.Println("没有收到带序号的消息")
+		return
+	}
+	for _, st := range stats {
+		fmt.Printf("%s: 收到 %d 条, 序号 %d-%d, 丢失 %d 条 (%.2f%%), 缺口 %d 处, 乱序 %d 条\n",
+			st.Source, st.Received, st.First, st.Max, st.Lost, st.LossRate()*100, st.Gaps, st.Reordered)
+	}
+}
+
+// reportStats 按间隔输出按格式统计，直到stop关闭
+func reportStats(logger *logging.Logger, srv *server.Server, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			printFormatStats(logger, srv.Stats())
+		}
+	}
+}
+
+// printFormatStats 输出按格式和解析结果分类的消息数和字节数
+func printFormatStats(logger *logging.Logger, stats server.Stats) {
+	if logger.JSON() {
+		logger.Info("格式统计", "event", "format_stats", "received", stats.Received,
+			"rfc3164", stats.RFC3164, "rfc5424", stats.RFC5424, "unparsed", stats.Unparsed)
+		return
+	}
+	fmt.Printf("收到 %d 条消息: RFC3164 %d 条 (%d 字节), RFC5424 %d 条 (%d 字节), 无法解析 %d 条 (%d 字节)\n",
+		stats.Received, stats.RFC3164.Messages, stats.RFC3164.Bytes, stats.RFC5424.Messages, stats.RFC5424.Bytes,
+		stats.Unparsed.Messages, stats.Unparsed.Bytes)
+}
+
+// writeStatsJSON 将停止时的统计摘要以JSON写入文件或标准输出，写入文件时随后关闭文件
+// 参数：
+//   - file: 启动前创建的摘要文件或os.Stdout
+//   - summary: 服务器的统计摘要
+func writeStatsJSON(file *os.File, summary server.Summary) error {
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(summary)
+	if file == os.Stdout {
+		return err
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// printParseErrors 按原因输出解析失败的消息数量，没有解析失败时不输出
+func printParseErrors(counts map[string]int64) {
+	if len(counts) == 0 {
+		return
+	}
+	causes := make([]string, 0, len(counts))
+	for cause := range counts {
+		causes = append(causes, cause)
+	}
+	sort.Strings(causes)
+	parts := make([]string, 0, len(causes))
+	for _, cause := range causes {
+		parts = append(parts, fmt.Sprintf("%s=%d", cause, counts[cause]))
+	}
+	fmt.Printf("解析失败的消息: %s\n", strings.Join(parts, ", "))
+}
@@ -2,69 +2,193 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+
+	"syslog_go/pkg/logging"
+	"syslog_go/pkg/syslog"
 )
 
 // Config 应用程序配置结构
 type Config struct {
 	// 基础配置
-	Target   string `mapstructure:"target" yaml:"target"`       // 目标服务器地址
-	SourceIP string `mapstructure:"source_ip" yaml:"source_ip"` // 源IP地址
-	Protocol string `mapstructure:"protocol" yaml:"protocol"`   // 传输协议
+	Target     string `mapstructure:"target" yaml:"target"`           // 目标服务器地址，逗号分隔多个目标或用 [1-10] 展开范围，每条消息发送到所有目标
+	SourceIP   string `mapstructure:"source_ip" yaml:"source_ip"`     // 源IP地址
+	SourcePort int    `mapstructure:"source_port" yaml:"source_port"` // 源端口，为0时由系统分配
+	Spoof      bool   `mapstructure:"spoof" yaml:"spoof"`             // 允许使用原始套接字伪造非本机源IP
+	Protocol   string `mapstructure:"protocol" yaml:"protocol"`       // 传输协议，tls等同于tcp并启用UseTLS
+
+	// TLS配置（RFC5425），只用于TCP
+	UseTLS             bool   `mapstructure:"use_tls" yaml:"use_tls"`                           // TCP连接使用TLS加密
+	TLSCAFile          string `mapstructure:"tls_ca_file" yaml:"tls_ca_file"`                   // 验证服务器证书的CA证书文件（PEM），为空时使用系统根证书
+	TLSCertFile        string `mapstructure:"tls_cert_file" yaml:"tls_cert_file"`               // 客户端证书文件（PEM），服务器要求双向认证时使用，需与TLSKeyFile同时指定
+	TLSKeyFile         string `mapstructure:"tls_key_file" yaml:"tls_key_file"`                 // 客户端私钥文件（PEM）
+	TLSServerName      string `mapstructure:"tls_server_name" yaml:"tls_server_name"`           // 验证证书时使用的服务器名，为空时取目标地址中的主机名
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify" yaml:"insecure_skip_verify"` // 不验证服务器证书，只用于测试
 
 	// Syslog配置
-	Format   string `mapstructure:"format" yaml:"format"`     // Syslog格式
-	Facility int    `mapstructure:"facility" yaml:"facility"` // Facility值
-	Severity int    `mapstructure:"severity" yaml:"severity"` // Severity值
+	Format     string `mapstructure:"format" yaml:"format"`           // Syslog格式
+	TimeLayout string `mapstructure:"time_layout" yaml:"time_layout"` // RFC3164时间戳的Go时间格式，如 "Jan _2 15:04:05"，为空时使用 "Jan 02 15:04:05"
+	Raw        bool   `mapstructure:"raw" yaml:"raw"`                 // 原样发送消息内容，不按Format添加头部
+	FQDN       bool   `mapstructure:"fqdn" yaml:"fqdn"`               // 消息的HOSTNAME字段使用本机的完全限定域名，解析失败时使用短主机名
+	ProcID     string `mapstructure:"proc_id" yaml:"proc_id"`         // 消息的进程ID（RFC5424 PROCID、RFC3164 TAG[PID]）：固定值、self（发送进程的PID）或模板如 {{SEQ}}，为空时不输出
+	Facility   int    `mapstructure:"facility" yaml:"facility"`       // Facility值
+	Severity   int    `mapstructure:"severity" yaml:"severity"`       // Severity值
+
+	// 严重性分布与模板选择
+	SeverityMix       string            `mapstructure:"severity_mix" yaml:"severity_mix"`             // 按权重随机选择Severity，如 "info=70,warning=20,err=10"，为空时固定使用Severity
+	FacilityMix       string            `mapstructure:"facility_mix" yaml:"facility_mix"`             // 按权重随机选择Facility，如 "local0=80,auth=20"，为空时固定使用Facility
+	SeverityTemplates map[string]string `mapstructure:"severity_templates" yaml:"severity_templates"` // Severity（名称或数值）到消息模板的映射，未配置的Severity使用默认消息
+	SeverityWeights   map[string]int    `mapstructure:"severity_weights" yaml:"severity_weights"`     // Severity（名称或数值）到权重的映射，配置文件中代替SeverityMix，如 {info: 70, err: 30}
+	FacilityWeights   map[string]int    `mapstructure:"facility_weights" yaml:"facility_weights"`     // Facility（名称或数值）到权重的映射，配置文件中代替FacilityMix
 
 	// 发送控制
-	EPS      int           `mapstructure:"eps" yaml:"eps"`           // 每秒事件数
-	Duration time.Duration `mapstructure:"duration" yaml:"duration"` // 发送持续时间
-	Encoding string        `mapstructure:"encoding" yaml:"encoding"` // 字符编码: utf-8/gbk
+	EPS          int           `mapstructure:"eps" yaml:"eps"`                     // 每秒事件数
+	InterArrival string        `mapstructure:"inter_arrival" yaml:"inter_arrival"` // 消息间隔分布，如 "exp:mean=100ms" 或 "uniform:min=50ms,max=150ms"，设置后代替EPS匀速发送
+	MaxEPS       int           `mapstructure:"max_eps" yaml:"max_eps"`             // 按消息间隔分布发送时的速率上限，突发快于上限的消息顺延到限速器，0为不限制
+	Limiter      string        `mapstructure:"limiter" yaml:"limiter"`             // 限速方式: interval（固定间隔，默认）/token-bucket（令牌桶，暂停后可补发最多Burst条）
+	Burst        int           `mapstructure:"burst" yaml:"burst"`                 // 发送暂停后一次最多放行的消息数：固定间隔下只在EPS超过1000时生效，0为4ms的量；令牌桶下为桶容量，0为EPS（1秒的量）
+	Duration     time.Duration `mapstructure:"duration" yaml:"duration"`           // 发送持续时间
+	Encoding     string        `mapstructure:"encoding" yaml:"encoding"`           // 字符编码: utf-8/gbk
+	BatchSize    int           `mapstructure:"batch_size" yaml:"batch_size"`       // 每次系统调用发送的消息条数，大于1时批量发送
+	Rounds       int           `mapstructure:"rounds" yaml:"rounds"`               // 大于0时先生成固定的消息集合，按速率重放Rounds轮后结束，忽略Duration
+	RoundSize    int           `mapstructure:"round_size" yaml:"round_size"`       // 每轮的消息条数，为0时只使用数据文件则为文件总行数，否则为EPS
+	Total        int64         `mapstructure:"total" yaml:"total"`                 // 大于0时在Over时长内匀速发送Total条消息后结束，速率由两者换算，忽略EPS和Duration
+	Over         time.Duration `mapstructure:"over" yaml:"over"`                   // 发送Total条消息的计划时长
+
+	// EPS闭环调节
+	TargetReceivedEPS int           `mapstructure:"target_received_eps" yaml:"target_received_eps"` // 期望服务端实际收到的速率，大于0时根据反馈自动调节EPS
+	FeedbackURL       string        `mapstructure:"feedback_url" yaml:"feedback_url"`               // 反馈地址，返回服务端收到的消息总数（如 server --metrics-addr 的 /metrics）
+	FeedbackInterval  time.Duration `mapstructure:"feedback_interval" yaml:"feedback_interval"`     // 读取反馈并调节EPS的间隔
+
+	// RFC5424结构化数据
+	Origin       bool `mapstructure:"origin" yaml:"origin"`               // 为每条RFC5424消息自动添加origin结构化数据，标识消息的生成工具和源地址
+	EnterpriseID int  `mapstructure:"enterprise_id" yaml:"enterprise_id"` // 私有企业号（IANA PEN），用于结构化数据的SD-ID（name@<企业号>）
+
+	StructuredData []string `mapstructure:"structured_data" yaml:"structured_data"` // 附加到每条RFC5424消息的结构化数据元素，按顺序输出，如 meta@32473 seq="{{SEQ}}"，参数值支持模板变量
+
+	Fields []string `mapstructure:"fields" yaml:"fields"` // JSON格式下附加到每条消息的顶层字段，格式 键=值，按顺序输出，值支持模板变量
+
+	// 消息分隔
+	AppendNewline string `mapstructure:"append_newline" yaml:"append_newline"` // 是否追加换行: auto/always/never，auto时仅TCP追加
+	Compress      bool   `mapstructure:"compress" yaml:"compress"`             // TCP连接使用zlib压缩，解压后按LF分帧
+	Framing       string `mapstructure:"framing" yaml:"framing"`               // TCP分帧方式: lf/octet，octet时每条消息前加 "长度 空格"（RFC6587八位组计数）
 
 	// 数据源配置
-	TemplateDir  string `mapstructure:"template_dir" yaml:"template_dir"`   // 模板目录
-	TemplateFile string `mapstructure:"template_file" yaml:"template_file"` // 指定模板文件
-	DataFile     string `mapstructure:"data_file" yaml:"data_file"`         // 数据文件
-	Message      string `mapstructure:"message" yaml:"message"`             // 消息内容
+	TemplateDir  string   `mapstructure:"template_dir" yaml:"template_dir"`   // 模板目录
+	TemplateFile string   `mapstructure:"template_file" yaml:"template_file"` // 指定模板文件
+	DataFile     string   `mapstructure:"data_file" yaml:"data_file"`         // 数据文件
+	DataFiles    []string `mapstructure:"data_files" yaml:"data_files"`       // 更多数据文件，支持通配符和 "路径=权重"，与DataFile一起读取
+	Message      string   `mapstructure:"message" yaml:"message"`             // 消息内容
+	VarsFile     string   `mapstructure:"vars_file" yaml:"vars_file"`         // 自定义变量配置文件，为空时使用当前目录的template.yml
 
 	// 高级配置
-	Concurrency int           `mapstructure:"concurrency" yaml:"concurrency"` // 并发连接数
-	RetryCount  int           `mapstructure:"retry_count" yaml:"retry_count"` // 重试次数
-	Timeout     time.Duration `mapstructure:"timeout" yaml:"timeout"`         // 连接超时
-	BufferSize  int           `mapstructure:"buffer_size" yaml:"buffer_size"` // 缓冲区大小
+	Concurrency    int           `mapstructure:"concurrency" yaml:"concurrency"`         // 并发连接数
+	MaxConcurrency int           `mapstructure:"max_concurrency" yaml:"max_concurrency"` // 实际速率持续低于目标时自动增加工作协程的上限，为0时只告警不扩容
+	RetryCount     int           `mapstructure:"retry_count" yaml:"retry_count"`         // 初始化连接失败时的重试次数
+	RetryInterval  time.Duration `mapstructure:"retry_interval" yaml:"retry_interval"`   // 重试基础间隔，按指数退避并叠加随机抖动
+	ReconnectEvery int           `mapstructure:"reconnect_every" yaml:"reconnect_every"` // 每个连接写入N条消息后关闭并重新建立，用于压测接收端的连接处理，为0时一直复用
+	Timeout        time.Duration `mapstructure:"timeout" yaml:"timeout"`                 // 连接超时，也用作每次写入的超时
+	BufferSize     int           `mapstructure:"buffer_size" yaml:"buffer_size"`         // 缓冲区大小，loadgen模式下为发送票据队列的容量
+	LoadGen        bool          `mapstructure:"loadgen" yaml:"loadgen"`                 // 负载生成模式：固定速率产生发送票据，由工作协程池消费
+	MaxFailures    string        `mapstructure:"max_failures" yaml:"max_failures"`       // 失败熔断阈值：失败总数如 100，或窗口内失败比例如 5%、5%/30s，超过时提前结束并返回错误
+
+	RequireEPSTolerance string `mapstructure:"require_eps_tolerance" yaml:"require_eps_tolerance"` // 实际平均速率与目标速率的允许偏差，如 5%，超出时发送结束后返回错误，为空时不检查
 
 	// 监控配置
 	EnableStats   bool          `mapstructure:"enable_stats" yaml:"enable_stats"`     // 启用统计
-	StatsInterval time.Duration `mapstructure:"stats_interval" yaml:"stats_interval"` // 统计间隔
-	Verbose       bool          `mapstructure:"verbose" yaml:"verbose"`               // 详细输出
+	StatsInterval time.Duration `mapstructure:"stats_interval" yaml:"stats_interval"` // 周期统计的输出间隔，为0时只输出最终统计
+	Verbose       bool          `mapstructure:"verbose" yaml:"verbose"`               // 详细输出（逐条消息日志）
+	Quiet         bool          `mapstructure:"quiet" yaml:"quiet"`                   // 静默模式，不输出周期统计和最终统计
+	DryRun        bool          `mapstructure:"dry_run" yaml:"dry_run"`               // 演练模式，消息输出到标准输出而不发送到网络
+	NullSink      bool          `mapstructure:"null_sink" yaml:"null_sink"`           // 空输出：消息写入丢弃所有数据的连接，只计数，用于测量生成和限速的吞吐量
+	LogFormat     string        `mapstructure:"log_format" yaml:"log_format"`         // 日志格式: text/json，json时统计和诊断信息以JSON行输出
 }
 
+// 换行追加策略
+const (
+	NewlineAuto   = "auto"   // 根据传输方式决定：TCP（LF分帧）追加，UDP不追加
+	NewlineAlways = "always" // 总是追加
+	NewlineNever  = "never"  // 从不追加
+)
+
+// TCP分帧方式
+const (
+	FramingLF    = "lf"    // 消息以LF结尾（RFC6587非透明分帧）
+	FramingOctet = "octet" // 消息前加十进制字节数和空格（RFC6587八位组计数），消息中可以包含换行
+)
+
+// 限速方式
+const (
+	LimiterInterval    = "interval"     // 固定间隔，暂停后不补发错过的消息
+	LimiterTokenBucket = "token-bucket" // 令牌桶，暂停期间积累的令牌可以补发
+)
+
+// ProcIDSelf 进程ID使用发送进程自身的PID
+const ProcIDSelf = "self"
+
+// DefaultEnterpriseID 默认的私有企业号
+// 32473是RFC 5612为文档和示例保留的企业号，正式环境的下游规则建议通过enterprise_id配置自己的企业号
+const DefaultEnterpriseID = 32473
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
-		Target:        "localhost:514",
-		SourceIP:      "",
-		Protocol:      "udp",
-		Format:        "",
-		Facility:      16, // local0
-		Severity:      6,  // info
-		EPS:           10,
-		Duration:      60 * time.Second,
-		Encoding:      "utf-8",
-		TemplateDir:   "./data/templates",
-		TemplateFile:  "",
-		DataFile:      "",
-		Message:       "",
-		Concurrency:   1,
-		RetryCount:    3,
-		Timeout:       5 * time.Second,
-		BufferSize:    1000,
-		EnableStats:   true,
-		StatsInterval: 5 * time.Second,
-		Verbose:       false,
+		Target:            "localhost:514",
+		SourceIP:          "",
+		SourcePort:        0,
+		Spoof:             false,
+		Protocol:          "udp",
+		Format:            "",
+		Raw:               false,
+		FQDN:              false,
+		TimeLayout:        "",
+		ProcID:            "",
+		Facility:          16, // local0
+		Severity:          6,  // info
+		SeverityMix:       "",
+		FacilityMix:       "",
+		EPS:               10,
+		InterArrival:      "",
+		MaxEPS:            0,
+		Duration:          60 * time.Second,
+		Encoding:          "utf-8",
+		BatchSize:         1,
+		Rounds:            0,
+		RoundSize:         0,
+		TargetReceivedEPS: 0,
+		FeedbackURL:       "",
+		FeedbackInterval:  2 * time.Second,
+		AppendNewline:     NewlineAuto,
+		Framing:           FramingLF,
+		Limiter:           LimiterInterval,
+		Origin:            false,
+		EnterpriseID:      DefaultEnterpriseID,
+		TemplateDir:       "./data/templates",
+		TemplateFile:      "",
+		DataFile:          "",
+		Message:           "",
+		VarsFile:          "",
+		Concurrency:       1,
+		MaxConcurrency:    0,
+		RetryCount:        3,
+		RetryInterval:     1 * time.Second,
+		Timeout:           5 * time.Second,
+		BufferSize:        1000,
+		LoadGen:           false,
+		MaxFailures:       "",
+		EnableStats:       true,
+		StatsInterval:     5 * time.Second,
+		Verbose:           false,
+		Quiet:             false,
+		DryRun:            false,
+		LogFormat:         logging.FormatText,
 	}
 }
 
@@ -85,6 +209,11 @@ func LoadConfig(configFile string) (*Config, error) {
 		return nil, fmt.Errorf("配置解析失败: %w", err)
 	}
 
+	// 目标地址带scheme且未显式指定协议时，由scheme决定协议
+	if !viper.IsSet("protocol") && HasTargetScheme(cfg.Target) {
+		cfg.Protocol = ""
+	}
+
 	// 验证配置
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("配置验证失败: %w", err)
@@ -99,18 +228,104 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("目标服务器地址不能为空")
 	}
 
-	if c.Protocol != "udp" && c.Protocol != "tcp" {
-		return fmt.Errorf("协议必须是 udp 或 tcp")
+	// 目标地址带scheme时拆分出协议和地址，显式指定的Protocol优先；
+	// 多个目标用逗号分隔，各目标的scheme必须相同
+	var scheme string
+	items := splitTargets(c.Target)
+	for i, item := range items {
+		itemScheme, address, err := ParseTarget(strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+		if address == "" {
+			return fmt.Errorf("目标地址列表中包含空地址: %s", c.Target)
+		}
+		if i > 0 && itemScheme != scheme {
+			return fmt.Errorf("多个目标必须使用相同的协议: %s", c.Target)
+		}
+		scheme, items[i] = itemScheme, address
+	}
+	if scheme != "" {
+		c.Target = strings.Join(items, ",")
+		if c.Protocol == "" {
+			c.Protocol = scheme
+		}
+	}
+	if _, err := ExpandTargets(c.Target); err != nil {
+		return err
+	}
+
+	if c.SourcePort < 0 || c.SourcePort > 65535 {
+		return fmt.Errorf("源端口必须在0-65535范围内: %d", c.SourcePort)
+	}
+
+	// tls://目标和 tls 协议即启用TLS的TCP
+	if c.Protocol == "tls" {
+		c.Protocol, c.UseTLS = "tcp", true
+	}
+
+	switch c.Protocol {
+	case "udp", "tcp":
+	case "unix":
+		if c.SourceIP != "" || c.SourcePort != 0 {
+			return fmt.Errorf("unix套接字不支持指定源IP或源端口")
+		}
+		for _, item := range items {
+			if _, ok := SRVName(item); ok {
+				return fmt.Errorf("SRV目标 %s 不能使用unix套接字", item)
+			}
+		}
+	default:
+		return fmt.Errorf("协议必须是 udp、tcp 或 unix")
+	}
+
+	if err := c.validateTLS(); err != nil {
+		return err
+	}
+
+	if c.Format != "rfc3164" && c.Format != "rfc5424" && c.Format != "json" {
+		return fmt.Errorf("格式必须是 rfc3164、rfc5424 或 json")
 	}
 
-	if c.Format != "rfc3164" && c.Format != "rfc5424" {
-		return fmt.Errorf("格式必须是 rfc3164 或 rfc5424")
+	if c.TimeLayout != "" {
+		if c.Format != "rfc3164" {
+			return fmt.Errorf("时间格式只用于rfc3164格式")
+		}
+		if err := syslog.ValidateTimeLayout(c.TimeLayout); err != nil {
+			return err
+		}
+	}
+
+	// 包含模板变量的进程ID在生成每条消息时校验
+	if c.ProcID != "" && c.ProcID != ProcIDSelf && !strings.Contains(c.ProcID, "{{") {
+		if err := syslog.ValidateProcID(c.ProcID); err != nil {
+			return err
+		}
 	}
 
 	if c.Encoding != "utf-8" && c.Encoding != "gbk" {
 		return fmt.Errorf("编码必须是 utf-8 或 gbk")
 	}
 
+	switch c.AppendNewline {
+	case NewlineAuto, NewlineAlways, NewlineNever:
+	default:
+		return fmt.Errorf("换行策略必须是 auto、always 或 never")
+	}
+
+	switch c.Framing {
+	case "", FramingLF:
+	case FramingOctet:
+		if c.Protocol != "tcp" {
+			return fmt.Errorf("八位组计数分帧只支持TCP协议")
+		}
+		if c.AppendNewline == NewlineAlways {
+			return fmt.Errorf("八位组计数分帧不追加换行，不能与 append_newline=always 同时使用")
+		}
+	default:
+		return fmt.Errorf("分帧方式必须是 lf 或 octet")
+	}
+
 	if c.Facility < 0 || c.Facility > 23 {
 		return fmt.Errorf("Facility必须在0-23范围内")
 	}
@@ -119,21 +334,842 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("Severity必须在0-7范围内")
 	}
 
-	if c.EPS <= 0 {
-		return fmt.Errorf("EPS必须大于0")
+	if _, err := c.SeverityDistribution(); err != nil {
+		return err
+	}
+
+	if _, err := c.FacilityDistribution(); err != nil {
+		return err
+	}
+
+	if _, err := c.SeverityTemplateMap(); err != nil {
+		return err
+	}
+
+	// 空输出测量吞吐量时EPS为0表示不限速，发送到网络时必须限速
+	if c.EPS < 0 || (c.EPS == 0 && !c.NullSink) {
+		return fmt.Errorf("EPS必须大于0（只有 --null-sink 时可以为0表示不限速）")
+	}
+	if c.EPS == 0 && (c.LoadGen || c.TargetReceivedEPS > 0) {
+		return fmt.Errorf("不限速（EPS为0）不能与loadgen模式或自动调节EPS同时使用")
+	}
+	if c.NullSink && c.DryRun {
+		return fmt.Errorf("空输出不能与演练模式同时使用")
+	}
+
+	if _, err := ParseInterArrival(c.InterArrival); err != nil {
+		return err
+	}
+	if c.MaxEPS < 0 {
+		return fmt.Errorf("速率上限不能为负数")
+	}
+	if c.MaxEPS > 0 && c.InterArrival == "" {
+		return fmt.Errorf("速率上限只用于消息间隔分布（--inter-arrival），匀速发送请直接调整EPS")
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("突发量不能为负数")
+	}
+	switch c.Limiter {
+	case "", LimiterInterval:
+	case LimiterTokenBucket:
+		// 按轮次重放（--rounds）时同样按EPS限速，可以使用令牌桶；
+		// --total 的速率由总量和时长换算，不是EPS，与令牌桶的速率冲突
+		if c.InterArrival != "" || c.Total > 0 {
+			return fmt.Errorf("令牌桶限速只用于按EPS发送，不能与 --inter-arrival 或 --total 同时使用")
+		}
+	default:
+		return fmt.Errorf("限速方式必须是 interval 或 token-bucket")
 	}
 
 	if c.Duration <= 0 {
 		return fmt.Errorf("持续时间必须大于0")
 	}
 
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("批量大小必须大于0")
+	}
+
+	if err := c.validateTotal(); err != nil {
+		return err
+	}
+
+	if c.Rounds < 0 {
+		return fmt.Errorf("轮数不能为负数")
+	}
+	if c.RoundSize < 0 {
+		return fmt.Errorf("每轮消息数不能为负数")
+	}
+
 	if c.Concurrency <= 0 {
 		return fmt.Errorf("并发数必须大于0")
 	}
+	if c.MaxConcurrency != 0 && c.MaxConcurrency < c.Concurrency {
+		return fmt.Errorf("最大并发数 %d 不能小于并发数 %d", c.MaxConcurrency, c.Concurrency)
+	}
+
+	if _, err := ParseMaxFailures(c.MaxFailures); err != nil {
+		return err
+	}
+	if tolerance, err := ParseEPSTolerance(c.RequireEPSTolerance); err != nil {
+		return err
+	} else if tolerance > 0 && (c.InterArrival != "" || c.TargetReceivedEPS > 0 || (c.EPS == 0 && c.Total == 0)) {
+		return fmt.Errorf("速率容差需要固定的目标速率，不能与消息间隔分布、自动调节EPS或不限速同时使用")
+	}
+
+	if c.StatsInterval < 0 {
+		return fmt.Errorf("统计间隔不能为负数")
+	}
+
+	if c.EnterpriseID <= 0 {
+		return fmt.Errorf("企业号必须是正整数: %d", c.EnterpriseID)
+	}
+	if err := c.validateStructuredData(); err != nil {
+		return err
+	}
+	if err := c.validateFields(); err != nil {
+		return err
+	}
+
+	if c.Compress {
+		if c.Protocol != "tcp" {
+			return fmt.Errorf("压缩只支持TCP协议")
+		}
+		// 压缩流解压后依靠LF分帧区分消息
+		if c.AppendNewline == NewlineNever {
+			return fmt.Errorf("压缩需要LF分帧，不能与 append_newline=never 同时使用")
+		}
+		if c.OctetFraming() {
+			return fmt.Errorf("压缩需要LF分帧，不能与 framing=octet 同时使用")
+		}
+	}
+
+	if c.TargetReceivedEPS < 0 {
+		return fmt.Errorf("目标接收速率不能为负数")
+	}
+	if c.TargetReceivedEPS > 0 {
+		if c.FeedbackURL == "" {
+			return fmt.Errorf("自动调节EPS需要指定反馈地址")
+		}
+		if c.InterArrival != "" {
+			return fmt.Errorf("自动调节EPS不能与消息间隔分布同时使用")
+		}
+		if c.FeedbackInterval <= 0 {
+			return fmt.Errorf("反馈间隔必须大于0")
+		}
+	}
+
+	if _, err := ExpandDataFiles(c.DataFileSpecs()); err != nil {
+		return err
+	}
+
+	if c.LoadGen && c.BufferSize <= 0 {
+		return fmt.Errorf("loadgen模式下缓冲区大小必须大于0")
+	}
+
+	if err := logging.ValidateFormat(c.LogFormat); err != nil {
+		return err
+	}
+
+	if c.Timeout < 0 {
+		return fmt.Errorf("超时时间不能为负数")
+	}
+
+	if c.RetryCount < 0 {
+		return fmt.Errorf("重试次数不能为负数")
+	}
+
+	if c.ReconnectEvery < 0 {
+		return fmt.Errorf("连接重建间隔不能为负数: %d", c.ReconnectEvery)
+	}
 
 	return nil
 }
 
+// validateTotal 校验按总量发送的配置
+// 总量和时长必须同时配置，换算出的速率须能由速率限制器实现（相邻两条消息的间隔至少1纳秒）
+func (c *Config) validateTotal() error {
+	if c.Total < 0 {
+		return fmt.Errorf("消息总量不能为负数")
+	}
+	if c.Over < 0 {
+		return fmt.Errorf("发送总量的时长不能为负数")
+	}
+	if (c.Total > 0) != (c.Over > 0) {
+		return fmt.Errorf("消息总量（--total）和时长（--over）必须同时指定")
+	}
+	if c.Total == 0 {
+		return nil
+	}
+
+	if c.Rounds > 0 {
+		return fmt.Errorf("按总量发送不能与按轮次重放同时使用")
+	}
+	if c.InterArrival != "" {
+		return fmt.Errorf("按总量发送时速率由总量和时长决定，不能与消息间隔分布同时使用")
+	}
+	if c.TargetReceivedEPS > 0 {
+		return fmt.Errorf("按总量发送时速率由总量和时长决定，不能与自动调节EPS同时使用")
+	}
+	if c.TotalInterval() <= 0 {
+		return fmt.Errorf("在 %v 内发送 %d 条消息需要 %.0f EPS，超过速率限制器的上限（每秒10亿条）", c.Over, c.Total, c.TotalRate())
+	}
+	return nil
+}
+
+// TotalRate 返回按总量发送时换算出的每秒消息数，未配置总量时返回0
+func (c *Config) TotalRate() float64 {
+	if c.Total <= 0 || c.Over <= 0 {
+		return 0
+	}
+	return float64(c.Total) / c.Over.Seconds()
+}
+
+// TotalInterval 返回按总量发送时相邻两条消息的间隔，未配置总量时返回0
+// 速率可以低于每秒一条或不是整数（如一小时一百万条约为每秒277.78条），因此按间隔而不是EPS限速
+func (c *Config) TotalInterval() time.Duration {
+	if c.Total <= 0 {
+		return 0
+	}
+	return c.Over / time.Duration(c.Total)
+}
+
+// ApplyTotal 按总量和时长设置EPS和Duration，未配置总量时不做修改
+// EPS取换算速率四舍五入后的值（至少为1），只用于显示和日志，实际按TotalInterval限速；
+// Duration为计划时长，发送在Total条消息全部发出后结束
+func (c *Config) ApplyTotal() {
+	if c.Total <= 0 || c.Over <= 0 {
+		return
+	}
+	c.EPS = int(math.Max(1, math.Round(c.TotalRate())))
+	c.Duration = c.Over
+}
+
+// ShouldAppendNewline 判断发送时是否需要在消息末尾追加换行符
+// auto策略下TCP使用LF分帧需要追加，UDP每个数据报即一条消息，不追加；
+// 八位组计数分帧由长度前缀区分消息，不追加
+func (c *Config) ShouldAppendNewline() bool {
+	if c.OctetFraming() {
+		return false
+	}
+	switch c.AppendNewline {
+	case NewlineAlways:
+		return true
+	case NewlineNever:
+		return false
+	default:
+		return c.Protocol == "tcp"
+	}
+}
+
+// validateTLS 检查TLS配置
+// TLS只用于TCP；原始套接字伪造源IP时不建立真正的TCP连接，无法握手
+func (c *Config) validateTLS() error {
+	if !c.UseTLS {
+		if c.TLSCAFile != "" || c.TLSCertFile != "" || c.TLSKeyFile != "" || c.TLSServerName != "" || c.InsecureSkipVerify {
+			return fmt.Errorf("TLS证书等选项需要启用TLS（--tls 或 tls://目标）")
+		}
+		return nil
+	}
+	if c.Protocol != "tcp" {
+		return fmt.Errorf("TLS只支持TCP协议")
+	}
+	if c.Spoof {
+		return fmt.Errorf("TLS不能与源IP伪造同时使用")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("客户端证书和私钥必须同时指定")
+	}
+	return nil
+}
+
+// BucketBurst 返回令牌桶的容量，未配置突发量时为EPS（积累1秒的令牌）
+func (c *Config) BucketBurst() int {
+	if c.Burst > 0 {
+		return c.Burst
+	}
+	return c.EPS
+}
+
+// OctetFraming 判断TCP消息是否使用八位组计数分帧
+func (c *Config) OctetFraming() bool {
+	return c.Framing == FramingOctet
+}
+
+// ParseTarget 解析带scheme的目标地址
+// 支持 udp://host:514、tcp://host:601、tls://host:6514（启用TLS的TCP）和 unix:///dev/log，
+// 以及通过DNS SRV记录发现目标的 srv+_syslog._udp.example.com，
+// 不带scheme时原样返回地址
+// 参数：
+//   - target: 目标地址
+//
+// 返回值：
+//   - string: scheme（即协议），不带scheme时为空，SRV目标为服务名中的协议
+//   - string: 去掉scheme后的地址，unix时为套接字路径，SRV目标保留 srv+ 前缀由发送器解析
+//   - error: scheme不支持或地址为空时返回错误
+func ParseTarget(target string) (string, string, error) {
+	if name, ok := SRVName(target); ok {
+		protocol, err := srvProtocol(name)
+		if err != nil {
+			return "", "", err
+		}
+		return protocol, target, nil
+	}
+
+	scheme, address, found := strings.Cut(target, "://")
+	if !found {
+		return "", target, nil
+	}
+
+	scheme = strings.ToLower(scheme)
+	switch scheme {
+	case "udp", "tcp", "tls", "unix":
+	default:
+		return "", "", fmt.Errorf("不支持的目标地址scheme: %s（支持 udp、tcp、tls、unix）", scheme)
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("目标地址 %s 缺少主机或路径", target)
+	}
+	return scheme, address, nil
+}
+
+// maxTargets 目标展开后的最大数量，避免范围写错时创建过多连接
+const maxTargets = 1024
+
+// ExpandTargets 展开目标地址列表
+// 参数：
+//   - target: 逗号分隔的地址列表（已去掉scheme），每个地址可以包含数字范围，
+//     如 10.0.0.[1-10]:514、collector-[1,3,5-7].example.com:514；
+//     方括号内包含冒号时视为IPv6地址（如 [::1]:514），不展开
+//
+// 返回值：
+//   - []string: 展开后的地址列表，按书写顺序排列
+//   - error: 范围无效或展开后超过上限时返回错误
+func ExpandTargets(target string) ([]string, error) {
+	var targets []string
+	for _, item := range splitTargets(target) {
+		expanded, err := expandTargetRanges(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, expanded...)
+		if len(targets) > maxTargets {
+			return nil, fmt.Errorf("目标地址展开后超过 %d 个: %s", maxTargets, target)
+		}
+	}
+	return targets, nil
+}
+
+// splitTargets 按逗号拆分目标地址列表，方括号内的逗号（如 [1,3,5-7]）不拆分
+func splitTargets(target string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, ch := range target {
+		switch ch {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, target[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, target[start:])
+}
+
+// expandTargetRanges 展开一个地址中的第一个数字范围，剩余部分递归展开
+func expandTargetRanges(address string) ([]string, error) {
+	start := strings.Index(address, "[")
+	for start >= 0 {
+		end := strings.Index(address[start:], "]")
+		if end < 0 {
+			return []string{address}, nil
+		}
+		end += start
+		spec := address[start+1 : end]
+		if strings.Contains(spec, ":") {
+			// IPv6地址，跳过
+			next := strings.Index(address[end:], "[")
+			if next < 0 {
+				return []string{address}, nil
+			}
+			start = end + next
+			continue
+		}
+
+		values, err := parseTargetRange(spec)
+		if err != nil {
+			return nil, fmt.Errorf("目标地址 %s 的范围无效: %w", address, err)
+		}
+		rest, err := expandTargetRanges(address[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		var expanded []string
+		for _, v := range values {
+			for _, r := range rest {
+				expanded = append(expanded, address[:start]+v+r)
+				if len(expanded) > maxTargets {
+					return nil, fmt.Errorf("目标地址展开后超过 %d 个: %s", maxTargets, address)
+				}
+			}
+		}
+		return expanded, nil
+	}
+	return []string{address}, nil
+}
+
+// parseTargetRange 解析方括号中的数字范围，如 "1-10" 或 "1,3,5-7"
+func parseTargetRange(spec string) ([]string, error) {
+	var values []string
+	for _, part := range strings.Split(spec, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("%q 不是非负整数", lo)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil || last < first {
+				return nil, fmt.Errorf("%q 不是有效的范围", part)
+			}
+		}
+		if last-first >= maxTargets {
+			return nil, fmt.Errorf("%q 范围过大", part)
+		}
+		for n := first; n <= last; n++ {
+			values = append(values, strconv.Itoa(n))
+		}
+	}
+	return values, nil
+}
+
+// 消息间隔分布类型
+const (
+	DistExponential = "exp"     // 指数分布（泊松过程），突发但整体平稳
+	DistUniform     = "uniform" // 均匀分布
+)
+
+// InterArrivalSpec 消息间隔分布配置
+type InterArrivalSpec struct {
+	Dist string        // 分布类型: exp/uniform
+	Mean time.Duration // 指数分布的平均间隔
+	Min  time.Duration // 均匀分布的最小间隔
+	Max  time.Duration // 均匀分布的最大间隔
+}
+
+// ParseInterArrival 解析消息间隔分布
+// 参数：
+//   - spec: 分布描述，格式为 "分布:参数=值,..."，支持：
+//     "exp:mean=100ms"（指数分布，平均间隔100ms）
+//     "uniform:min=50ms,max=150ms"（均匀分布）
+//
+// 返回值：
+//   - *InterArrivalSpec: 解析结果，spec为空时返回nil
+//   - error: 分布类型或参数无效时返回错误
+func ParseInterArrival(spec string) (*InterArrivalSpec, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	dist, params, _ := strings.Cut(spec, ":")
+	result := &InterArrivalSpec{Dist: strings.ToLower(strings.TrimSpace(dist))}
+	values := make(map[string]time.Duration)
+	for _, item := range strings.Split(params, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("消息间隔分布参数无效: %s，格式应为 参数=时长", item)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("消息间隔分布参数 %s 的时长无效: %s", strings.TrimSpace(key), value)
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = d
+	}
+
+	switch result.Dist {
+	case DistExponential:
+		mean, ok := values["mean"]
+		if !ok || mean <= 0 {
+			return nil, fmt.Errorf("指数分布需要大于0的mean参数，如 exp:mean=100ms")
+		}
+		result.Mean = mean
+	case DistUniform:
+		min, hasMin := values["min"]
+		max, hasMax := values["max"]
+		if !hasMin || !hasMax || max <= 0 || min > max {
+			return nil, fmt.Errorf("均匀分布需要min和max参数且min不大于max，如 uniform:min=50ms,max=150ms")
+		}
+		result.Min, result.Max = min, max
+	default:
+		return nil, fmt.Errorf("不支持的消息间隔分布: %s（支持 exp、uniform）", dist)
+	}
+	return result, nil
+}
+
+// ParseEPSTolerance 解析速率容差
+// 参数：
+//   - spec: 百分比，如 "5%"，也可以省略百分号写作 "5"
+//
+// 返回值：
+//   - float64: 允许的偏差比例（0-1），spec为空时返回0
+//   - error: 格式无效或不在0%-100%之间（不含0）时返回错误
+func ParseEPSTolerance(spec string) (float64, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 0, nil
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(spec, "%")), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("速率容差无效: %s，应为0%%-100%%之间的百分比（不含0），如 5%%", spec)
+	}
+	return percent / 100, nil
+}
+
+// DefaultFailureWindow 按失败比例熔断时未指定窗口使用的时间窗口
+const DefaultFailureWindow = 10 * time.Second
+
+// FailureLimit 失败熔断阈值
+type FailureLimit struct {
+	Count  int64         // 失败总数上限，为0时不按总数判断
+	Rate   float64       // 窗口内失败数占尝试数的比例上限（0-1），为0时不按比例判断
+	Window time.Duration // 计算失败比例的时间窗口
+}
+
+// ParseMaxFailures 解析失败熔断阈值
+// 参数：
+//   - spec: "100"（失败总数超过100条）、"5%"（最近10秒内失败比例超过5%）
+//     或 "5%/30s"（最近30秒内失败比例超过5%）
+//
+// 返回值：
+//   - *FailureLimit: 解析结果，spec为空时返回nil
+//   - error: 格式无效时返回错误
+func ParseMaxFailures(spec string) (*FailureLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	rate, window, isRate := strings.Cut(spec, "%")
+	if !isRate {
+		count, err := strconv.ParseInt(spec, 10, 64)
+		if err != nil || count <= 0 {
+			return nil, fmt.Errorf("失败熔断阈值无效: %s，应为正整数（如 100）或百分比（如 5%%、5%%/30s）", spec)
+		}
+		return &FailureLimit{Count: count}, nil
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return nil, fmt.Errorf("失败熔断比例无效: %s，应在0%%-100%%之间（不含0）", spec)
+	}
+	limit := &FailureLimit{Rate: percent / 100, Window: DefaultFailureWindow}
+	if window = strings.TrimSpace(window); window != "" {
+		d, err := time.ParseDuration(strings.TrimPrefix(window, "/"))
+		if err != nil || !strings.HasPrefix(window, "/") || d < time.Second {
+			return nil, fmt.Errorf("失败熔断窗口无效: %s，格式如 5%%/30s，窗口不小于1s", spec)
+		}
+		limit.Window = d
+	}
+	return limit, nil
+}
+
+// SRVPrefix 通过DNS SRV记录发现目标的地址前缀
+const SRVPrefix = "srv+"
+
+// SRVName 判断目标地址是否为SRV目标，是时返回要查询的SRV记录名
+func SRVName(address string) (string, bool) {
+	if len(address) <= len(SRVPrefix) || !strings.EqualFold(address[:len(SRVPrefix)], SRVPrefix) {
+		return "", false
+	}
+	return address[len(SRVPrefix):], true
+}
+
+// srvProtocol 从SRV记录名（RFC 2782的 _服务._协议.域名 形式）中取出传输协议
+func srvProtocol(name string) (string, error) {
+	labels := strings.SplitN(name, ".", 3)
+	if len(labels) < 3 || !strings.HasPrefix(labels[0], "_") || labels[2] == "" {
+		return "", fmt.Errorf("SRV目标 %s 格式无效，应为 srv+_服务._协议.域名（如 srv+_syslog._udp.example.com）", name)
+	}
+	switch protocol := strings.ToLower(labels[1]); protocol {
+	case "_udp", "_tcp":
+		return protocol[1:], nil
+	default:
+		return "", fmt.Errorf("SRV目标 %s 的协议 %s 不支持（支持 _udp、_tcp）", name, labels[1])
+	}
+}
+
+// HasTargetScheme 判断目标地址是否带scheme（包括由SRV记录名决定协议的SRV目标）
+func HasTargetScheme(target string) bool {
+	if _, ok := SRVName(strings.TrimSpace(target)); ok {
+		return true
+	}
+	return strings.Contains(target, "://")
+}
+
+// WeightedValue 带权重的取值，用于按比例随机选择Severity等
+type WeightedValue struct {
+	Value  int // 取值
+	Weight int // 权重，必须大于0
+}
+
+// MixShare 返回分布中value所占的比例（0-1），不在分布中时返回0
+func MixShare(values []WeightedValue, value int) float64 {
+	var total, weight int
+	for _, v := range values {
+		total += v.Weight
+		if v.Value == value {
+			weight += v.Weight
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(weight) / float64(total)
+}
+
+// ParseSeverityMix 解析Severity分布字符串
+// 参数：
+//   - mix: 逗号分隔的 "Severity=权重" 列表，Severity可以是名称或数值，
+//     省略权重时默认为1，如 "info=70,warning=20,err=10"
+//
+// 返回值：
+//   - []WeightedValue: 解析后的分布，mix为空时返回nil
+//   - error: Severity无法识别、重复或权重无效时返回错误
+func ParseSeverityMix(mix string) ([]WeightedValue, error) {
+	return parseMix(mix, "Severity", syslog.ParseSeverity)
+}
+
+// ParseFacilityMix 解析Facility分布字符串
+// 参数：
+//   - mix: 逗号分隔的 "Facility=权重" 列表，Facility可以是名称或数值，
+//     省略权重时默认为1，如 "local0=80,auth=20"
+//
+// 返回值：
+//   - []WeightedValue: 解析后的分布，mix为空时返回nil
+//   - error: Facility无法识别、重复或权重无效时返回错误
+func ParseFacilityMix(mix string) ([]WeightedValue, error) {
+	return parseMix(mix, "Facility", syslog.ParseFacility)
+}
+
+// parseMix 解析 "取值=权重" 列表，取值由parse解析并检查范围
+func parseMix(mix, kind string, parse func(string) (int, error)) ([]WeightedValue, error) {
+	if strings.TrimSpace(mix) == "" {
+		return nil, nil
+	}
+
+	var values []WeightedValue
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(mix, ",") {
+		name, weightStr, hasWeight := strings.Cut(item, "=")
+		value, err := parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s分布无效: %w", kind, err)
+		}
+		if seen[value] {
+			return nil, fmt.Errorf("%s分布无效: %s 重复出现", kind, strings.TrimSpace(name))
+		}
+		seen[value] = true
+		weight := 1
+		if hasWeight {
+			weight, err = strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil || weight <= 0 {
+				return nil, fmt.Errorf("%s分布无效: %s 的权重必须是正整数", kind, strings.TrimSpace(name))
+			}
+		}
+		values = append(values, WeightedValue{Value: value, Weight: weight})
+	}
+	return values, nil
+}
+
+// SeverityDistribution 返回配置的Severity分布
+// 分布由SeverityMix（命令行的 "取值=权重" 列表）或SeverityWeights（配置文件中的映射）给出，只能指定其中一个
+// 返回值：
+//   - []WeightedValue: 解析后的分布，都未配置时返回nil
+//   - error: Severity无法识别、重复、权重无效或两种方式同时指定时返回错误
+func (c *Config) SeverityDistribution() ([]WeightedValue, error) {
+	return distribution(c.SeverityMix, c.SeverityWeights, "Severity", "severity", syslog.ParseSeverity)
+}
+
+// FacilityDistribution 返回配置的Facility分布，规则与SeverityDistribution相同
+func (c *Config) FacilityDistribution() ([]WeightedValue, error) {
+	return distribution(c.FacilityMix, c.FacilityWeights, "Facility", "facility", syslog.ParseFacility)
+}
+
+// distribution 从分布字符串或权重映射得到分布
+// 映射的键可以是名称或数值，结果按取值排序，保证最终统计的输出顺序稳定
+func distribution(mix string, weights map[string]int, kind, key string, parse func(string) (int, error)) ([]WeightedValue, error) {
+	if len(weights) == 0 {
+		return parseMix(mix, kind, parse)
+	}
+	if strings.TrimSpace(mix) != "" {
+		return nil, fmt.Errorf("%s分布不能同时通过 %s_mix 和 %s_weights 指定", kind, key, key)
+	}
+
+	values := make([]WeightedValue, 0, len(weights))
+	seen := make(map[int]string, len(weights))
+	for name, weight := range weights {
+		value, err := parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s分布无效: %w", kind, err)
+		}
+		if other, ok := seen[value]; ok {
+			return nil, fmt.Errorf("%s分布无效: %s 和 %s 是同一个%s", kind, other, name, kind)
+		}
+		seen[value] = name
+		if weight <= 0 {
+			return nil, fmt.Errorf("%s分布无效: %s 的权重必须是正整数", kind, name)
+		}
+		values = append(values, WeightedValue{Value: value, Weight: weight})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Value < values[j].Value })
+	return values, nil
+}
+
+// DataFileSource 一个数据文件及其权重
+type DataFileSource struct {
+	Path   string // 文件路径
+	Weight int    // 权重，未指定时为0；所有文件都未指定权重时按顺序依次读取
+}
+
+// DataFileSpecs 返回配置的所有数据文件（DataFile在前，DataFiles在后）
+func (c *Config) DataFileSpecs() []string {
+	var specs []string
+	if c.DataFile != "" {
+		specs = append(specs, c.DataFile)
+	}
+	return append(specs, c.DataFiles...)
+}
+
+// ExpandDataFiles 展开数据文件列表
+// 参数：
+//   - specs: 文件路径列表，每项可以包含通配符（如 logs/*.log），
+//     可以用 "路径=权重" 指定权重，通配符匹配到的每个文件使用相同的权重
+//
+// 返回值：
+//   - []DataFileSource: 展开后的文件列表，按指定顺序排列，通配符匹配的文件按文件名排序
+//   - error: 权重无效、通配符格式错误或没有匹配到文件时返回错误
+func ExpandDataFiles(specs []string) ([]DataFileSource, error) {
+	var sources []DataFileSource
+	for _, spec := range specs {
+		path, weight := spec, 0
+		// 只有=后面是数字时才视为权重，文件名本身可以包含=
+		if i := strings.LastIndex(spec, "="); i >= 0 {
+			if n, err := strconv.Atoi(spec[i+1:]); err == nil {
+				if n <= 0 {
+					return nil, fmt.Errorf("数据文件 %s 的权重必须是正整数", spec[:i])
+				}
+				path, weight = spec[:i], n
+			}
+		}
+		if path == "" {
+			return nil, fmt.Errorf("数据文件路径不能为空")
+		}
+
+		if !strings.ContainsAny(path, "*?[") {
+			sources = append(sources, DataFileSource{Path: path, Weight: weight})
+			continue
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("数据文件通配符无效: %s", path)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("数据文件通配符没有匹配到文件: %s", path)
+		}
+		for _, match := range matches {
+			sources = append(sources, DataFileSource{Path: match, Weight: weight})
+		}
+	}
+	return sources, nil
+}
+
+// SeverityTemplateMap 将SeverityTemplates的键解析为Severity数值
+// 返回值：
+//   - map[int]string: Severity到消息模板的映射
+//   - error: 键无法识别或同一Severity配置了多个模板时返回错误
+func (c *Config) SeverityTemplateMap() (map[int]string, error) {
+	templates := make(map[int]string, len(c.SeverityTemplates))
+	for key, tmpl := range c.SeverityTemplates {
+		severity, err := syslog.ParseSeverity(key)
+		if err != nil {
+			return nil, fmt.Errorf("Severity模板无效: %w", err)
+		}
+		if _, exists := templates[severity]; exists {
+			return nil, fmt.Errorf("Severity %s 配置了多个模板", syslog.GetSeverityName(severity))
+		}
+		templates[severity] = tmpl
+	}
+	return templates, nil
+}
+
+// validateStructuredData 校验结构化数据元素
+// 只适用于RFC5424格式；同一条消息中的SD-ID不能重复（RFC5424 6.3.2），包括 --origin 添加的元素
+func (c *Config) validateStructuredData() error {
+	if len(c.StructuredData) == 0 {
+		return nil
+	}
+	if c.GetSyslogFormat() != syslog.RFC5424 {
+		return fmt.Errorf("结构化数据只适用于rfc5424格式")
+	}
+	ids := make(map[string]bool, len(c.StructuredData)+1)
+	if c.Origin {
+		ids[syslog.SDID("origin", c.EnterpriseID)] = true
+	}
+	for _, item := range c.StructuredData {
+		element, err := syslog.ParseSDElement(item)
+		if err != nil {
+			return fmt.Errorf("结构化数据 %s 无效: %w", item, err)
+		}
+		if ids[element.ID] {
+			return fmt.Errorf("结构化数据的SD-ID %s 重复", element.ID)
+		}
+		ids[element.ID] = true
+	}
+	return nil
+}
+
+// validateFields 校验JSON格式的附加字段
+// 只适用于json格式；字段名不能为空、不能重复，也不能与timestamp、message等标准字段重名
+func (c *Config) validateFields() error {
+	if len(c.Fields) == 0 {
+		return nil
+	}
+	if c.GetSyslogFormat() != syslog.JSON {
+		return fmt.Errorf("附加字段只适用于json格式")
+	}
+	seen := make(map[string]bool, len(c.Fields))
+	for _, item := range c.Fields {
+		key, _, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("无效的附加字段 %q，格式应为 键=值", item)
+		}
+		if syslog.IsReservedJSONKey(key) {
+			return fmt.Errorf("附加字段 %s 与JSON消息的标准字段重名", key)
+		}
+		if seen[key] {
+			return fmt.Errorf("附加字段 %s 重复", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// GetSyslogFormat 返回发送时使用的Syslog格式
+// Raw为true时原样透传消息内容，否则按Format格式化
+func (c *Config) GetSyslogFormat() syslog.SyslogFormat {
+	if c.Raw {
+		return syslog.Raw
+	}
+	return syslog.ParseFormat(c.Format)
+}
+
 // GetPriority 计算Syslog优先级
 func (c *Config) GetPriority() int {
 	return c.Facility*8 + c.Severity
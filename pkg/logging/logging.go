@@ -0,0 +1,153 @@
+// Package logging 提供发送器、服务器和原始套接字共用的诊断日志
+// 文本格式保持原有的中文提示输出，JSON格式每个事件输出一行，包含时间、级别、消息和字段，便于自动化解析
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 日志格式
+const (
+	FormatText = "text" // 人类可读的文本（默认）
+	FormatJSON = "json" // 每行一个JSON事件
+)
+
+// ValidateFormat 检查日志格式是否有效，空字符串视为text
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("日志格式必须是 text 或 json: %s", format)
+	}
+}
+
+// Logger 诊断日志
+// 在slog.Logger的基础上增加格式化方法，可以用Info/Warn/Error附带结构化字段
+type Logger struct {
+	*slog.Logger
+	json bool
+}
+
+// New 创建日志
+// 参数：
+//   - format: 日志格式，text或json，空字符串视为text
+//   - out: 信息和错误日志的输出目标
+//   - errOut: 警告日志的输出目标；JSON格式下所有事件都写到out
+//   - timestamps: 文本格式下是否在每行前加上时间（与标准库log的默认格式相同）
+//
+// 返回值：
+//   - *Logger: 日志实例
+//   - error: 格式无效时返回错误
+func New(format string, out, errOut io.Writer, timestamps bool) (*Logger, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	if format == FormatJSON {
+		return &Logger{Logger: slog.New(slog.NewJSONHandler(out, nil)), json: true}, nil
+	}
+	return &Logger{Logger: slog.New(&textHandler{out: out, errOut: errOut, timestamps: timestamps, mutex: &sync.Mutex{}})}, nil
+}
+
+// Default 返回写到标准输出和标准错误的文本日志
+func Default() *Logger {
+	l, _ := New(FormatText, os.Stdout, os.Stderr, false)
+	return l
+}
+
+// JSON 是否为JSON格式，调用方据此决定是输出多行文本摘要还是一个结构化事件
+func (l *Logger) JSON() bool {
+	return l.json
+}
+
+// Infof 输出格式化的信息日志
+func (l *Logger) Infof(format string, args ...any) {
+	l.Info(fmt.Sprintf(format, args...))
+}
+
+// Warnf 输出格式化的警告日志
+func (l *Logger) Warnf(format string, args ...any) {
+	l.Warn(fmt.Sprintf(format, args...))
+}
+
+// Errorf 输出格式化的错误日志
+func (l *Logger) Errorf(format string, args ...any) {
+	l.Error(fmt.Sprintf(format, args...))
+}
+
+// Writer 返回一个按行转换为信息日志的io.Writer，用于只接受io.Writer的组件（如模板引擎）
+func (l *Logger) Writer() io.Writer {
+	return &lineWriter{logger: l}
+}
+
+// lineWriter 将写入的每一行作为一条信息日志
+type lineWriter struct {
+	logger *Logger
+	buf    bytes.Buffer
+	mutex  sync.Mutex
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// 不完整的行留到下次写入
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			w.logger.Info(line)
+		}
+	}
+}
+
+// textHandler 文本格式的slog处理器
+// 只输出消息文本，字段只用于JSON格式；警告加上"警告: "前缀并写到errOut
+type textHandler struct {
+	out        io.Writer
+	errOut     io.Writer
+	timestamps bool
+	mutex      *sync.Mutex
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if h.timestamps {
+		b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	}
+	w := h.out
+	if r.Level == slog.LevelWarn {
+		w = h.errOut
+		b.WriteString("警告: ")
+	}
+	b.WriteString(r.Message)
+	b.WriteString("\n")
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(_ []slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *textHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
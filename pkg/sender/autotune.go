@@ -0,0 +1,122 @@
+package sender
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// receivedMetric 反馈接口中表示服务端收到消息总数的计数器名称
+const receivedMetric = "syslog_go_received_total"
+
+// 每次调节EPS时允许的最大变化倍数，避免反馈抖动导致速率剧烈波动
+const maxTuneFactor = 2.0
+
+// autoTune EPS闭环调节协程
+// 周期性读取服务端收到的消息总数，计算实际收到的速率，
+// 按 目标速率/实际速率 的比例调整发送EPS，使服务端收到的速率逼近目标
+func (s *Sender) autoTune() {
+	defer s.wg.Done()
+
+	client := &http.Client{Timeout: s.config.FeedbackInterval}
+	ticker := time.NewTicker(s.config.FeedbackInterval)
+	defer ticker.Stop()
+
+	lastCount, err := fetchReceivedCount(client, s.config.FeedbackURL)
+	if err != nil {
+		s.log.Warn("读取反馈失败，EPS自动调节已停止: "+err.Error(), "error", err.Error())
+		return
+	}
+	lastTime := time.Now()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		count, err := fetchReceivedCount(client, s.config.FeedbackURL)
+		if err != nil {
+			if s.config.Verbose {
+				s.log.Error("读取反馈失败: "+err.Error(), "error", err.Error())
+			}
+			continue
+		}
+		now := time.Now()
+		received := float64(count-lastCount) / now.Sub(lastTime).Seconds()
+		lastCount, lastTime = count, now
+
+		current := s.rateLimiter.GetRate()
+		next := nextTunedRate(current, received, float64(s.config.TargetReceivedEPS))
+		if next != current {
+			s.rateLimiter.SetRate(int(next))
+		}
+		if s.config.Verbose {
+			s.log.Info(fmt.Sprintf("[调节] 服务端收到: %.2f/s, 目标: %d/s, EPS: %d -> %d", received, s.config.TargetReceivedEPS, current, next),
+				"event", "autotune", "received_eps", received, "target_eps", s.config.TargetReceivedEPS, "eps_before", current, "eps_after", next)
+		}
+	}
+}
+
+// nextTunedRate 根据服务端实际收到的速率计算下一轮的发送EPS
+// 按比例修正并限制单次变化不超过maxTuneFactor倍；服务端没有收到消息时按最大倍数提高
+func nextTunedRate(current int64, received, target float64) int64 {
+	factor := maxTuneFactor
+	if received > 0 {
+		factor = target / received
+	}
+	if factor > maxTuneFactor {
+		factor = maxTuneFactor
+	} else if factor < 1/maxTuneFactor {
+		factor = 1 / maxTuneFactor
+	}
+
+	next := int64(float64(current)*factor + 0.5)
+	if next < 1 {
+		next = 1
+	}
+	return next
+}
+
+// fetchReceivedCount 从反馈地址读取服务端收到的消息总数
+// 支持Prometheus文本格式（读取syslog_go_received_total）或只包含一个整数的响应
+func fetchReceivedCount(client *http.Client, url string) (int64, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("反馈接口返回 %s", resp.Status)
+	}
+	return parseReceivedCount(resp.Body)
+}
+
+// parseReceivedCount 解析反馈接口的响应
+func parseReceivedCount(r io.Reader) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, receivedMetric+" "); ok {
+			line = strings.TrimSpace(value)
+		} else if strings.ContainsAny(line, " \t") {
+			// 其他计数器
+			continue
+		}
+		if n, err := strconv.ParseFloat(line, 64); err == nil {
+			return int64(n), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("响应中没有找到 %s", receivedMetric)
+}
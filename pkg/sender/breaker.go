@@ -0,0 +1,83 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTooManyFailures 失败数或失败比例超过 --max-failures 阈值，发送提前结束
+var ErrTooManyFailures = errors.New("失败次数超过阈值")
+
+// 按失败比例熔断的检测参数：每个间隔取一次计数，窗口内尝试数不足时不判断，避免刚开始时个别失败就触发
+const (
+	failureCheckInterval = time.Second
+	failureMinAttempts   = 20
+)
+
+// failureSample 一次计数采样
+type failureSample struct {
+	at        time.Time
+	attempted int64
+	failed    int64
+}
+
+// checkFailures 失败总数超过阈值时停止发送，返回是否已停止
+// 由工作协程在每次发送前调用，只读取原子计数
+func (s *Sender) checkFailures() bool {
+	if s.failureLimit == nil || s.failureLimit.Count == 0 {
+		return false
+	}
+	failed := atomic.LoadInt64(&s.stats.Failed)
+	if failed <= s.failureLimit.Count {
+		return false
+	}
+	s.abort(fmt.Errorf("%w: 已失败 %d 条，超过 --max-failures %d", ErrTooManyFailures, failed, s.failureLimit.Count))
+	return true
+}
+
+// watchFailureRate 失败比例检测协程
+// 每秒采样一次，窗口内失败数占尝试数的比例超过阈值时停止发送
+func (s *Sender) watchFailureRate() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(failureCheckInterval)
+	defer ticker.Stop()
+
+	samples := []failureSample{{at: time.Now(), attempted: s.attempted(), failed: atomic.LoadInt64(&s.stats.Failed)}}
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := failureSample{at: time.Now(), attempted: s.attempted(), failed: atomic.LoadInt64(&s.stats.Failed)}
+		samples = append(samples, now)
+		// 保留窗口起点之前的最后一个采样作为比较基准
+		for len(samples) > 2 && now.at.Sub(samples[1].at) >= s.failureLimit.Window {
+			samples = samples[1:]
+		}
+
+		base := samples[0]
+		attempted, failed := now.attempted-base.attempted, now.failed-base.failed
+		if attempted < failureMinAttempts {
+			continue
+		}
+		if rate := float64(failed) / float64(attempted); rate > s.failureLimit.Rate {
+			s.abort(fmt.Errorf("%w: 最近 %v 内失败 %d/%d 条 (%.1f%%)，超过 --max-failures %g%%",
+				ErrTooManyFailures, now.at.Sub(base.at).Round(time.Second), failed, attempted, rate*100, s.failureLimit.Rate*100))
+			return
+		}
+	}
+}
+
+// abort 记录提前结束的原因并停止发送，只有第一次调用生效
+func (s *Sender) abort(err error) {
+	s.abortOnce.Do(func() {
+		s.abortErr = err
+		s.log.Error(err.Error(), "event", "abort", "error", err.Error())
+		s.cancel()
+	})
+}
@@ -0,0 +1,37 @@
+package sender
+
+import "sync"
+
+// 缓冲区池参数：新缓冲区的初始容量足够容纳常见的单条消息，
+// 超过maxPooledBuffer的缓冲区（如偶尔的超长消息）不放回池中，避免长期占用内存
+const (
+	pooledBufferSize = 1024
+	maxPooledBuffer  = 64 * 1024
+)
+
+// bufferPool 序列化消息和组装数据包使用的缓冲区池
+// 存放 *[]byte 以避免放回池时的额外分配；高速率发送时每条消息复用缓冲区，
+// 减少分配和GC压力
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, pooledBufferSize)
+		return &buf
+	},
+}
+
+// getBuffer 从池中取出一个长度为0的缓冲区
+func getBuffer() *[]byte {
+	buf := bufferPool.Get().(*[]byte)
+	*buf = (*buf)[:0]
+	return buf
+}
+
+// putBuffer 将缓冲区放回池中
+// 调用方必须确保缓冲区的内容已写出且不再被引用：写入连接是同步的
+// （压缩连接在Write返回前已复制到zlib流），写入返回后即可放回
+func putBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,104 @@
+package sender
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"syslog_go/pkg/config"
+	"syslog_go/pkg/syslog"
+)
+
+// recordingWriter 模拟传输层：Write返回前复制数据，与内核发送缓冲区的语义相同
+type recordingWriter struct {
+	writes [][]byte
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// newEncodeSender 创建只用于序列化的演练模式发送器，输出写到w
+func newEncodeSender(t testing.TB, w io.Writer, framing string) *Sender {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Protocol = "tcp"
+	cfg.Format = "rfc5424"
+	cfg.Message = "bench"
+	cfg.Framing = framing
+	cfg.DryRun = true
+	cfg.Quiet = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("配置验证失败: %v", err)
+	}
+	s, err := NewSenderWithOutput(cfg, w, io.Discard)
+	if err != nil {
+		t.Fatalf("创建发送器失败: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// TestEncodeMessageBufferReuse 消息A的缓冲区放回池后序列化消息B，已交给传输层的A的字节不变
+func TestEncodeMessageBufferReuse(t *testing.T) {
+	w := &recordingWriter{}
+	s := newEncodeSender(t, w, config.FramingLF)
+	msgA := syslog.NewMessage(14, "host-a", "app", "message A with a longer body", syslog.RFC5424)
+	msgB := syslog.NewMessage(11, "host-b", "app", "B", syslog.RFC5424)
+
+	bufA := s.encodeMessage(msgA)
+	wantA := string(*bufA)
+	if err := s.writeDryRun(*bufA); err != nil {
+		t.Fatal(err)
+	}
+	putBuffer(bufA)
+
+	bufB := s.encodeMessage(msgB)
+	wantB := string(*bufB)
+	if err := s.writeDryRun(*bufB); err != nil {
+		t.Fatal(err)
+	}
+	putBuffer(bufB)
+
+	if len(w.writes) != 2 {
+		t.Fatalf("传输层收到 %d 次写入，期望 2 次", len(w.writes))
+	}
+	if got := string(w.writes[0]); got != wantA {
+		t.Fatalf("消息A的字节被修改为 %q，期望 %q", got, wantA)
+	}
+	if got := string(w.writes[1]); got != wantB {
+		t.Fatalf("消息B为 %q，期望 %q", got, wantB)
+	}
+	if !bytes.Contains(w.writes[0], []byte("message A")) || !bytes.Contains(w.writes[1], []byte("host-b")) {
+		t.Fatalf("传输层收到 %q", w.writes)
+	}
+
+	// 未放回的缓冲区不会被再次取出
+	held := s.encodeMessage(msgA)
+	heldWant := string(*held)
+	other := s.encodeMessage(msgB)
+	if string(*held) != heldWant {
+		t.Fatalf("持有中的缓冲区被修改为 %q，期望 %q", *held, heldWant)
+	}
+	if &(*held)[0] == &(*other)[0] {
+		t.Fatal("两条消息共用了同一块缓冲区")
+	}
+	putBuffer(held)
+	putBuffer(other)
+}
+
+// BenchmarkEncodeMessage 序列化一条消息并放回缓冲区
+func BenchmarkEncodeMessage(b *testing.B) {
+	for _, framing := range []string{config.FramingLF, config.FramingOctet} {
+		b.Run(framing, func(b *testing.B) {
+			s := newEncodeSender(b, io.Discard, framing)
+			msg := syslog.NewMessage(14, "host", "app", "benchmark message body", syslog.RFC5424)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				putBuffer(s.encodeMessage(msg))
+			}
+		})
+	}
+}
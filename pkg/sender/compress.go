@@ -0,0 +1,91 @@
+package sender
+
+import (
+	"compress/zlib"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// CompressionStats TCP压缩统计，由连接池中所有压缩连接共享
+type CompressionStats struct {
+	RawBytes        int64 // 压缩前的字节数，原子操作更新
+	CompressedBytes int64 // 压缩后实际写入连接的字节数，原子操作更新
+}
+
+// Ratio 返回压缩后与压缩前的字节数之比，尚未发送数据时返回0
+func (c *CompressionStats) Ratio() float64 {
+	raw := atomic.LoadInt64(&c.RawBytes)
+	if raw == 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&c.CompressedBytes)) / float64(raw)
+}
+
+// compressedConn 对TCP连接的写入进行zlib压缩
+// 连接建立后直接写出zlib流（以zlib头开始，无额外协商），服务端据此识别压缩连接；
+// 每次Write或WriteBatch后执行同步刷新，保证消息及时到达，压缩字典在整个连接上延续
+type compressedConn struct {
+	net.Conn
+	zw    *zlib.Writer
+	stats *CompressionStats
+	mutex sync.Mutex
+}
+
+// countingWriter 统计写入底层连接的字节数
+type countingWriter struct {
+	w     io.Writer
+	count *int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(c.count, int64(n))
+	return n, err
+}
+
+// newCompressedConn 创建压缩连接
+func newCompressedConn(conn net.Conn, stats *CompressionStats) *compressedConn {
+	return &compressedConn{
+		Conn:  conn,
+		zw:    zlib.NewWriter(countingWriter{w: conn, count: &stats.CompressedBytes}),
+		stats: stats,
+	}
+}
+
+// Write 压缩并发送一条消息
+func (c *compressedConn) Write(b []byte) (int, error) {
+	if _, err := c.WriteBatch([][]byte{b}); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// WriteBatch 压缩多条消息后一次刷新，实现BatchWriter
+// 刷新失败时无法确定对端收到了哪些消息，按全部失败处理
+func (c *compressedConn) WriteBatch(msgs [][]byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var raw int
+	for _, msg := range msgs {
+		if _, err := c.zw.Write(msg); err != nil {
+			return 0, err
+		}
+		raw += len(msg)
+	}
+	if err := c.zw.Flush(); err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&c.stats.RawBytes, int64(raw))
+	return len(msgs), nil
+}
+
+// Close 写出zlib流结尾后关闭连接
+func (c *compressedConn) Close() error {
+	c.mutex.Lock()
+	c.zw.Close()
+	c.mutex.Unlock()
+	return closeGracefully(c.Conn)
+}
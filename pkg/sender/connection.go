@@ -1,11 +1,24 @@
 package sender
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
 	"net"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+
+	"syslog_go/pkg/config"
+	"syslog_go/pkg/logging"
 )
 
 // ConnectionPool 连接池结构体
@@ -19,7 +32,7 @@ import (
 type ConnectionPool struct {
 	// 基础配置
 	address  string        // 目标服务器地址，格式：host:port
-	protocol string        // 网络协议，支持tcp和udp
+	protocol string        // 网络协议，支持tcp、udp和unix
 	maxSize  int           // 连接池最大容量
 	timeout  time.Duration // 连接超时时间
 
@@ -28,122 +41,275 @@ type ConnectionPool struct {
 	mutex       sync.RWMutex  // 读写锁，保护并发访问
 	closed      bool          // 连接池状态标志
 
-	// 高级功能
-	sourceIP string // 源IP地址，用于IP伪装，为空则使用系统默认地址
-	verbose  bool   // 是否输出详细日志（用于打印所用网卡等）
+	// 拨号
+	ctx       context.Context // 预创建和重建连接的拨号都受其控制，取消后Get不再阻塞在拨号上
+	transport Transport       // 按协议选用的传输，负责建立连接（包括源IP绑定和伪造）
+	verbose   bool            // 是否输出详细日志（用于打印SRV解析结果等）
+
+	log *logging.Logger // 详细日志和警告
+
+	compression *CompressionStats // TCP压缩统计，为nil时不压缩
+
+	// 连接重建
+	maxUses  int              // 每个连接最多写入的消息数，达到后关闭并重新建立，为0时不限制
+	uses     map[net.Conn]int // 每个连接已写入的消息数
+	usesMu   sync.Mutex       // 保护uses
+	dials    int64            // 成功建立的连接数（包括预创建），原子操作更新
+	retired  int64            // 因达到maxUses而关闭的连接数，原子操作更新
+	retiring sync.WaitGroup   // 正在后台关闭的连接，Close时等待它们完成
 }
 
+// maxDialConcurrency 预创建连接时允许同时进行的最大拨号数
+const maxDialConcurrency = 16
+
 // NewConnectionPool 创建新的连接池
-func NewConnectionPool(address, protocol string, maxSize int, timeout time.Duration, sourceIP string, verbose bool) (*ConnectionPool, error) {
+// 预创建的连接会以有限并发的方式同时建立，任意一个连接失败即取消其余拨号，
+// 避免目标不可达时按 maxSize * timeout 串行阻塞。
+//
+// sourceIP为本机地址（包括网卡别名）时直接绑定该地址，无需特殊权限；
+// 只有spoof为true时才对非本机地址使用原始套接字伪造源IP。
+// sourcePort大于0时每个连接都绑定该源端口，因此通常只适合单个连接。
+//
+// ctx在连接池的整个生命周期内有效：Get在连接失效或池为空时重新拨号也受其控制，
+// 发送停止（ctx取消）时正在进行的拨号立即返回，而不是等到timeout。
+//
+// compress为true时对TCP连接的写入进行zlib压缩。
+// tlsConfig不为nil时TCP连接使用TLS，握手与建立连接一起受timeout限制。
+// logger接收详细日志和警告，为nil时输出到进程的标准输出和标准错误。
+// 连接由按protocol选用的Transport建立（见RegisterTransport）。
+func NewConnectionPool(ctx context.Context, address, protocol string, maxSize int, timeout time.Duration, sourceIP string, sourcePort int, spoof, verbose, compress bool, tlsConfig *tls.Config, logger *logging.Logger) (*ConnectionPool, error) {
+	if logger == nil {
+		logger = logging.Default()
+	}
+
+	transport, err := newTransport(protocol, TransportOptions{
+		Timeout:    timeout,
+		SourceIP:   sourceIP,
+		SourcePort: sourcePort,
+		Spoof:      spoof,
+		Verbose:    verbose,
+		TLS:        tlsConfig,
+		Logger:     logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// UDP/TCP目标规范化为 host:port，自定义传输的地址原样传给Transport
+	if _, srv := config.SRVName(address); (protocol == "udp" || protocol == "tcp") && !srv {
+		if t, ok := transport.(*netTransport); ok && t.spoofing() && isLocalTarget(address) {
+			warnLocalTarget(logger, t.sourceIP, address, protocol)
+		}
+		address = normalizeAddress(address)
+	}
+
 	pool := &ConnectionPool{
 		address:     address,
 		protocol:    protocol,
 		maxSize:     maxSize,
 		timeout:     timeout,
 		connections: make(chan net.Conn, maxSize),
-		sourceIP:    sourceIP,
+		ctx:         ctx,
+		transport:   transport,
 		verbose:     verbose,
+		log:         logger,
+	}
+	if compress && protocol == "tcp" {
+		pool.compression = &CompressionStats{}
 	}
 
 	// 预创建连接
-	for i := 0; i < maxSize; i++ {
-		conn, err := pool.createConnection()
-		if err != nil {
-			// 如果无法创建连接，关闭已创建的连接
-			pool.Close()
-			return nil, fmt.Errorf("创建连接失败: %w", err)
-		}
-		pool.connections <- conn
+	if err := pool.fill(ctx); err != nil {
+		// 如果无法创建连接，关闭已创建的连接
+		pool.Close()
+		return nil, fmt.Errorf("创建连接失败: %w", err)
 	}
 
 	return pool, nil
 }
 
-// createConnection 创建新连接
-// 支持IPv4和IPv6地址格式，支持原始套接字模拟源IP地址
-func (p *ConnectionPool) createConnection() (net.Conn, error) {
-	// 构建网络地址
-	network := p.protocol
-	if network == "tcp" || network == "udp" {
-		// 检查是否为IPv6地址
-		if strings.Contains(p.address, ":") {
-			// 如果地址中包含多个冒号，说明是IPv6地址
-			// 检查地址是否已包含端口号
-			if !strings.HasSuffix(p.address, "]") {
-				// 如果地址不是以]结尾，说明需要添加端口号
-				// 查找最后一个冒号，它应该是端口号分隔符
-				lastColon := strings.LastIndex(p.address, ":")
-				if lastColon != -1 {
-					// 分离地址和端口
-					host := p.address[:lastColon]
-					port := p.address[lastColon+1:]
-					// 重新组合地址，确保IPv6地址被方括号包围
-					if !strings.HasPrefix(host, "[") {
-						host = "[" + host + "]"
-					}
-					p.address = host + ":" + port
-				}
+// fill 并发预创建 maxSize 个连接
+// 同时进行的拨号数不超过 maxDialConcurrency，首个错误会取消其余拨号并被返回
+func (p *ConnectionPool) fill(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, maxDialConcurrency)
+
+	for i := 0; i < p.maxSize; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
 			}
-		}
 
-		// 如果指定了源IP地址且不是本机IP，尝试使用原始套接字
-		if p.sourceIP != "" && !isLocalIP(p.sourceIP) {
-			fmt.Printf("尝试使用原始套接字模拟源IP地址: %s\n", p.sourceIP)
-			// 尝试创建原始套接字连接
-			rawConn, err := newRawSocketConn(p.sourceIP, p.address, network, true) // 启用详细日志
+			conn, err := p.createConnection(ctx)
 			if err != nil {
-				fmt.Printf("警告: 创建原始套接字失败: %v\n", err)
-				fmt.Printf("回退到标准连接，使用系统默认地址\n")
-				// 回退到标准连接，不设置源IP
-				baseDialer := &net.Dialer{Timeout: p.timeout}
-				conn, derr := baseDialer.Dial(network, p.address)
-				if derr != nil {
-					return nil, derr
-				}
-				p.logInterfaceForConn(conn)
-				return conn, nil
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
 			}
-			if p.verbose {
-				// 尝试根据源IP解析本地网卡名称（仅当源IP是本机IP时有效）
-				name := lookupInterfaceNameByIP(net.ParseIP(p.sourceIP))
-				if name != "" && isLocalIP(p.sourceIP) {
-					fmt.Printf("使用原始套接字 使用网卡: %s 源IP: %s -> 目标: %s 协议: %s\n", name, p.sourceIP, p.address, p.protocol)
-				} else {
-					fmt.Printf("使用原始套接字 源IP: %s -> 目标: %s 协议: %s（若为非本机IP，出口网卡由路由决定）\n", p.sourceIP, p.address, p.protocol)
+			// 容量为maxSize，不会阻塞
+			p.connections <- conn
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// warnSpoofDisabled 输出源IP伪造被禁用的警告
+// 无论是否开启详细模式都会输出，避免用户误以为伪造生效
+func warnSpoofDisabled(logger *logging.Logger, sourceIP string, reason error) {
+	logger.Warn(reason.Error())
+	logger.Warn("已回退到标准连接，源IP伪造已禁用，消息将使用系统默认源地址发送而不是 "+sourceIP,
+		"source_ip", sourceIP, "error", reason.Error())
+}
+
+// warnLocalTarget 伪造源IP发送到本机目标时输出警告
+// 数据包经回环接口投递，结果通常与向远端发送不同，只做提示，不影响发送
+func warnLocalTarget(logger *logging.Logger, sourceIP, address, protocol string) {
+	reason := "接收端可能看到伪造的源IP，但反向路径过滤（rp_filter）等内核策略可能丢弃这些数据包"
+	if protocol == "tcp" {
+		reason = "TCP握手的回复发往伪造的源IP，连接无法建立"
+	}
+	logger.Warn(fmt.Sprintf("目标 %s 是本机地址，伪造源IP %s 的数据包经回环接口投递：%s；"+
+		"测试伪造源IP请从另一台主机发送或发送到非本机目标", address, sourceIP, reason),
+		"target", address, "source_ip", sourceIP, "protocol", protocol)
+}
+
+// ErrMessageTooLarge 消息超过数据报上限（UDP单个数据报最大65507字节载荷），需要改用TCP发送
+var ErrMessageTooLarge = errors.New("消息超过UDP数据报上限")
+
+// wsaEMSGSIZE Windows套接字返回的WSAEMSGSIZE，与syscall.EMSGSIZE不是同一个值
+const wsaEMSGSIZE = syscall.Errno(10040)
+
+// isMessageTooLarge 判断写入错误是否因消息超过数据报上限（EMSGSIZE）引起
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, wsaEMSGSIZE)
+}
+
+// isWriteTimeout 判断写入错误是否因超过写截止时间引起
+func isWriteTimeout(err error) bool {
+	return errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+// normalizeAddress 规范化目标地址
+// 支持IPv4和IPv6地址格式，确保IPv6地址被方括号包围
+func normalizeAddress(address string) string {
+	// 检查是否为IPv6地址
+	if strings.Contains(address, ":") {
+		// 如果地址中包含多个冒号，说明是IPv6地址
+		// 检查地址是否已包含端口号
+		if !strings.HasSuffix(address, "]") {
+			// 如果地址不是以]结尾，说明需要添加端口号
+			// 查找最后一个冒号，它应该是端口号分隔符
+			lastColon := strings.LastIndex(address, ":")
+			if lastColon != -1 {
+				// 分离地址和端口
+				host := address[:lastColon]
+				port := address[lastColon+1:]
+				// 重新组合地址，确保IPv6地址被方括号包围（IPv4地址和主机名保持原样）
+				if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+					host = "[" + host + "]"
 				}
+				address = host + ":" + port
 			}
-			return rawConn, nil
 		}
+	}
+	return address
+}
 
-		// 使用Dialer以支持设置源IP地址
-		dialer := &net.Dialer{
-			Timeout: p.timeout,
-		}
+// createConnection 创建新连接，启用压缩时包装为zlib压缩连接
+func (p *ConnectionPool) createConnection(ctx context.Context) (net.Conn, error) {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&p.dials, 1)
+	if p.compression == nil {
+		return conn, nil
+	}
+	return newCompressedConn(conn, p.compression), nil
+}
 
-		// 如果指定了源IP地址且为本机IP，设置本地地址
-		if p.sourceIP != "" && isLocalIP(p.sourceIP) {
-			var localAddr net.Addr
-			if network == "tcp" {
-				localAddr, _ = net.ResolveTCPAddr(network, p.sourceIP+":0")
-			} else if network == "udp" {
-				localAddr, _ = net.ResolveUDPAddr(network, p.sourceIP+":0")
-			}
-			if localAddr != nil {
-				dialer.LocalAddr = localAddr
+// Compression 返回TCP压缩统计，未启用压缩时返回nil
+func (p *ConnectionPool) Compression() *CompressionStats {
+	return p.compression
+}
+
+// dial 建立到目标的连接
+// SRV目标每次建立连接时重新查询SRV记录，按优先级和权重依次尝试，
+// 因此连接失效重建时会切换到当前可用的目标
+func (p *ConnectionPool) dial(ctx context.Context) (net.Conn, error) {
+	name, ok := config.SRVName(p.address)
+	if !ok || p.protocol == "unix" {
+		return p.dialAddress(ctx, p.address)
+	}
+
+	addresses, err := lookupSRV(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range addresses {
+		var conn net.Conn
+		if conn, err = p.dialAddress(ctx, address); err == nil {
+			if p.verbose {
+				p.log.Info(fmt.Sprintf("SRV记录 %s 解析为 %s", name, address), "srv", name, "target", address)
 			}
+			return conn, nil
 		}
+		if ctx.Err() != nil {
+			break
+		}
+		if p.verbose {
+			p.log.Warn(fmt.Sprintf("连接SRV目标 %s 失败，尝试下一个: %v", address, err), "srv", name, "target", address, "error", err.Error())
+		}
+	}
+	return nil, fmt.Errorf("SRV记录 %s 的所有目标均连接失败: %w", name, err)
+}
 
-		conn, err := dialer.Dial(network, p.address)
-		if err != nil {
-			return nil, err
+// lookupSRV 查询SRV记录，返回按优先级排列（同一优先级内按权重随机排列）的目标地址
+func lookupSRV(ctx context.Context, name string) ([]string, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("查询SRV记录 %s 失败: %w", name, err)
+	}
+	addresses := make([]string, 0, len(records))
+	for _, r := range records {
+		// 目标为 "." 表示该服务在此域名下不可用（RFC 2782）
+		if target := strings.TrimSuffix(r.Target, "."); target != "" {
+			addresses = append(addresses, net.JoinHostPort(target, strconv.Itoa(int(r.Port))))
 		}
-		p.logInterfaceForConn(conn)
-		return conn, nil
 	}
-	return nil, fmt.Errorf("不支持的协议: %s", p.protocol)
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("SRV记录 %s 没有可用的目标", name)
+	}
+	return addresses, nil
+}
+
+// dialAddress 通过传输建立到指定地址的连接，拨号受ctx控制，ctx取消时立即返回
+func (p *ConnectionPool) dialAddress(ctx context.Context, address string) (net.Conn, error) {
+	return p.transport.Dial(ctx, address)
 }
 
 // Get 从连接池获取连接
+// 连接失效或池为空时用连接池的ctx重新拨号，ctx取消后拨号立即返回错误
 func (p *ConnectionPool) Get() (net.Conn, error) {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
@@ -159,21 +325,97 @@ func (p *ConnectionPool) Get() (net.Conn, error) {
 			return conn, nil
 		}
 		// 连接无效，创建新连接
+		p.forget(conn)
 		conn.Close()
-		return p.createConnection()
+		return p.createConnection(p.ctx)
 	default:
 		// 连接池为空，创建新连接
-		return p.createConnection()
+		return p.createConnection(p.ctx)
+	}
+}
+
+// Discard 关闭从连接池获取的连接而不放回，用于已无法继续使用的连接（如写入超时）
+func (p *ConnectionPool) Discard(conn net.Conn) {
+	if conn != nil {
+		p.forget(conn)
+		conn.Close()
 	}
 }
 
+// SetMaxUses 设置每个连接最多写入的消息数
+// 连接写满n条消息后在归还时关闭，下次获取时重新建立，用于压测接收端的连接建立和拆除；
+// n为0时连接一直复用
+func (p *ConnectionPool) SetMaxUses(n int) {
+	p.usesMu.Lock()
+	defer p.usesMu.Unlock()
+	p.maxUses = n
+	if n > 0 && p.uses == nil {
+		p.uses = make(map[net.Conn]int)
+	}
+}
+
+// Release 归还连接并记录本次写入的消息数
+// 连接累计写入的消息数达到SetMaxUses设置的上限时关闭连接而不放回连接池，
+// 批量写入时在整批写完后才关闭，因此一个连接实际写入的消息数可能略多于上限。
+// 连接在后台排空并关闭，不阻塞发送，Close会等待这些连接关闭完成
+func (p *ConnectionPool) Release(conn net.Conn, n int) {
+	if p.used(conn, n) {
+		atomic.AddInt64(&p.retired, 1)
+		p.closeInBackground(conn)
+		return
+	}
+	p.Put(conn)
+}
+
+// closeInBackground 在后台排空并关闭连接，Close会等待这些连接关闭完成
+// closeGracefully最长等待drainTimeout，不能在持有锁或发送的路径上同步调用
+func (p *ConnectionPool) closeInBackground(conn net.Conn) {
+	p.retiring.Add(1)
+	go func() {
+		defer p.retiring.Done()
+		closeGracefully(conn)
+	}()
+}
+
+// used 累加连接写入的消息数，达到上限时返回true并停止跟踪该连接
+func (p *ConnectionPool) used(conn net.Conn, n int) bool {
+	p.usesMu.Lock()
+	defer p.usesMu.Unlock()
+	if p.maxUses <= 0 || conn == nil {
+		return false
+	}
+	p.uses[conn] += n
+	if p.uses[conn] < p.maxUses {
+		return false
+	}
+	delete(p.uses, conn)
+	return true
+}
+
+// forget 停止跟踪不再使用的连接
+func (p *ConnectionPool) forget(conn net.Conn) {
+	p.usesMu.Lock()
+	defer p.usesMu.Unlock()
+	delete(p.uses, conn)
+}
+
+// Churn 返回成功建立的连接数和因达到写入上限而关闭的连接数
+func (p *ConnectionPool) Churn() (dials, retired int64) {
+	return atomic.LoadInt64(&p.dials), atomic.LoadInt64(&p.retired)
+}
+
 // Put 将连接放回连接池
+// 无效的连接和连接池已满时多出的连接在后台关闭，对端响应慢时不会阻塞Close和其他归还的连接
 func (p *ConnectionPool) Put(conn net.Conn) {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
 
+	if conn == nil {
+		return
+	}
 	if p.closed || !p.isConnectionValid(conn) {
-		conn.Close()
+		p.forget(conn)
+		p.closeInBackground(conn)
 		return
 	}
 
@@ -182,8 +424,32 @@ func (p *ConnectionPool) Put(conn net.Conn) {
 		// 成功放回连接池
 	default:
 		// 连接池已满，关闭连接
-		conn.Close()
+		p.forget(conn)
+		p.closeInBackground(conn)
+	}
+}
+
+// drainTimeout 关闭TCP连接时等待对端读完数据并关闭连接的最长时间
+const drainTimeout = 2 * time.Second
+
+// closeGracefully 关闭连接，TCP（包括TLS）连接先排空再关闭
+// 对端发送过应答（如 server --ack）而本端没有读取时，直接Close会发送RST，
+// 对端尚未读取的消息随之被丢弃。先CloseWrite发送FIN，读尽对端的数据直到EOF（或超时）再关闭，
+// 保证已写出的消息都能被对端读到
+// TLS连接的CloseWrite先发送close_notify再半关闭底层连接
+func closeGracefully(conn net.Conn) error {
+	var cw interface{ CloseWrite() error }
+	switch c := conn.(type) {
+	case *net.TCPConn:
+		cw = c
+	case *tls.Conn:
+		cw = c
+	}
+	if cw != nil && cw.CloseWrite() == nil {
+		conn.SetReadDeadline(time.Now().Add(drainTimeout))
+		io.Copy(io.Discard, conn)
 	}
+	return conn.Close()
 }
 
 // isConnectionValid 检查连接是否有效
@@ -192,12 +458,13 @@ func (p *ConnectionPool) isConnectionValid(conn net.Conn) bool {
 		return false
 	}
 
-	// 对于UDP连接，总是认为有效
-	if p.protocol == "udp" {
+	// 只探测TCP连接，UDP、unix套接字和自定义传输的连接总是认为有效
+	if p.protocol != "tcp" {
 		return true
 	}
 
 	// 对于TCP连接，尝试设置读取超时来检查连接状态
+	// TLS连接的读取超时不会破坏连接状态，同样适用；对端的TLS会话票据等握手后消息在此被处理
 	conn.SetReadDeadline(time.Now().Add(1 * time.Millisecond))
 	buf := make([]byte, 1)
 	_, err := conn.Read(buf)
@@ -224,10 +491,17 @@ func (p *ConnectionPool) Close() {
 	p.closed = true
 	close(p.connections)
 
-	// 关闭所有连接
+	// 并行排空并关闭所有连接，避免逐个等待对端
+	var wg sync.WaitGroup
 	for conn := range p.connections {
-		conn.Close()
+		wg.Add(1)
+		go func(conn net.Conn) {
+			defer wg.Done()
+			closeGracefully(conn)
+		}(conn)
 	}
+	wg.Wait()
+	p.retiring.Wait()
 }
 
 // Size 返回连接池当前大小
@@ -237,6 +511,32 @@ func (p *ConnectionPool) Size() int {
 	return len(p.connections)
 }
 
+// BatchWriter 支持批量写入的连接
+// 连接实现该接口时（如Linux下的原始套接字），发送端可以在一次系统调用中发出多条消息
+type BatchWriter interface {
+	// WriteBatch 发送多条消息，返回成功发送的条数
+	WriteBatch(msgs [][]byte) (int, error)
+}
+
+// writeBatch 批量写入消息
+// 连接实现了BatchWriter时使用批量写入，否则逐条调用Write
+func writeBatch(conn net.Conn, msgs [][]byte) (int, error) {
+	if bw, ok := conn.(BatchWriter); ok {
+		return bw.WriteBatch(msgs)
+	}
+	return writeEach(conn, msgs)
+}
+
+// writeEach 逐条写入消息，返回成功写入的条数
+func writeEach(conn net.Conn, msgs [][]byte) (int, error) {
+	for i, msg := range msgs {
+		if _, err := conn.Write(msg); err != nil {
+			return i, err
+		}
+	}
+	return len(msgs), nil
+}
+
 // isTemporaryError 检查错误是否为临时性错误
 func isTemporaryError(err error) bool {
 	if err == nil {
@@ -249,8 +549,38 @@ func isTemporaryError(err error) bool {
 	return false
 }
 
+// isLocalTarget 判断目标地址是否指向本机（回环地址、本机网卡地址或解析到它们的主机名）
+// 主机名解析失败时按非本机处理，由后续拨号报告错误
+func isLocalTarget(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = strings.Trim(address, "[]")
+	}
+	if host == "" {
+		return true
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = net.LookupIP(host); err != nil {
+			return false
+		}
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsUnspecified() || isLocalIP(ip.String()) {
+			return true
+		}
+	}
+	return false
+}
+
 // isLocalIP 检查IP地址是否为本机IP
 func isLocalIP(ip string) bool {
+	// 按地址比较而不是按字符串比较，IPv6的不同写法（如 0:0:0:0:0:0:0:1 与 ::1）视为同一地址，比较时忽略区域
+	parsed := parseIPZone(ip)
+	if parsed == nil {
+		return false
+	}
+
 	// 获取所有网络接口
 	interfaces, err := net.Interfaces()
 	if err != nil {
@@ -285,8 +615,8 @@ func isLocalIP(ip string) bool {
 
 			// 如果是有效的IP地址
 			if ok {
-				// 将IP地址转换为字符串并比较
-				if ipNet.IP.String() == ip {
+				// 比较IP地址
+				if ipNet.IP.Equal(parsed) {
 					return true
 				}
 			}
@@ -294,7 +624,7 @@ func isLocalIP(ip string) bool {
 	}
 
 	// 特殊处理本地回环地址
-	if ip == "127.0.0.1" || ip == "::1" {
+	if parsed.Equal(net.IPv4(127, 0, 0, 1)) || parsed.Equal(net.IPv6loopback) {
 		return true
 	}
 
@@ -303,8 +633,19 @@ func isLocalIP(ip string) bool {
 
 // RateLimiter 速率限制器
 //
-// 这个速率限制器不使用传统的“令牌桶”算法（即一个单独的goroutine持续生成令牌），
-// 而是采用了一种基于“下次允许通行时间”的计算方法。
+// 这个速率限制器默认不使用传统的“令牌桶”算法（即一个单独的goroutine持续生成令牌），
+// 而是采用了一种基于“下次允许通行时间”的计算方法，发送暂停后不会补发错过的消息。
+// NewTokenBucketLimiter创建的令牌桶模式则按经过的时间累积令牌，暂停后最多补发burst条。
+//
+// 每条消息的间隔短于batchTick（EPS超过1000）时，逐条Sleep受定时器精度限制无法达到目标速率，
+// 改为批量模式：按经过的时间累积令牌，每次Sleep覆盖至少一个tick，醒来后放行这段时间内的所有消息。
+//
+// 所有工作协程共享同一个限制器，而不是把EPS平均分给每个协程：
+// 每次Wait在锁内预订一个发送时间点（或一个令牌），预订只会向后推进，
+// 因此任意时长T内放行的消息数不超过 T*rate 加上一个固定的突发量（匀速模式为1条，批量模式为一个tick加burst），
+// 与工作协程数和它们的调度顺序无关。按协程分配速率在工作协程数变化（自动扩容）时需要重新分配，
+// 某个协程被写入阻塞时它的份额也会丢失，共享的限制器没有这些问题。
+// 锁内只做时间计算，Sleep在解锁后进行，单核每秒可以放行数百万次，不会成为瓶颈。
 type RateLimiter struct {
 	rate     int64         // 每秒允许的请求数 (EPS)
 	interval time.Duration // 根据rate计算出的、两次请求之间必须经过的最小时间间隔。
@@ -312,9 +653,26 @@ type RateLimiter struct {
 	lastTime time.Time // 记录“理论上”上次请求应该发生的时间点。
 	// 这不是上次请求的实际发生时间，而是基于interval累加的、理想的、平滑的时间点。
 
-	mutex sync.Mutex // 互斥锁，用于保护lastTime的并发读写，确保线程安全。
+	// 批量模式
+	batched  bool      // 是否按令牌批量放行，间隔短于batchTick或令牌桶模式时启用
+	bucket   bool      // 令牌桶模式，任意速率下都按令牌放行，暂停期间积累的令牌可以补发
+	perNano  float64   // 每纳秒产生的令牌数，速率超过每秒10亿条（间隔不足1纳秒）时同样精确
+	burst    int       // 空闲时最多积累的令牌数，为0时为defaultBurstTicks个tick产生的令牌数
+	tokens   float64   // 可用的令牌数，为负时表示已预订、尚未到时间的发送
+	lastFill time.Time // 上次补充令牌的时间
+
+	mutex sync.Mutex // 互斥锁，用于保护lastTime和令牌的并发读写，确保线程安全。
 }
 
+// batchTick 批量模式的时间粒度
+// 间隔短于该值时启用批量模式；批量模式下预订的发送超前不到一个tick时不Sleep，
+// 因此每次Sleep至少一个tick，醒来后放行约 rate*batchTick 条消息
+const batchTick = time.Millisecond
+
+// defaultBurstTicks 未设置突发量时令牌最多积累的tick数
+// Sleep实际醒来的时间常比预订的晚，多积累几个tick的令牌才不会在醒来时丢掉超出的部分
+const defaultBurstTicks = 4
+
 // NewRateLimiter 创建新的速率限制器
 func NewRateLimiter(ratePerSecond int) *RateLimiter {
 	// 如果速率小于或等于0，则不进行速率限制。
@@ -323,21 +681,90 @@ func NewRateLimiter(ratePerSecond int) *RateLimiter {
 		return nil
 	}
 
-	// 计算时间间隔。
-	interval := time.Second / time.Duration(ratePerSecond)
+	rl := &RateLimiter{lastTime: time.Now()}
+	rl.setRate(ratePerSecond)
+	return rl
+}
 
-	// 如果计算出的间隔为0，意味着速率过高（大于1,000,000,000 EPS），
-	// 这会在 Wait() 方法中导致除以零的错误。
-	// 在这种情况下，我们返回 nil，相当于不进行速率限制，因为这实际上就是“无限速率”的意图。
-	if interval == 0 {
+// newIntervalLimiter 创建按固定间隔放行的速率限制器
+// 用于不是整数EPS的速率（如按总量发送时每秒277.78条或每分钟一条），rate为显示和速率对比使用的近似EPS
+func newIntervalLimiter(interval time.Duration, rate int) *RateLimiter {
+	rl := &RateLimiter{
+		rate:     int64(rate),
+		interval: interval,
+		lastTime: time.Now(),
+	}
+	rl.setBatched(interval < batchTick, 1/float64(interval))
+	return rl
+}
+
+// setRate 按EPS设置间隔并选择放行方式，调用方持有锁或独占rl
+// 速率超过每秒10亿条时间隔为0，只能使用批量模式
+func (rl *RateLimiter) setRate(ratePerSecond int) {
+	rl.rate = int64(ratePerSecond)
+	rl.interval = time.Second / time.Duration(ratePerSecond)
+	rl.setBatched(rl.bucket || rl.interval < batchTick, float64(ratePerSecond)/float64(time.Second))
+}
+
+// NewTokenBucketLimiter 创建令牌桶模式的速率限制器
+// 令牌按rate匀速产生，最多积累burst个，Allow和Wait各消耗一个令牌。
+// 与NewRateLimiter的固定间隔不同，调用方暂停（如模板生成偶尔变慢）期间积累的令牌可以立即使用，
+// 补发最多burst条消息，长期平均速率仍为rate。令牌桶初始为空，启动时不会突发
+// 参数：
+//   - rate: 每秒产生的令牌数，小于等于0时不限速，返回nil
+//   - burst: 最多积累的令牌数，小于1时按1处理
+//
+// 返回值：
+//   - *RateLimiter: 令牌桶模式的限制器
+func NewTokenBucketLimiter(rate, burst int) *RateLimiter {
+	if rate <= 0 {
 		return nil
 	}
+	if burst < 1 {
+		burst = 1
+	}
 
-	return &RateLimiter{
-		rate:     int64(ratePerSecond),
-		interval: interval,
-		lastTime: time.Now(),
+	rl := &RateLimiter{lastTime: time.Now(), bucket: true, burst: burst}
+	rl.setRate(rate)
+	return rl
+}
+
+// setBatched 切换放行方式，进入批量模式时从当前时间开始累积令牌
+func (rl *RateLimiter) setBatched(batched bool, perNano float64) {
+	if batched && !rl.batched {
+		rl.tokens, rl.lastFill = 0, time.Now()
+	}
+	if !batched && rl.batched {
+		rl.lastTime = time.Now()
+	}
+	rl.batched, rl.perNano = batched, perNano
+}
+
+// SetBurst 设置批量模式下空闲时最多积累的令牌数，即发送暂停后一次最多放行的消息数
+// 为0时为defaultBurstTicks个tick（4ms）产生的令牌数；只对批量模式（EPS超过1000）和令牌桶模式生效，
+// 低速率的固定间隔模式下仍严格按间隔发送。令牌桶模式下小于1时按1处理
+func (rl *RateLimiter) SetBurst(burst int) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if rl.bucket && burst < 1 {
+		burst = 1
+	}
+	rl.burst = burst
+}
+
+// tickTokens 一个tick产生的令牌数
+func (rl *RateLimiter) tickTokens() float64 {
+	return rl.perNano * float64(batchTick)
+}
+
+// refill 按经过的时间补充令牌，不超过burst（未设置时为defaultBurstTicks个tick的量），调用方持有锁
+func (rl *RateLimiter) refill(now time.Time) {
+	capacity := float64(rl.burst)
+	if rl.burst <= 0 {
+		capacity = math.Max(1, defaultBurstTicks*rl.tickTokens())
 	}
+	rl.tokens = math.Min(capacity, rl.tokens+float64(now.Sub(rl.lastFill))*rl.perNano)
+	rl.lastFill = now
 }
 
 // Allow 检查是否允许请求
@@ -346,6 +773,15 @@ func (rl *RateLimiter) Allow() bool {
 	defer rl.mutex.Unlock()
 
 	now := time.Now()
+	if rl.batched {
+		rl.refill(now)
+		if rl.tokens >= 1 {
+			rl.tokens--
+			return true
+		}
+		return false
+	}
+
 	elapsed := now.Sub(rl.lastTime)
 	if elapsed >= rl.interval {
 		// 如果距离上次发送时间超过了多个间隔，调整lastTime以保持期望速率
@@ -363,11 +799,18 @@ func (rl *RateLimiter) Allow() bool {
 // 1. 计算当前时间与“理论上次发送时间”（lastTime）的差距。
 // 2. 如果差距已经超过了预设的最小间隔（interval），说明可以立即发送，然后更新“理论下次发送时间”。
 // 3. 如果差距小于最小间隔，说明发送过快，需要计算还需等待多久，然后Sleep等待。
+//
+// 批量模式下见waitBatched。
 func (rl *RateLimiter) Wait() {
 	// 加锁，确保同一时间只有一个goroutine能修改lastTime。
 	// 这防止了多个协程同时计算等待时间，导致速率失控。
 	rl.mutex.Lock()
 
+	if rl.batched {
+		rl.waitBatched()
+		return
+	}
+
 	// 获取当前时间
 	now := time.Now()
 
@@ -416,21 +859,77 @@ func (rl *RateLimiter) Wait() {
 	time.Sleep(waitDuration)
 }
 
-// RateLimiterV2 使用令牌桶算法的速率限制器
-type RateLimiterV2 struct {
-	rate     int64         // 每秒允许的请求数
-	interval time.Duration // 请求间隔
-	lastTime time.Time     // 上次请求时间
-	mutex    sync.Mutex    // 互斥锁
+// waitBatched 批量模式下预订一个令牌，调用方持有锁，返回前释放
+// 令牌不足时预订未来的令牌（令牌数为负），预订超前不到一个tick时立即放行，
+// 超过时Sleep到预订的令牌产生为止。这样每个tick只Sleep一次，长期平均速率由累计的令牌保证，
+// 与Sleep的实际时长和工作协程数无关
+func (rl *RateLimiter) waitBatched() {
+	rl.refill(time.Now())
+	rl.tokens--
+	if rl.tokens >= -rl.tickTokens() {
+		rl.mutex.Unlock()
+		return
+	}
+	wait := time.Duration(-rl.tokens / rl.perNano)
+	rl.mutex.Unlock()
+	time.Sleep(wait)
+}
+
+// InterArrivalLimiter 按随机间隔分布控制发送节奏的限制器
+// 与RateLimiter的匀速发送不同，相邻两条消息的间隔从指定分布中随机抽取，
+// 所有工作协程共享同一个发送时间表，整体上形成一个（近似）泊松或均匀到达过程
+type InterArrivalLimiter struct {
+	sample func() time.Duration // 抽取下一个间隔
+	next   time.Time            // 下一条消息的计划发送时间
+	mutex  sync.Mutex           // 保护next的并发读写
+}
+
+// NewInterArrivalLimiter 根据间隔分布配置创建限制器
+// 参数：
+//   - spec: 间隔分布配置（指数分布或均匀分布）
+//
+// 返回值：
+//   - *InterArrivalLimiter: 新创建的限制器
+func NewInterArrivalLimiter(spec *config.InterArrivalSpec) *InterArrivalLimiter {
+	var sample func() time.Duration
+	switch spec.Dist {
+	case config.DistExponential:
+		mean := float64(spec.Mean)
+		sample = func() time.Duration { return time.Duration(rand.ExpFloat64() * mean) }
+	default:
+		span := int64(spec.Max - spec.Min)
+		sample = func() time.Duration { return spec.Min + time.Duration(rand.Int63n(span+1)) }
+	}
+	return &InterArrivalLimiter{sample: sample, next: time.Now()}
+}
+
+// Wait 等待直到计划的发送时间
+// 每次调用预定当前的发送时间点，并抽取一个随机间隔作为下一条消息的时间点；
+// 发送落后于计划时从当前时间重新开始，避免积压后的补偿性爆发
+func (l *InterArrivalLimiter) Wait() {
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	fireAt := l.next
+	l.next = l.next.Add(l.sample())
+	l.mutex.Unlock()
+
+	time.Sleep(fireAt.Sub(now))
 }
 
 // SetRate 设置新的速率
+// 按令牌放行时先按原速率补充到当前时间再切换，已积累的令牌保留，
+// 令牌桶模式下容量不变，调节速率不会丢弃或凭空增加令牌
 func (rl *RateLimiter) SetRate(ratePerSecond int) {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
-	rl.rate = int64(ratePerSecond)
-	rl.interval = time.Second / time.Duration(ratePerSecond)
+	if rl.batched {
+		rl.refill(time.Now())
+	}
+	rl.setRate(ratePerSecond)
 }
 
 // GetRate 获取当前速率
@@ -440,27 +939,6 @@ func (rl *RateLimiter) GetRate() int64 {
 	return rl.rate
 }
 
-// 辅助：记录连接所用网卡/本地地址
-func (p *ConnectionPool) logInterfaceForConn(conn net.Conn) {
-	if !p.verbose || conn == nil {
-		return
-	}
-	la := conn.LocalAddr()
-	var ip net.IP
-	switch a := la.(type) {
-	case *net.TCPAddr:
-		ip = a.IP
-	case *net.UDPAddr:
-		ip = a.IP
-	}
-	name := lookupInterfaceNameByIP(ip)
-	if name != "" {
-		fmt.Printf("已建立连接 使用网卡: %s 本地地址: %s -> 目标: %s 协议: %s\n", name, la.String(), p.address, p.protocol)
-	} else {
-		fmt.Printf("已建立连接 本地地址: %s -> 目标: %s 协议: %s\n", la.String(), p.address, p.protocol)
-	}
-}
-
 // 根据本地IP查找网卡名称（跨平台尽力而为）
 func lookupInterfaceNameByIP(ip net.IP) string {
 	if ip == nil || ip.IsUnspecified() {
@@ -0,0 +1,216 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTokenBucketLimiterStartsEmpty 令牌桶初始为空，启动时不突发
+func TestTokenBucketLimiterStartsEmpty(t *testing.T) {
+	rl := NewTokenBucketLimiter(10, 5)
+	if rl.Allow() {
+		t.Fatal("新建的令牌桶不应立即放行")
+	}
+}
+
+// TestTokenBucketLimiterAbsorbsBurst 暂停期间积累的令牌可以立即使用，最多burst个
+func TestTokenBucketLimiterAbsorbsBurst(t *testing.T) {
+	const burst = 20
+	rl := NewTokenBucketLimiter(1000, burst)
+
+	// 暂停100ms产生约100个令牌，只保留burst个
+	time.Sleep(100 * time.Millisecond)
+
+	allowed := 0
+	for rl.Allow() {
+		allowed++
+		if allowed > 10*burst {
+			break
+		}
+	}
+	// 连续调用Allow期间可能再产生一个令牌
+	if allowed < burst || allowed > burst+1 {
+		t.Fatalf("暂停后放行 %d 条，期望 %d 条", allowed, burst)
+	}
+}
+
+// blockingTransport 第一次拨号返回内存连接，之后的拨号阻塞到ctx取消
+type blockingTransport struct {
+	dials int32
+}
+
+func (t *blockingTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	if atomic.AddInt32(&t.dials, 1) == 1 {
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// TestConnectionPoolGetUsesPoolContext 连接池为空时Get重新拨号，取消连接池的ctx后拨号立即返回
+func TestConnectionPoolGetUsesPoolContext(t *testing.T) {
+	if err := RegisterTransport("test-blocking", func(string, TransportOptions) (Transport, error) {
+		return &blockingTransport{}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool, err := NewConnectionPool(ctx, "blocking", "test-blocking", 1, time.Minute, "", 0, false, false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("创建连接池失败: %v", err)
+	}
+
+	conn, err := pool.Get()
+	if err != nil {
+		t.Fatalf("获取预创建的连接失败: %v", err)
+	}
+	pool.Discard(conn)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := pool.Get()
+		result <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("取消后Get返回 %v，期望 %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		// 阻塞的Get持有读锁，此时不能关闭连接池
+		t.Fatal("取消连接池的ctx后Get仍阻塞在拨号上")
+	}
+	pool.Close()
+}
+
+// drainTokens 连续调用Allow直到不再放行，返回放行的次数
+func drainTokens(rl *RateLimiter, limit int) int {
+	allowed := 0
+	for allowed < limit && rl.Allow() {
+		allowed++
+	}
+	return allowed
+}
+
+// TestTokenBucketSetRateKeepsTokens 运行中调整速率时已积累的令牌保留，既不丢弃也不重复计算，容量不变
+func TestTokenBucketSetRateKeepsTokens(t *testing.T) {
+	const burst, used = 20, 8
+	tests := []struct {
+		name     string
+		from, to int
+	}{
+		{"increase", 1000, 4000},
+		{"decrease", 4000, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewTokenBucketLimiter(tt.from, burst)
+			// 暂停50ms积满令牌桶，先用掉一部分
+			time.Sleep(50 * time.Millisecond)
+			if got := drainTokens(rl, used); got != used {
+				t.Fatalf("积满后只放行 %d 条，期望 %d 条", got, used)
+			}
+
+			rl.SetRate(tt.to)
+			if got := rl.GetRate(); got != int64(tt.to) {
+				t.Fatalf("速率为 %d，期望 %d", got, tt.to)
+			}
+			// 剩余的令牌在调整速率后仍可使用；连续调用Allow期间可能再产生一个令牌
+			if got := drainTokens(rl, 10*burst); got < burst-used || got > burst-used+1 {
+				t.Fatalf("调整速率后放行 %d 条，期望 %d 条", got, burst-used)
+			}
+
+			// 按新速率重新积累，最多仍为burst个
+			time.Sleep(50 * time.Millisecond)
+			if got := drainTokens(rl, 10*burst); got < burst || got > burst+1 {
+				t.Fatalf("调整速率后暂停放行 %d 条，期望 %d 条", got, burst)
+			}
+		})
+	}
+}
+
+// TestRateLimiterSteadyRate 固定间隔、批量和令牌桶模式的长期平均速率都为设置的EPS
+func TestRateLimiterSteadyRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    int
+		newFunc func(rate int) *RateLimiter
+	}{
+		{"interval", 200, NewRateLimiter},
+		{"batched", 50000, NewRateLimiter},
+		{"token-bucket", 2000, func(rate int) *RateLimiter { return NewTokenBucketLimiter(rate, rate) }},
+		{"token-bucket batched", 50000, func(rate int) *RateLimiter { return NewTokenBucketLimiter(rate, 100) }},
+	}
+	const window = 500 * time.Millisecond
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 在子测试中创建，令牌桶不会在前面的子测试运行期间积累令牌
+			rl := tt.newFunc(tt.rate)
+			n := int(float64(tt.rate) * window.Seconds())
+			start := time.Now()
+			for i := 0; i < n; i++ {
+				rl.Wait()
+			}
+			elapsed := time.Since(start)
+
+			// 限速器从空开始，n条消息至少需要 n/rate 的时间，Sleep醒来偏晚时稍长
+			if elapsed < window*9/10 || elapsed > window*13/10 {
+				t.Fatalf("%d EPS 放行 %d 条用时 %v，期望约 %v", tt.rate, n, elapsed, window)
+			}
+		})
+	}
+}
+
+// benchmarkRateLimiter 以rate EPS调用Wait，报告实际放行速率（eps）
+// 批量模式下每次Wait的开销应远小于1µs，实际速率接近rate
+func benchmarkRateLimiter(b *testing.B, rl *RateLimiter, parallel bool) {
+	b.ResetTimer()
+	start := time.Now()
+	if parallel {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				rl.Wait()
+			}
+		})
+	} else {
+		for i := 0; i < b.N; i++ {
+			rl.Wait()
+		}
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "eps")
+}
+
+// BenchmarkRateLimiter1M 单个协程以每秒100万条调用批量模式的限制器
+func BenchmarkRateLimiter1M(b *testing.B) {
+	benchmarkRateLimiter(b, NewRateLimiter(1000000), false)
+}
+
+// BenchmarkRateLimiter1MParallel 多个协程共享每秒100万条的限制器
+func BenchmarkRateLimiter1MParallel(b *testing.B) {
+	benchmarkRateLimiter(b, NewRateLimiter(1000000), true)
+}
+
+// BenchmarkRateLimiter1MBurst 设置了突发量的批量模式
+func BenchmarkRateLimiter1MBurst(b *testing.B) {
+	rl := NewRateLimiter(1000000)
+	rl.SetBurst(10000)
+	benchmarkRateLimiter(b, rl, false)
+}
+
+// BenchmarkRateLimiter1MTokenBucket 令牌桶模式以每秒100万条放行
+func BenchmarkRateLimiter1MTokenBucket(b *testing.B) {
+	benchmarkRateLimiter(b, NewTokenBucketLimiter(1000000, 10000), false)
+}
@@ -0,0 +1,161 @@
+package sender
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+
+	"syslog_go/pkg/config"
+	"syslog_go/pkg/template"
+)
+
+// dataSource 一个数据文件的读取状态
+type dataSource struct {
+	path    string
+	weight  int
+	file    *os.File
+	scanner *bufio.Scanner
+}
+
+// nextLine 读取下一行，跳过 mock --annotate 写入的说明行
+// 文件在第一次读取时打开；读到文件末尾时返回false并回到文件开头，下次调用从第一行重新读取
+func (d *dataSource) nextLine() (string, bool, error) {
+	if d.file == nil {
+		file, err := os.Open(d.path)
+		if err != nil {
+			return "", false, fmt.Errorf("打开数据文件失败: %w", err)
+		}
+		d.file = file
+		d.scanner = bufio.NewScanner(file)
+	}
+
+	for d.scanner.Scan() {
+		if line := d.scanner.Text(); !template.IsAnnotation(line) {
+			return line, true, nil
+		}
+	}
+	if err := d.scanner.Err(); err != nil {
+		return "", false, fmt.Errorf("读取数据文件 %s 失败: %w", d.path, err)
+	}
+	// 重置文件指针到开头
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return "", false, fmt.Errorf("重置文件指针失败: %w", err)
+	}
+	d.scanner = bufio.NewScanner(d.file)
+	return "", false, nil
+}
+
+// dataReader 从一个或多个数据文件循环读取消息内容，可被多个发送协程并发调用
+// 读取方式：
+//   - 所有文件都未指定权重时按顺序读取，读完一个文件后切换到下一个，读完最后一个后回到第一个
+//   - 任一文件指定了权重时，每条消息按权重随机选择文件（未指定权重的文件按1计算），
+//     每个文件各自循环读取
+type dataReader struct {
+	sources []*dataSource
+	current int // 顺序读取时当前读取的文件
+	total   int // 按权重读取时的权重总和，为0表示顺序读取
+	mutex   sync.Mutex
+}
+
+// newDataReader 根据展开后的数据文件列表创建读取器，文件在第一次读取时才打开
+func newDataReader(files []config.DataFileSource) *dataReader {
+	r := &dataReader{}
+	weighted := false
+	for _, f := range files {
+		if f.Weight > 0 {
+			weighted = true
+		}
+	}
+	for _, f := range files {
+		weight := f.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		r.sources = append(r.sources, &dataSource{path: f.Path, weight: weight})
+		if weighted {
+			r.total += weight
+		}
+	}
+	return r
+}
+
+// next 返回下一行数据
+func (r *dataReader) next() (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.total > 0 {
+		source := r.pick()
+		// 读到文件末尾时回到开头再读一次，仍然没有数据说明文件为空
+		for i := 0; i < 2; i++ {
+			line, ok, err := source.nextLine()
+			if err != nil || ok {
+				return line, err
+			}
+		}
+		return "", fmt.Errorf("数据文件为空: %s", source.path)
+	}
+
+	// 顺序读取：当前文件读完后切换到下一个文件，
+	// 尝试次数多一次以便只有一个文件时回到开头重新读取
+	for i := 0; i <= len(r.sources); i++ {
+		line, ok, err := r.sources[r.current].nextLine()
+		if err != nil || ok {
+			return line, err
+		}
+		r.current = (r.current + 1) % len(r.sources)
+	}
+	return "", fmt.Errorf("数据文件为空")
+}
+
+// lineCount 返回所有数据文件的总行数
+func (r *dataReader) lineCount() (int, error) {
+	count := 0
+	for _, source := range r.sources {
+		file, err := os.Open(source.path)
+		if err != nil {
+			return 0, fmt.Errorf("打开数据文件失败: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if !template.IsAnnotation(scanner.Text()) {
+				count++
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return 0, fmt.Errorf("读取数据文件 %s 失败: %w", source.path, err)
+		}
+	}
+	return count, nil
+}
+
+// pick 按权重随机选择一个文件
+func (r *dataReader) pick() *dataSource {
+	n := rand.Intn(r.total)
+	for _, source := range r.sources {
+		if n < source.weight {
+			return source
+		}
+		n -= source.weight
+	}
+	return r.sources[len(r.sources)-1]
+}
+
+// Close 关闭所有已打开的数据文件
+func (r *dataReader) Close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, source := range r.sources {
+		if source.file != nil {
+			source.file.Close()
+			source.file = nil
+			source.scanner = nil
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sender
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"syslog_go/pkg/syslog"
+	"syslog_go/pkg/template"
+)
+
+// fieldTemplate JSON格式下一个配置的附加字段
+// 值包含模板变量时加载为引擎中的模板，每条消息重新求值
+type fieldTemplate struct {
+	key      string
+	value    string // 配置的值，不含模板变量时直接输出
+	template string // 值的模板名，不含模板变量时为空
+}
+
+// initFields 解析配置的附加字段（键=值），为含模板变量的值分配模板名
+// 字段名的合法性（非空、不重复、不与标准字段重名）已由配置校验保证，模板由newTemplateEngine加载到引擎中
+func (s *Sender) initFields() {
+	for i, item := range s.config.Fields {
+		key, value, _ := strings.Cut(item, "=")
+		field := fieldTemplate{key: strings.TrimSpace(key), value: value}
+		if strings.Contains(value, "{{") {
+			field.template = "field:" + strconv.Itoa(i)
+		}
+		s.fields = append(s.fields, field)
+	}
+}
+
+// buildFields 按配置顺序生成本条消息的附加字段
+// 参数：
+//   - severity: 本条消息的Severity，用于填充值中的LEVEL变量
+func (s *Sender) buildFields(severity int) ([]syslog.Field, error) {
+	fields := make([]syslog.Field, len(s.fields))
+	for i, f := range s.fields {
+		value := f.value
+		if f.template != "" {
+			var err error
+			if value, err = s.engine().GenerateMessage(f.template); err != nil {
+				return nil, fmt.Errorf("处理附加字段 %s 失败: %w", f.key, err)
+			}
+			value = template.FillLevel(value, severity)
+		}
+		fields[i] = syslog.Field{Key: f.key, Value: value}
+	}
+	return fields, nil
+}
@@ -0,0 +1,58 @@
+package sender
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// localHostname 返回消息HOSTNAME字段使用的本机主机名
+// 启用 --fqdn 时解析完全限定域名，解析失败时回退为短主机名并输出一次告警
+func (s *Sender) localHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "localhost"
+	}
+	if !s.config.FQDN || strings.Contains(hostname, ".") {
+		return hostname
+	}
+
+	fqdn, err := lookupFQDN(hostname)
+	if err != nil {
+		s.log.Warn(fmt.Sprintf("解析本机完全限定域名失败，使用主机名 %s: %v", hostname, err),
+			"hostname", hostname, "error", err.Error())
+		return hostname
+	}
+	if s.config.Verbose {
+		s.log.Info("使用本机完全限定域名: "+fqdn, "hostname", fqdn)
+	}
+	return fqdn
+}
+
+// lookupFQDN 解析主机名对应的完全限定域名
+// 先查询CNAME（解析器通常返回带搜索域的规范名），再对主机名的地址做反向解析，取第一个带域名的结果
+func lookupFQDN(hostname string) (string, error) {
+	if cname, err := net.LookupCNAME(hostname); err == nil {
+		if name := strings.TrimSuffix(cname, "."); strings.Contains(name, ".") {
+			return name, nil
+		}
+	}
+
+	addrs, err := net.LookupHost(hostname)
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		names, err := net.LookupAddr(addr)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			if name = strings.TrimSuffix(name, "."); strings.Contains(name, ".") {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s 的地址 %s 没有带域名的反向解析结果", hostname, strings.Join(addrs, ", "))
+}
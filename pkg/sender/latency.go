@@ -0,0 +1,85 @@
+package sender
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets 延迟直方图的桶数
+// 每个2的幂区间再均分为4个子桶，相对误差不超过25%，覆盖纳秒到int64上限
+const latencyBuckets = 64 * 4
+
+// latencyHistogram 无锁的写入延迟直方图
+// 多个发送协程并发记录，只在统计时遍历所有桶
+type latencyHistogram struct {
+	buckets [latencyBuckets]int64
+	count   int64
+	max     int64
+}
+
+// latencyBucket 返回纳秒值所在的桶下标
+func latencyBucket(ns int64) int {
+	if ns < 4 {
+		if ns < 0 {
+			return 0
+		}
+		return int(ns)
+	}
+	exp := bits.Len64(uint64(ns)) - 1
+	sub := int(ns>>uint(exp-2)) & 3
+	return exp*4 + sub
+}
+
+// latencyBucketUpper 返回桶内的最大纳秒值
+func latencyBucketUpper(index int) int64 {
+	if index < 4 {
+		return int64(index)
+	}
+	exp, sub := index/4, index%4
+	return int64(4+sub+1)<<uint(exp-2) - 1
+}
+
+// record 记录一次写入耗时
+func (h *latencyHistogram) record(d time.Duration) {
+	ns := int64(d)
+	atomic.AddInt64(&h.buckets[latencyBucket(ns)], 1)
+	atomic.AddInt64(&h.count, 1)
+	for {
+		old := atomic.LoadInt64(&h.max)
+		if ns <= old || atomic.CompareAndSwapInt64(&h.max, old, ns) {
+			return
+		}
+	}
+}
+
+// percentile 返回第p分位（0-1）的近似延迟，没有记录时返回0
+// 结果为所在桶的上界，不超过记录到的最大值
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	total := atomic.LoadInt64(&h.count)
+	if total == 0 {
+		return 0
+	}
+	rank := int64(p*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+
+	max := atomic.LoadInt64(&h.max)
+	var seen int64
+	for i := range h.buckets {
+		seen += atomic.LoadInt64(&h.buckets[i])
+		if seen >= rank {
+			if upper := latencyBucketUpper(i); upper < max {
+				return time.Duration(upper)
+			}
+			break
+		}
+	}
+	return time.Duration(max)
+}
+
+// maxLatency 返回记录到的最大延迟
+func (h *latencyHistogram) maxLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&h.max))
+}
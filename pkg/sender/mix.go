@@ -0,0 +1,82 @@
+package sender
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+
+	"syslog_go/pkg/config"
+	"syslog_go/pkg/syslog"
+)
+
+// pickWeighted 按权重随机选择一个取值，分布为空时返回fixed
+func pickWeighted(values []config.WeightedValue, total, fixed int) int {
+	if total == 0 {
+		return fixed
+	}
+	n := rand.Intn(total)
+	for _, v := range values {
+		if n < v.Weight {
+			return v.Value
+		}
+		n -= v.Weight
+	}
+	return values[len(values)-1].Value
+}
+
+// pickFacility 按Facility分布随机选择本条消息的Facility
+// 未配置分布时返回配置的固定Facility
+func (s *Sender) pickFacility() int {
+	return pickWeighted(s.facilities, s.facilityTotal, s.config.Facility)
+}
+
+// recordPriority 记录生成的消息的Facility和Severity，用于最终统计中核对实际分布
+func (s *Sender) recordPriority(facility, severity int) {
+	atomic.AddInt64(&s.stats.facilityCounts[facility], 1)
+	atomic.AddInt64(&s.stats.severityCounts[severity], 1)
+}
+
+// severityCounts 返回按Severity名称统计的生成消息数，只包含出现过的Severity
+func (s *Sender) severityCounts() map[string]int64 {
+	counts := make(map[string]int64)
+	for severity := range s.stats.severityCounts {
+		if n := atomic.LoadInt64(&s.stats.severityCounts[severity]); n > 0 {
+			counts[syslog.GetSeverityName(severity)] = n
+		}
+	}
+	return counts
+}
+
+// facilityCounts 返回按Facility名称统计的生成消息数，只包含出现过的Facility
+func (s *Sender) facilityCounts() map[string]int64 {
+	counts := make(map[string]int64)
+	for facility := range s.stats.facilityCounts {
+		if n := atomic.LoadInt64(&s.stats.facilityCounts[facility]); n > 0 {
+			counts[syslog.GetFacilityName(facility)] = n
+		}
+	}
+	return counts
+}
+
+// printMix 输出配置了分布时实际生成的比例与配置比例的对照
+// 参数：
+//   - title: 标题，如 "Severity分布"
+//   - values: 配置的分布
+//   - counts: 按取值统计的生成消息数
+//   - name: 取值到名称的转换
+func (s *Sender) printMix(title string, values []config.WeightedValue, counts []int64, name func(int) string) {
+	var total int64
+	for i := range counts {
+		total += atomic.LoadInt64(&counts[i])
+	}
+	if total == 0 {
+		return
+	}
+
+	fmt.Fprintf(s.stdout, "%s (实际/配置):\n", title)
+	for _, v := range values {
+		n := atomic.LoadInt64(&counts[v.Value])
+		fmt.Fprintf(s.stdout, "  %s: %d (%.1f%% / %.1f%%)\n",
+			name(v.Value), n, float64(n)/float64(total)*100, config.MixShare(values, v.Value)*100)
+	}
+}
@@ -0,0 +1,43 @@
+package sender
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"syslog_go/pkg/config"
+	"syslog_go/pkg/syslog"
+)
+
+// procIDTemplateName 模板化的进程ID在模板引擎中的名称
+const procIDTemplateName = "procid"
+
+// staticProcID 返回固定的进程ID
+// self为发送进程自身的PID；包含模板变量时返回空，由nextProcID按消息生成
+func staticProcID(procID string) string {
+	switch {
+	case procID == config.ProcIDSelf:
+		return strconv.Itoa(os.Getpid())
+	case strings.Contains(procID, "{{"):
+		return ""
+	default:
+		return procID
+	}
+}
+
+// nextProcID 返回本条消息的进程ID
+// 模板生成的值同样需要满足PROCID的语法，否则该条消息生成失败
+func (s *Sender) nextProcID() (string, error) {
+	if !s.procIDTpl {
+		return s.procID, nil
+	}
+	pid, err := s.engine().GenerateMessage(procIDTemplateName)
+	if err != nil {
+		return "", fmt.Errorf("生成进程ID失败: %w", err)
+	}
+	if err := syslog.ValidateProcID(pid); err != nil {
+		return "", err
+	}
+	return pid, nil
+}
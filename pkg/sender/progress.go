@@ -0,0 +1,97 @@
+package sender
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval 进度行的刷新间隔
+const progressInterval = 500 * time.Millisecond
+
+// progressTotal 返回有界发送的消息总数，不限数量（按持续时间结束）时返回0
+// 按轮次重放时总数为 轮数 x 每轮消息数，按总量发送时为 --total
+func (s *Sender) progressTotal() int64 {
+	if s.replay != nil {
+		return s.replay.total
+	}
+	return s.total
+}
+
+// progressWriter 返回进度行的输出目标，不需要显示进度时返回nil
+// 只在stderr是终端时显示：重定向到文件或管道时不输出，避免日志中混入回车重绘的内容；
+// 静默、详细日志和演练模式下标准输出已有其他内容，同样不显示
+func (s *Sender) progressWriter(stderr io.Writer) io.Writer {
+	if s.config.Quiet || s.config.Verbose || s.config.DryRun || s.progressTotal() == 0 {
+		return nil
+	}
+	if !isTerminal(stderr) {
+		return nil
+	}
+	return stderr
+}
+
+// isTerminal 判断w是否为终端（字符设备）
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressMonitor 定期在同一行重绘发送进度（百分比、已发送数、速率和预计剩余时间）
+// 进度按已尝试发送的消息数（成功+失败）计算，所有工作协程退出后输出最后一次进度并换行
+func (s *Sender) progressMonitor() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			s.printProgress(true)
+			return
+		case <-s.ctx.Done():
+			s.printProgress(true)
+			return
+		case <-ticker.C:
+			s.printProgress(false)
+		}
+	}
+}
+
+// printProgress 输出一次进度行，final为true时在末尾换行
+func (s *Sender) printProgress(final bool) {
+	total := s.progressTotal()
+	done := atomic.LoadInt64(&s.stats.Sent) + atomic.LoadInt64(&s.stats.Failed)
+	if done > total {
+		done = total
+	}
+	elapsed := time.Since(s.stats.StartTime)
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "\r进度: %5.1f%% (%d/%d)", float64(done)/float64(total)*100, done, total)
+	if seconds := elapsed.Seconds(); seconds > 0 && done > 0 {
+		rate := float64(done) / seconds
+		fmt.Fprintf(&line, " 速率: %.0f/s", rate)
+		if done < total {
+			eta := time.Duration(float64(total-done) / rate * float64(time.Second))
+			fmt.Fprintf(&line, " 预计剩余: %v", eta.Round(time.Second))
+		}
+	}
+	// 清除上一次较长的进度行的残留字符
+	line.WriteString("\x1b[K")
+	if final {
+		line.WriteString("\n")
+	}
+	io.WriteString(s.progress, line.String())
+}
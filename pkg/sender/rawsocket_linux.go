@@ -6,11 +6,18 @@ package sender
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 	"syscall"
 	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"syslog_go/pkg/logging"
 )
 
 // RawSocketConn Linux版本的原始套接字连接
@@ -23,15 +30,16 @@ import (
 // 5. 调试支持：详细的日志输出功能
 type RawSocketConn struct {
 	// 套接字控制
-	fd      int  // 原始套接字文件描述符
-	closed  bool // 连接关闭状态
-	verbose bool // 是否输出详细日志
+	fd      int             // 原始套接字文件描述符
+	closed  bool            // 连接关闭状态
+	verbose bool            // 是否输出详细日志
+	log     *logging.Logger // 日志输出目标
 
 	// 网络地址
 	sourceIP   net.IP // 源IP地址
 	targetIP   net.IP // 目标IP地址
 	targetPort int    // 目标端口
-	srcPort    uint16 // 源端口（随机分配）
+	srcPort    uint16 // 源端口（未指定时随机分配）
 
 	// 协议控制
 	protocol  string // 使用的协议（tcp/udp）
@@ -40,6 +48,18 @@ type RawSocketConn struct {
 	ackNum    uint32 // TCP确认号
 }
 
+// checkRawSocketPrivilege 检查当前进程能否创建原始套接字 (Linux版本)
+// 通过试探性地创建并立即关闭一个原始套接字判断，
+// 需要root权限或CAP_NET_RAW能力（如 setcap cap_net_raw+ep）
+func checkRawSocketPrivilege() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW, syscall.IPPROTO_UDP)
+	if err != nil {
+		return fmt.Errorf("无法创建原始套接字: %w (当前EUID=%d，需要root权限或CAP_NET_RAW能力)", err, os.Geteuid())
+	}
+	syscall.Close(fd)
+	return nil
+}
+
 // newRawSocketConn 创建新的原始套接字连接 (Linux版本)
 // 功能：
 //   - 创建并配置原始套接字
@@ -49,14 +69,16 @@ type RawSocketConn struct {
 //
 // 参数：
 //   - sourceIP: 源IP地址字符串
+//   - sourcePort: 源端口，为0时随机分配
 //   - targetAddr: 目标地址字符串（格式：IP:Port）
 //   - protocol: 传输协议（tcp/udp）
 //   - verbose: 是否输出详细日志
+//   - logger: 日志输出目标
 //
 // 返回值：
 //   - *RawSocketConn: 原始套接字连接对象
 //   - error: 创建过程中的错误
-func newRawSocketConn(sourceIP, targetAddr, protocol string, verbose bool) (*RawSocketConn, error) {
+func newRawSocketConn(sourceIP string, sourcePort int, targetAddr, protocol string, verbose bool, logger *logging.Logger) (*RawSocketConn, error) {
 	// 解析源IP地址
 	srcIP := net.ParseIP(sourceIP)
 	if srcIP == nil {
@@ -127,11 +149,13 @@ func newRawSocketConn(sourceIP, targetAddr, protocol string, verbose bool) (*Raw
 	return &RawSocketConn{
 		fd:         fd,
 		sourceIP:   srcIP,
+		srcPort:    uint16(sourcePort),
 		targetIP:   targetIP,
 		targetPort: targetPort,
 		protocol:   protocol,
 		closed:     false,
 		verbose:    verbose,
+		log:        logger,
 	}, nil
 }
 
@@ -150,18 +174,20 @@ func (c *RawSocketConn) establishTCPConnection() error {
 		return nil
 	}
 
-	// 设置源端口和初始序列号
-	c.srcPort = uint16(time.Now().UnixNano()&0xFFFF) + 32768
+	// 设置源端口（未指定时随机）和初始序列号
+	if c.srcPort == 0 {
+		c.srcPort = uint16(time.Now().UnixNano()&0xFFFF) + 32768
+	}
 	c.seqNum = uint32(time.Now().UnixNano() & 0xFFFFFFFF)
 
-	fmt.Printf("开始TCP连接建立 [%s:%d -> %s:%d]\n", c.sourceIP, c.srcPort, c.targetIP, c.targetPort)
+	c.log.Infof("开始TCP连接建立 [%s:%d -> %s:%d]", c.sourceIP, c.srcPort, c.targetIP, c.targetPort)
 
 	// 1. 发送SYN包
 	if err := c.sendTCPPacket(0x0002, nil); err != nil { // SYN标志
 		return fmt.Errorf("发送SYN包失败: %w", err)
 	}
 	if c.verbose {
-		fmt.Printf("已发送SYN包，序列号: %d\n", c.seqNum)
+		c.log.Infof("已发送SYN包，序列号: %d", c.seqNum)
 	}
 
 	// 2. 等待接收SYN+ACK包
@@ -169,7 +195,7 @@ func (c *RawSocketConn) establishTCPConnection() error {
 	maxRetries := 5 // 增加重试次数到5次
 	for i := 0; i < maxRetries; i++ {
 		if c.verbose {
-			fmt.Printf("等待接收SYN+ACK包，尝试次数: %d\n", i+1)
+			c.log.Infof("等待接收SYN+ACK包，尝试次数: %d", i+1)
 		}
 
 		// 设置读取超时为5秒
@@ -178,14 +204,14 @@ func (c *RawSocketConn) establishTCPConnection() error {
 			return fmt.Errorf("设置读取超时失败: %w", err)
 		}
 		if c.verbose {
-			fmt.Printf("设置数据包接收超时为%d秒\n", tv.Sec)
+			c.log.Infof("设置数据包接收超时为%d秒", tv.Sec)
 		}
 
 		n, _, err := syscall.Recvfrom(c.fd, buf, 0)
 		if err != nil {
 			if strings.Contains(err.Error(), "timeout") {
 				if c.verbose {
-					fmt.Printf("等待超时，将重试\n")
+					c.log.Infof("等待超时，将重试")
 				}
 				continue
 			}
@@ -193,13 +219,13 @@ func (c *RawSocketConn) establishTCPConnection() error {
 		}
 
 		if c.verbose {
-			fmt.Printf("收到数据包，长度: %d 字节\n", n)
+			c.log.Infof("收到数据包，长度: %d 字节", n)
 		}
 
 		// 解析接收到的包
 		if n < 40 { // IP头部(20) + TCP头部(20)
 			if c.verbose {
-				fmt.Printf("数据包长度不足，至少需要40字节，实际长度: %d字节\n", n)
+				c.log.Infof("数据包长度不足，至少需要40字节，实际长度: %d字节", n)
 			}
 			continue
 		}
@@ -208,7 +234,7 @@ func (c *RawSocketConn) establishTCPConnection() error {
 		ipVersion := buf[0] >> 4
 		if ipVersion != 4 {
 			if c.verbose {
-				fmt.Printf("非IPv4数据包，版本: %d\n", ipVersion)
+				c.log.Infof("非IPv4数据包，版本: %d", ipVersion)
 			}
 			continue
 		}
@@ -217,12 +243,12 @@ func (c *RawSocketConn) establishTCPConnection() error {
 		ipProtocol := buf[9]
 		if ipProtocol != syscall.IPPROTO_TCP {
 			if c.verbose {
-				fmt.Printf("非TCP协议，协议号: %d（TCP协议号应为: %d）\n", ipProtocol, syscall.IPPROTO_TCP)
+				c.log.Infof("非TCP协议，协议号: %d（TCP协议号应为: %d）", ipProtocol, syscall.IPPROTO_TCP)
 			}
 			continue
 		}
 		if c.verbose {
-			fmt.Printf("收到TCP协议数据包\n")
+			c.log.Infof("收到TCP协议数据包")
 		}
 
 		// 检查源IP和目标IP是否匹配
@@ -231,71 +257,71 @@ func (c *RawSocketConn) establishTCPConnection() error {
 		srcIP := net.IP(buf[12:16])
 		dstIP := net.IP(buf[16:20])
 		if c.verbose {
-			fmt.Printf("收到的数据包IP信息:\n")
-			fmt.Printf("  源IP: %v，目标IP: %v\n", srcIP, dstIP)
-			fmt.Printf("  本地配置 - 源IP: %v，目标IP: %v\n", c.sourceIP, c.targetIP)
+			c.log.Infof("收到的数据包IP信息:")
+			c.log.Infof("  源IP: %v，目标IP: %v", srcIP, dstIP)
+			c.log.Infof("  本地配置 - 源IP: %v，目标IP: %v", c.sourceIP, c.targetIP)
 		}
 
 		// 检查数据包是否与当前连接相关
 		// 至少目标IP应该是我们发送SYN包时使用的源IP
 		if !bytes.Equal(dstIP, c.sourceIP.To4()) {
 			if c.verbose {
-				fmt.Printf("忽略与当前连接无关的数据包\n")
+				c.log.Infof("忽略与当前连接无关的数据包")
 			}
 			continue
 		}
 
 		// 检查TCP头部和标志位
 		ipHeaderLen := (buf[0] & 0x0F) * 4 // IP头部长度
-		fmt.Printf("IP头部长度: %d字节\n", ipHeaderLen)
+		c.log.Infof("IP头部长度: %d字节", ipHeaderLen)
 		tcpOffset := ipHeaderLen
 
 		// 检查源端口和目标端口
 		srcPort := binary.BigEndian.Uint16(buf[tcpOffset : tcpOffset+2])
 		dstPort := binary.BigEndian.Uint16(buf[tcpOffset+2 : tcpOffset+4])
-		fmt.Printf("收到的数据包端口信息:\n")
-		fmt.Printf("  源端口: %d，目标端口: %d\n", srcPort, dstPort)
-		fmt.Printf("  本地配置 - 源端口: %d，目标端口: %d\n", c.srcPort, c.targetPort)
+		c.log.Infof("收到的数据包端口信息:")
+		c.log.Infof("  源端口: %d，目标端口: %d", srcPort, dstPort)
+		c.log.Infof("  本地配置 - 源端口: %d，目标端口: %d", c.srcPort, c.targetPort)
 
 		// 检查端口匹配
 		// 对于收到的SYN+ACK包，源端口应该是目标端口，目标端口应该是源端口
 		if srcPort != uint16(c.targetPort) || dstPort != c.srcPort {
-			fmt.Printf("端口不匹配:\n")
-			fmt.Printf("  收到的包 - 源端口: %d，目标端口: %d\n", srcPort, dstPort)
-			fmt.Printf("  期望的值 - 源端口: %d，目标端口: %d\n", c.targetPort, c.srcPort)
+			c.log.Infof("端口不匹配:")
+			c.log.Infof("  收到的包 - 源端口: %d，目标端口: %d", srcPort, dstPort)
+			c.log.Infof("  期望的值 - 源端口: %d，目标端口: %d", c.targetPort, c.srcPort)
 			continue
 		}
 
 		// 检查TCP标志位
 		tcpFlags := buf[tcpOffset+13]
 		if c.verbose {
-			fmt.Printf("TCP标志位分析:\n")
-			fmt.Printf("  收到的标志位: 0x%02x\n", tcpFlags)
-			fmt.Printf("  标志位含义:\n")
-			fmt.Printf("    FIN: %v\n", tcpFlags&0x01 != 0)
-			fmt.Printf("    SYN: %v\n", tcpFlags&0x02 != 0)
-			fmt.Printf("    RST: %v\n", tcpFlags&0x04 != 0)
-			fmt.Printf("    PSH: %v\n", tcpFlags&0x08 != 0)
-			fmt.Printf("    ACK: %v\n", tcpFlags&0x10 != 0)
-			fmt.Printf("    URG: %v\n", tcpFlags&0x20 != 0)
+			c.log.Infof("TCP标志位分析:")
+			c.log.Infof("  收到的标志位: 0x%02x", tcpFlags)
+			c.log.Infof("  标志位含义:")
+			c.log.Infof("    FIN: %v", tcpFlags&0x01 != 0)
+			c.log.Infof("    SYN: %v", tcpFlags&0x02 != 0)
+			c.log.Infof("    RST: %v", tcpFlags&0x04 != 0)
+			c.log.Infof("    PSH: %v", tcpFlags&0x08 != 0)
+			c.log.Infof("    ACK: %v", tcpFlags&0x10 != 0)
+			c.log.Infof("    URG: %v", tcpFlags&0x20 != 0)
 		}
 
 		// 检查是否包含SYN和ACK标志
 		if tcpFlags != 0x12 { // SYN+ACK = 0x12
 			if c.verbose {
-				fmt.Printf("  警告：期望收到SYN+ACK (0x12)，但收到了不同的标志位组合\n")
+				c.log.Infof("  警告：期望收到SYN+ACK (0x12)，但收到了不同的标志位组合")
 			}
 			continue
 		}
 		if c.verbose {
-			fmt.Printf("  确认：收到了正确的SYN+ACK标志位组合\n")
+			c.log.Infof("  确认：收到了正确的SYN+ACK标志位组合")
 		}
 
 		// 获取确认号和对方的序列号
 		c.ackNum = binary.BigEndian.Uint32(buf[tcpOffset+8:tcpOffset+12]) + 1
 		c.seqNum = binary.BigEndian.Uint32(buf[tcpOffset+4 : tcpOffset+8])
 		if c.verbose {
-			fmt.Printf("收到SYN+ACK包，确认号: %d，序列号: %d\n", c.ackNum, c.seqNum)
+			c.log.Infof("收到SYN+ACK包，确认号: %d，序列号: %d", c.ackNum, c.seqNum)
 		}
 
 		// 3. 发送ACK包
@@ -303,11 +329,11 @@ func (c *RawSocketConn) establishTCPConnection() error {
 			return fmt.Errorf("发送ACK包失败: %w", err)
 		}
 		if c.verbose {
-			fmt.Printf("已发送ACK包\n")
+			c.log.Infof("已发送ACK包")
 		}
 
 		c.connected = true
-		fmt.Printf("TCP连接建立成功 [%s:%d -> %s:%d]\n", c.sourceIP, c.srcPort, c.targetIP, c.targetPort)
+		c.log.Infof("TCP连接建立成功 [%s:%d -> %s:%d]", c.sourceIP, c.srcPort, c.targetIP, c.targetPort)
 		return nil
 	}
 
@@ -328,7 +354,7 @@ func (c *RawSocketConn) establishTCPConnection() error {
 // 返回值：
 //   - error: 发送过程中的错误
 func (c *RawSocketConn) sendTCPPacket(flags uint16, data []byte) error {
-	fmt.Printf("准备发送TCP数据包，标志位: 0x%02x\n", flags)
+	c.log.Infof("准备发送TCP数据包，标志位: 0x%02x", flags)
 
 	// 构建IP头部
 	ipHeader := make([]byte, 20)
@@ -348,7 +374,7 @@ func (c *RawSocketConn) sendTCPPacket(flags uint16, data []byte) error {
 	binary.BigEndian.PutUint16(tcpHeader[16:18], 0)     // 校验和
 	binary.BigEndian.PutUint16(tcpHeader[18:20], 0)     // 紧急指针
 
-	fmt.Printf("TCP头部 - 源端口: %d, 目标端口: %d, 序列号: %d, 确认号: %d\n",
+	c.log.Infof("TCP头部 - 源端口: %d, 目标端口: %d, 序列号: %d, 确认号: %d",
 		c.srcPort, c.targetPort, c.seqNum, c.ackNum)
 
 	// 计算TCP校验和
@@ -384,18 +410,18 @@ func (c *RawSocketConn) sendTCPPacket(flags uint16, data []byte) error {
 		Addr: [4]byte{c.targetIP[0], c.targetIP[1], c.targetIP[2], c.targetIP[3]},
 	}
 
-	fmt.Printf("IP头部 - 源IP: %v, 目标IP: %v\n", net.IP(c.sourceIP), net.IP(c.targetIP))
-	fmt.Printf("准备发送到地址: %v:%d\n", net.IP(addr.Addr[:]), addr.Port)
+	c.log.Infof("IP头部 - 源IP: %v, 目标IP: %v", net.IP(c.sourceIP), net.IP(c.targetIP))
+	c.log.Infof("准备发送到地址: %v:%d", net.IP(addr.Addr[:]), addr.Port)
 
 	err := syscall.Sendto(c.fd, packet, 0, &addr)
 	if err != nil {
 		if c.verbose {
-			fmt.Printf("发送数据包失败: %v\n", err)
+			c.log.Infof("发送数据包失败: %v", err)
 		}
-		return err
+		return sendError(err)
 	}
 	if c.verbose {
-		fmt.Printf("数据包发送成功，长度: %d字节\n", len(packet))
+		c.log.Infof("数据包发送成功，长度: %d字节", len(packet))
 	}
 	return nil
 }
@@ -436,48 +462,8 @@ func (c *RawSocketConn) Write(data []byte) (int, error) {
 		c.seqNum += uint32(len(data))
 		return len(data), nil
 	case "udp":
-		// 构建IP头部
-		ipHeader := make([]byte, 20)
-		ipHeader[0] = 0x45 // 版本(4)和头部长度(5)
-		ipHeader[1] = 0x00 // 服务类型
-		ipHeaderLen := 20
-
-		// UDP头部
-		udpHeader := make([]byte, 8)
-		srcPort := uint16(time.Now().UnixNano()&0xFFFF) + 32768 // 随机源端口
-		dstPort := uint16(c.targetPort)
-
-		binary.BigEndian.PutUint16(udpHeader[0:2], srcPort)
-		binary.BigEndian.PutUint16(udpHeader[2:4], dstPort)
-		binary.BigEndian.PutUint16(udpHeader[4:6], uint16(8+len(data))) // UDP长度
-		// 校验和字段先设为0
-		binary.BigEndian.PutUint16(udpHeader[6:8], 0)
-
-		// 计算UDP校验和
-		udpChecksum := calculateUDPChecksum(c.sourceIP, c.targetIP, udpHeader, data)
-		binary.BigEndian.PutUint16(udpHeader[6:8], udpChecksum)
-
-		// 设置IP头部其他字段
-		totalLen := uint16(ipHeaderLen + len(udpHeader) + len(data))
-		binary.BigEndian.PutUint16(ipHeader[2:4], totalLen)
-		binary.BigEndian.PutUint16(ipHeader[4:6], uint16(time.Now().UnixNano()&0xFFFF)) // ID字段
-		binary.BigEndian.PutUint16(ipHeader[6:8], 0)                                    // 标志和片偏移
-		ipHeader[8] = 64                                                                // TTL
-		ipHeader[9] = syscall.IPPROTO_UDP                                               // 协议
-		// 校验和字段先设为0
-		binary.BigEndian.PutUint16(ipHeader[10:12], 0)
-		copy(ipHeader[12:16], c.sourceIP.To4())
-		copy(ipHeader[16:20], c.targetIP.To4())
-
-		// 计算IP头部校验和
-		ipChecksum := calculateIPChecksum(ipHeader)
-		binary.BigEndian.PutUint16(ipHeader[10:12], ipChecksum)
-
-		// 组装完整的数据包
-		packet := make([]byte, len(ipHeader)+len(udpHeader)+len(data))
-		copy(packet[0:len(ipHeader)], ipHeader)
-		copy(packet[len(ipHeader):len(ipHeader)+len(udpHeader)], udpHeader)
-		copy(packet[len(ipHeader)+len(udpHeader):], data)
+		packet := c.buildUDPPacket(data)
+		defer putBuffer(packet)
 
 		// 构建目标地址结构
 		addr := syscall.SockaddrInet4{
@@ -486,8 +472,8 @@ func (c *RawSocketConn) Write(data []byte) (int, error) {
 		}
 
 		// 发送数据包
-		if err := syscall.Sendto(c.fd, packet, 0, &addr); err != nil {
-			return 0, fmt.Errorf("发送数据包失败: %w", err)
+		if err := syscall.Sendto(c.fd, *packet, 0, &addr); err != nil {
+			return 0, fmt.Errorf("发送数据包失败: %w", sendError(err))
 		}
 
 		return len(data), nil
@@ -496,6 +482,151 @@ func (c *RawSocketConn) Write(data []byte) (int, error) {
 	}
 }
 
+// buildUDPPacket 构建完整的IP/UDP数据包
+// 功能：
+//   - 填充IP头部和UDP头部（源端口随机）
+//   - 计算UDP和IP校验和
+//   - 头部和负载直接写入从缓冲区池取出的同一块内存，不产生中间切片
+//
+// 参数：
+//   - data: UDP负载数据
+//
+// 返回值：
+//   - *[]byte: 可直接通过原始套接字发送的数据包，发送完成后由调用方用putBuffer放回
+func (c *RawSocketConn) buildUDPPacket(data []byte) *[]byte {
+	const ipHeaderLen, udpHeaderLen = 20, 8
+
+	buf := getBuffer()
+	packet := append(*buf, make([]byte, ipHeaderLen+udpHeaderLen)...)
+	packet = append(packet, data...)
+	*buf = packet
+
+	// 构建IP头部
+	ipHeader := packet[:ipHeaderLen]
+	ipHeader[0] = 0x45 // 版本(4)和头部长度(5)
+	ipHeader[1] = 0x00 // 服务类型
+
+	// UDP头部
+	udpHeader := packet[ipHeaderLen : ipHeaderLen+udpHeaderLen]
+	srcPort := c.srcPort
+	if srcPort == 0 {
+		srcPort = uint16(time.Now().UnixNano()&0xFFFF) + 32768 // 随机源端口
+	}
+	dstPort := uint16(c.targetPort)
+
+	binary.BigEndian.PutUint16(udpHeader[0:2], srcPort)
+	binary.BigEndian.PutUint16(udpHeader[2:4], dstPort)
+	binary.BigEndian.PutUint16(udpHeader[4:6], uint16(udpHeaderLen+len(data))) // UDP长度
+	// 校验和字段先设为0
+	binary.BigEndian.PutUint16(udpHeader[6:8], 0)
+
+	// 计算UDP校验和
+	udpChecksum := calculateUDPChecksum(c.sourceIP, c.targetIP, udpHeader, data)
+	binary.BigEndian.PutUint16(udpHeader[6:8], udpChecksum)
+
+	// 设置IP头部其他字段
+	binary.BigEndian.PutUint16(ipHeader[2:4], uint16(len(packet)))
+	binary.BigEndian.PutUint16(ipHeader[4:6], uint16(time.Now().UnixNano()&0xFFFF)) // ID字段
+	binary.BigEndian.PutUint16(ipHeader[6:8], 0)                                    // 标志和片偏移
+	ipHeader[8] = 64                                                                // TTL
+	ipHeader[9] = syscall.IPPROTO_UDP                                               // 协议
+	// 校验和字段先设为0
+	binary.BigEndian.PutUint16(ipHeader[10:12], 0)
+	copy(ipHeader[12:16], c.sourceIP.To4())
+	copy(ipHeader[16:20], c.targetIP.To4())
+
+	// 计算IP头部校验和
+	ipChecksum := calculateIPChecksum(ipHeader)
+	binary.BigEndian.PutUint16(ipHeader[10:12], ipChecksum)
+
+	return buf
+}
+
+// mmsghdr 对应Linux内核的struct mmsghdr，用于sendmmsg批量发送
+type mmsghdr struct {
+	hdr unix.Msghdr
+	len uint32
+}
+
+// WriteBatch 批量发送数据
+// 功能：
+//   - UDP协议下一次构建多个数据包，通过sendmmsg在一次系统调用中发出
+//   - 内核不支持sendmmsg时退化为逐个Sendto
+//   - TCP协议需要维护序列号，逐条调用Write发送
+//
+// 参数：
+//   - msgs: 要发送的消息列表，每个元素对应一个数据报
+//
+// 返回值：
+//   - int: 成功发送的消息条数
+//   - error: 发送过程中的错误
+func (c *RawSocketConn) WriteBatch(msgs [][]byte) (int, error) {
+	if c.closed {
+		return 0, fmt.Errorf("连接已关闭")
+	}
+	if c.protocol != "udp" {
+		return writeEach(c, msgs)
+	}
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	// 目标地址，原始套接字下端口由IP包内的UDP头部决定
+	addr := unix.RawSockaddrInet4{Family: unix.AF_INET}
+	copy(addr.Addr[:], c.targetIP.To4())
+
+	packets := make([][]byte, len(msgs))
+	iovecs := make([]unix.Iovec, len(msgs))
+	hdrs := make([]mmsghdr, len(msgs))
+	bufs := make([]*[]byte, len(msgs))
+	defer func() {
+		for _, buf := range bufs {
+			putBuffer(buf)
+		}
+	}()
+	for i, data := range msgs {
+		bufs[i] = c.buildUDPPacket(data)
+		packets[i] = *bufs[i]
+		iovecs[i].Base = &packets[i][0]
+		iovecs[i].SetLen(len(packets[i]))
+		hdrs[i].hdr.Name = (*byte)(unsafe.Pointer(&addr))
+		hdrs[i].hdr.Namelen = unix.SizeofSockaddrInet4
+		hdrs[i].hdr.Iov = &iovecs[i]
+		hdrs[i].hdr.SetIovlen(1)
+	}
+
+	// sendmmsg可能只发送部分消息，循环直到全部发出
+	sent := 0
+	for sent < len(hdrs) {
+		n, _, errno := syscall.Syscall6(unix.SYS_SENDMMSG, uintptr(c.fd),
+			uintptr(unsafe.Pointer(&hdrs[sent])), uintptr(len(hdrs)-sent), 0, 0, 0)
+		switch errno {
+		case 0:
+			sent += int(n)
+		case syscall.EINTR:
+			continue
+		case syscall.ENOSYS:
+			// 旧内核不支持sendmmsg，逐个发送剩余的数据包
+			return c.sendPackets(packets, sent)
+		default:
+			return sent, fmt.Errorf("批量发送数据包失败: %w", sendError(errno))
+		}
+	}
+	return sent, nil
+}
+
+// sendPackets 从下标start开始逐个发送已构建的UDP数据包
+func (c *RawSocketConn) sendPackets(packets [][]byte, start int) (int, error) {
+	addr := syscall.SockaddrInet4{Port: c.targetPort}
+	copy(addr.Addr[:], c.targetIP.To4())
+	for i := start; i < len(packets); i++ {
+		if err := syscall.Sendto(c.fd, packets[i], 0, &addr); err != nil {
+			return i, fmt.Errorf("发送数据包失败: %w", sendError(err))
+		}
+	}
+	return len(packets), nil
+}
+
 // Read 读取数据 (原始套接字通常不用于读取)
 // 功能：
 //   - 从原始套接字读取数据
@@ -530,7 +661,7 @@ func (c *RawSocketConn) Close() error {
 
 // LocalAddr 返回本地地址
 func (c *RawSocketConn) LocalAddr() net.Addr {
-	return &net.TCPAddr{IP: c.sourceIP, Port: 0}
+	return &net.TCPAddr{IP: c.sourceIP, Port: int(c.srcPort)}
 }
 
 // RemoteAddr 返回远程地址
@@ -539,8 +670,9 @@ func (c *RawSocketConn) RemoteAddr() net.Addr {
 }
 
 // SetDeadline 设置读写超时
+// 原始套接字不支持读取，只设置写超时；TCP握手等待SYN+ACK时使用单独的接收超时
 func (c *RawSocketConn) SetDeadline(t time.Time) error {
-	return nil // 原始套接字不支持超时设置
+	return c.SetWriteDeadline(t)
 }
 
 // SetReadDeadline 设置读超时
@@ -549,8 +681,28 @@ func (c *RawSocketConn) SetReadDeadline(t time.Time) error {
 }
 
 // SetWriteDeadline 设置写超时
+// 原始套接字没有截止时间，按距截止时间的剩余时长设置SO_SNDTIMEO，零值表示不超时；
+// 超时后发送返回EAGAIN，由sendError转换为os.ErrDeadlineExceeded
 func (c *RawSocketConn) SetWriteDeadline(t time.Time) error {
-	return nil
+	var tv syscall.Timeval
+	if !t.IsZero() {
+		d := time.Until(t)
+		if d < time.Microsecond {
+			// 截止时间已过，设置最小超时，使下一次发送在缓冲区已满时立即失败
+			d = time.Microsecond
+		}
+		tv = syscall.NsecToTimeval(d.Nanoseconds())
+	}
+	return syscall.SetsockoptTimeval(c.fd, syscall.SOL_SOCKET, syscall.SO_SNDTIMEO, &tv)
+}
+
+// sendError 将发送超时（SO_SNDTIMEO到期时返回EAGAIN）转换为os.ErrDeadlineExceeded，
+// 与标准连接的写超时错误一致
+func sendError(err error) error {
+	if errors.Is(err, syscall.EAGAIN) {
+		return fmt.Errorf("%v: %w", err, os.ErrDeadlineExceeded)
+	}
+	return err
 }
 
 // calculateIPChecksum 计算IP校验和
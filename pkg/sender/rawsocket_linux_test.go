@@ -0,0 +1,23 @@
+package sender
+
+import (
+	"net"
+	"testing"
+)
+
+// BenchmarkBuildUDPPacket 组装一个IP/UDP数据包并放回缓冲区
+func BenchmarkBuildUDPPacket(b *testing.B) {
+	c := &RawSocketConn{
+		sourceIP:   net.ParseIP("192.0.2.1"),
+		targetIP:   net.ParseIP("198.51.100.1"),
+		targetPort: 514,
+		srcPort:    40000,
+		protocol:   "udp",
+	}
+	payload := []byte("<14>1 2024-01-02T03:04:05Z host app - - - benchmark message body\n")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		putBuffer(c.buildUDPPacket(payload))
+	}
+}
@@ -5,10 +5,14 @@ package sender
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"syscall"
 	"time"
+
+	"syslog_go/pkg/logging"
 )
 
 // Windows系统的常量定义
@@ -19,21 +23,37 @@ const (
 	IPPROTO_UDP = 17
 	IPPROTO_IP  = 0
 	IP_HDRINCL  = 2
+
+	soSNDTIMEO   = 0x1005               // Winsock的SO_SNDTIMEO，syscall包中未定义
+	wsaETIMEDOUT = syscall.Errno(10060) // 发送超时
 )
 
 // RawSocketConn Windows版本的原始套接字连接
 type RawSocketConn struct {
 	fd         syscall.Handle
 	sourceIP   net.IP
+	srcPort    uint16 // 源端口，为0时每个数据包使用随机端口
 	targetIP   net.IP
 	targetPort int
 	protocol   string
 	closed     bool
-	verbose    bool // 是否输出详细日志
+	verbose    bool            // 是否输出详细日志
+	log        *logging.Logger // 日志输出目标
+}
+
+// checkRawSocketPrivilege 检查当前进程能否创建原始套接字 (Windows版本)
+// 通过试探性地创建并立即关闭一个原始套接字判断，需要以管理员身份运行
+func checkRawSocketPrivilege() error {
+	fd, err := syscall.Socket(AF_INET, SOCK_RAW, IPPROTO_UDP)
+	if err != nil {
+		return fmt.Errorf("无法创建原始套接字: %w (需要以管理员身份运行)", err)
+	}
+	syscall.Close(fd)
+	return nil
 }
 
 // NewRawSocketConn 创建新的原始套接字连接 (Windows版本)
-func newRawSocketConn(sourceIP, targetAddr, protocol string, verbose bool) (*RawSocketConn, error) {
+func newRawSocketConn(sourceIP string, sourcePort int, targetAddr, protocol string, verbose bool, logger *logging.Logger) (*RawSocketConn, error) {
 	// 解析源IP地址
 	srcIP := net.ParseIP(sourceIP)
 	if srcIP == nil {
@@ -103,11 +123,13 @@ func newRawSocketConn(sourceIP, targetAddr, protocol string, verbose bool) (*Raw
 	return &RawSocketConn{
 		fd:         fd,
 		sourceIP:   srcIP,
+		srcPort:    uint16(sourcePort),
 		targetIP:   targetIP,
 		targetPort: targetPort,
 		protocol:   protocol,
 		closed:     false,
 		verbose:    verbose,
+		log:        logger,
 	}, nil
 }
 
@@ -137,13 +159,17 @@ func (c *RawSocketConn) Write(data []byte) (int, error) {
 	err := syscall.Sendto(c.fd, packet, 0, addr)
 	if err != nil {
 		if c.verbose {
-			fmt.Printf("发送数据包失败: %v\n", err)
+			c.log.Infof("发送数据包失败: %v", err)
+		}
+		if errors.Is(err, wsaETIMEDOUT) {
+			// 与标准连接的写超时错误一致
+			err = fmt.Errorf("%v: %w", err, os.ErrDeadlineExceeded)
 		}
 		return 0, fmt.Errorf("发送数据包失败: %w", err)
 	}
 
 	if c.verbose {
-		fmt.Printf("数据包发送成功，长度: %d字节\n", len(packet))
+		c.log.Infof("数据包发送成功，长度: %d字节", len(packet))
 	}
 
 	return len(data), nil
@@ -165,7 +191,7 @@ func (c *RawSocketConn) Close() error {
 
 // LocalAddr 返回本地地址
 func (c *RawSocketConn) LocalAddr() net.Addr {
-	return &net.TCPAddr{IP: c.sourceIP, Port: 0}
+	return &net.TCPAddr{IP: c.sourceIP, Port: int(c.srcPort)}
 }
 
 // RemoteAddr 返回远程地址
@@ -173,9 +199,9 @@ func (c *RawSocketConn) RemoteAddr() net.Addr {
 	return &net.TCPAddr{IP: c.targetIP, Port: c.targetPort}
 }
 
-// SetDeadline 设置读写超时
+// SetDeadline 设置读写超时，原始套接字不支持读取，只设置写超时
 func (c *RawSocketConn) SetDeadline(t time.Time) error {
-	return nil // 原始套接字不支持超时设置
+	return c.SetWriteDeadline(t)
 }
 
 // SetReadDeadline 设置读超时
@@ -184,8 +210,16 @@ func (c *RawSocketConn) SetReadDeadline(t time.Time) error {
 }
 
 // SetWriteDeadline 设置写超时
+// 按距截止时间的剩余时长设置SO_SNDTIMEO（毫秒），零值表示不超时
 func (c *RawSocketConn) SetWriteDeadline(t time.Time) error {
-	return nil
+	ms := 0
+	if !t.IsZero() {
+		ms = int(time.Until(t).Milliseconds())
+		if ms < 1 {
+			ms = 1
+		}
+	}
+	return syscall.SetsockoptInt(c.fd, syscall.SOL_SOCKET, soSNDTIMEO, ms)
 }
 
 // buildIPHeader 构造IP头
@@ -231,13 +265,21 @@ func (c *RawSocketConn) buildIPHeader(protocol uint8, dataLen int) []byte {
 	return header
 }
 
+// sourcePort 返回数据包使用的源端口，未指定时使用32768-65535范围的随机端口
+func (c *RawSocketConn) sourcePort() uint16 {
+	if c.srcPort != 0 {
+		return c.srcPort
+	}
+	return uint16(32768 + time.Now().UnixNano()%32768)
+}
+
 // buildTCPPacket 构造TCP数据包
 func (c *RawSocketConn) buildTCPPacket(data []byte) []byte {
 	// 构造TCP头 (20字节)
 	tcpHeader := make([]byte, 20)
 
-	// 源端口 (使用随机端口)
-	srcPort := uint16(32768 + time.Now().UnixNano()%32768) // 使用32768-65535范围的随机端口
+	// 源端口 (未指定时使用随机端口)
+	srcPort := c.sourcePort()
 	binary.BigEndian.PutUint16(tcpHeader[0:2], srcPort)
 
 	// 目标端口
@@ -286,8 +328,8 @@ func (c *RawSocketConn) buildUDPPacket(data []byte) []byte {
 	// 构造UDP头 (8字节)
 	udpHeader := make([]byte, 8)
 
-	// 源端口 (使用随机端口)
-	srcPort := uint16(32768 + time.Now().UnixNano()%32768) // 使用32768-65535范围的随机端口
+	// 源端口 (未指定时使用随机端口)
+	srcPort := c.sourcePort()
 	binary.BigEndian.PutUint16(udpHeader[0:2], srcPort)
 
 	// 目标端口
@@ -0,0 +1,32 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReloadTemplates 重新读取模板文件和自定义变量配置文件，成功后整体替换模板引擎
+// 新引擎创建完成后才原子地替换，发送协程在替换前后分别使用旧引擎或新引擎生成完整的消息，不需要加锁；
+// 加载失败时继续使用原来的模板。命令行消息、Severity模板、结构化数据和附加字段来自启动时的配置，
+// 重新加载时保持不变，但其中引用的自定义变量和FILE变量的文件内容会重新读取
+// 返回值：
+//   - error: 未使用模板、按轮次重放或加载失败时返回错误
+func (s *Sender) ReloadTemplates() error {
+	if s.engine() == nil {
+		return errors.New("未使用消息模板，没有可重新加载的内容")
+	}
+	if s.replay != nil {
+		return errors.New("按轮次重放的消息在启动时已生成，重新加载模板不影响本次发送")
+	}
+
+	engine, err := s.newTemplateEngine()
+	if err != nil {
+		return err
+	}
+	// 创建引擎时自定义变量配置加载失败只在详细模式下提示，重新加载时明确报错，避免静默丢失自定义变量
+	if err := engine.LoadError(); err != nil {
+		return fmt.Errorf("加载自定义变量配置失败: %w", err)
+	}
+	s.templateEngine.Store(engine)
+	return nil
+}
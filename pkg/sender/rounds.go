@@ -0,0 +1,104 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"syslog_go/pkg/syslog"
+)
+
+// errAllSent 有界发送（按轮次重放或按总量发送）的消息都已发送完毕
+var errAllSent = errors.New("消息已全部发送")
+
+// replaySet 按轮次重放的固定消息集合
+// 消息在开始发送前一次性生成，时间戳等内容随之固定，每轮发送的字节完全相同
+type replaySet struct {
+	messages []*syslog.Message
+	rounds   int
+	total    int64 // 需要发送的消息总数
+	next     int64 // 下一条消息的序号，原子操作更新
+}
+
+// nextMessage 返回下一条要重放的消息，全部轮次发送完后返回errAllSent
+func (r *replaySet) nextMessage() (*syslog.Message, error) {
+	i := atomic.AddInt64(&r.next, 1) - 1
+	if i >= r.total {
+		return nil, errAllSent
+	}
+	return r.messages[i%int64(len(r.messages))], nil
+}
+
+// done 是否所有轮次都已取完
+func (r *replaySet) done() bool {
+	return atomic.LoadInt64(&r.next) >= r.total
+}
+
+// allSent 有界发送的消息是否都已取出，无界发送（按持续时间结束）时总是false
+func (s *Sender) allSent() bool {
+	if s.replay != nil {
+		return s.replay.done()
+	}
+	return s.total > 0 && atomic.LoadInt64(&s.issued) >= s.total
+}
+
+// initReplay 生成重放的消息集合
+// 每轮的消息数为RoundSize；未指定时只使用数据文件则为文件总行数，否则为EPS
+func (s *Sender) initReplay() error {
+	size := s.config.RoundSize
+	if size == 0 {
+		size = s.config.EPS
+		if s.dataReader != nil && s.engine() == nil {
+			lines, err := s.dataReader.lineCount()
+			if err != nil {
+				return err
+			}
+			if lines == 0 {
+				return fmt.Errorf("数据文件为空")
+			}
+			size = lines
+		}
+	}
+	if size == 0 {
+		return fmt.Errorf("不限速时需要用 --round-size 指定每轮的消息数")
+	}
+
+	messages := make([]*syslog.Message, 0, size)
+	for len(messages) < size {
+		message, err := s.generateMessage()
+		if err != nil {
+			return fmt.Errorf("生成重放消息失败: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	s.replay = &replaySet{
+		messages: messages,
+		rounds:   s.config.Rounds,
+		total:    int64(size) * int64(s.config.Rounds),
+	}
+	if s.config.Verbose {
+		s.log.Info(fmt.Sprintf("已生成 %d 条消息，将重放 %d 轮", size, s.config.Rounds),
+			"round_size", size, "rounds", s.config.Rounds)
+	}
+	return nil
+}
+
+// nextMessage 返回下一条要发送的消息
+// 配置了轮次时从重放集合中取，否则生成新消息；同时记录消息的Facility和Severity。
+// 按总量发送时第Total条之后返回errAllSent
+func (s *Sender) nextMessage() (*syslog.Message, error) {
+	var message *syslog.Message
+	var err error
+	if s.replay != nil {
+		message, err = s.replay.nextMessage()
+	} else if s.total > 0 && atomic.AddInt64(&s.issued, 1) > s.total {
+		return nil, errAllSent
+	} else {
+		message, err = s.generateMessage()
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.recordPriority(message.GetFacility(), message.GetSeverity())
+	return message, nil
+}
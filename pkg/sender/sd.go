@@ -0,0 +1,55 @@
+package sender
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"syslog_go/pkg/syslog"
+)
+
+// sdElementTemplate 一个配置的结构化数据元素
+// 参数值包含模板变量时加载为引擎中的模板，每条消息重新求值
+type sdElementTemplate struct {
+	element   syslog.SDElement
+	templates []string // 与element.Params一一对应的模板名，值不含模板变量时为空
+}
+
+// initStructuredData 解析配置的结构化数据元素，为含模板变量的参数值分配模板名
+// 模板由newTemplateEngine加载到引擎中
+func (s *Sender) initStructuredData() error {
+	for i, item := range s.config.StructuredData {
+		element, err := syslog.ParseSDElement(item)
+		if err != nil {
+			return fmt.Errorf("结构化数据 %s 无效: %w", item, err)
+		}
+		tpl := sdElementTemplate{element: element, templates: make([]string, len(element.Params))}
+		for j, param := range element.Params {
+			if strings.Contains(param.Value, "{{") {
+				tpl.templates[j] = "sd:" + strconv.Itoa(i) + ":" + param.Name
+			}
+		}
+		s.sdElements = append(s.sdElements, tpl)
+	}
+	return nil
+}
+
+// buildStructuredData 按配置顺序生成本条消息的结构化数据
+func (s *Sender) buildStructuredData() (string, error) {
+	var b strings.Builder
+	for _, tpl := range s.sdElements {
+		element := syslog.SDElement{ID: tpl.element.ID, Params: make([]syslog.SDParam, len(tpl.element.Params))}
+		for i, param := range tpl.element.Params {
+			if tpl.templates[i] != "" {
+				value, err := s.engine().GenerateMessage(tpl.templates[i])
+				if err != nil {
+					return "", fmt.Errorf("处理结构化数据 %s 的参数 %s 失败: %w", element.ID, param.Name, err)
+				}
+				param.Value = value
+			}
+			element.Params[i] = param
+		}
+		b.WriteString(element.String())
+	}
+	return b.String(), nil
+}
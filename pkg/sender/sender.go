@@ -1,19 +1,35 @@
 package sender
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"syslog_go/pkg/config"
+	"syslog_go/pkg/logging"
 	"syslog_go/pkg/syslog"
 	"syslog_go/pkg/template"
 )
 
+// 写入origin结构化数据的软件信息
+// SoftwareVersion可在构建时通过 -ldflags "-X syslog_go/pkg/sender.SoftwareVersion=x.y.z" 覆盖
+var (
+	SoftwareName    = "syslog_go"
+	SoftwareVersion = "1.0.0"
+)
+
 // Sender Syslog发送器
 // 负责管理消息的生成、发送和统计信息收集
 // 主要功能：
@@ -27,23 +43,61 @@ type Sender struct {
 	config *config.Config // 配置信息，包含目标地址、协议、并发数等
 
 	// 连接管理
-	connPool *ConnectionPool // 连接池，管理与目标服务器的连接，支持连接复用
+	targets   []*sendTarget // 发送目标，每个目标一个连接池，消息扇出到所有目标
+	tlsConfig *tls.Config   // TCP连接的TLS配置，未启用TLS时为nil
 
 	// 性能控制
-	rateLimiter *RateLimiter // 速率限制器，控制消息发送速率，防止目标服务器过载
+	rateLimiter *RateLimiter         // 速率限制器，控制消息发送速率，防止目标服务器过载
+	arrivals    *InterArrivalLimiter // 随机间隔限制器，配置了消息间隔分布时代替rateLimiter
+	ceiling     *RateLimiter         // 按消息间隔分布发送时的速率上限，未配置时为nil
+	tickets     chan struct{}        // loadgen模式下的发送票据队列，由生产协程按速率写入
 
 	// 状态监控
 	stats *Statistics // 统计信息，记录发送成功/失败数量、运行时间等指标
 
 	// 生命周期管理
-	ctx    context.Context    // 上下文，用于控制发送器的生命周期和优雅停止
-	cancel context.CancelFunc // 取消函数，用于触发停止信号
-	wg     sync.WaitGroup     // 等待组，确保所有协程完成后再退出
+	ctx     context.Context    // 上下文，用于控制发送器的生命周期和优雅停止
+	cancel  context.CancelFunc // 取消函数，用于触发停止信号
+	wg      sync.WaitGroup     // 等待组，确保所有协程完成后再退出
+	workers int32              // 仍在运行的发送工作协程数，原子操作更新
+	spawned int32              // 已启动的发送工作协程总数（包括自动扩容增加的），原子操作更新
+
+	done     chan struct{} // 所有发送工作协程退出时关闭，统计监控据此结束，不必等到持续时间超时
+	doneOnce sync.Once     // 保证done只关闭一次（自动扩容时工作协程数可能多次归零）
 
 	// 消息生成
-	templateEngine *template.Engine // 模板引擎，处理消息模板和变量替换
-	dataFile       *os.File         // 数据文件句柄，用于从文件读取消息内容
-	dataScanner    *bufio.Scanner   // 数据文件扫描器，支持按行读取数据
+	templateEngine atomic.Pointer[template.Engine] // 模板引擎，处理消息模板和变量替换；重新加载模板时整体替换
+	severities     []config.WeightedValue          // Severity分布，为空时固定使用配置的Severity
+	severityTotal  int                             // Severity分布的权重总和
+	facilities     []config.WeightedValue          // Facility分布，为空时固定使用配置的Facility
+	facilityTotal  int                             // Facility分布的权重总和
+	severityTpls   map[int]bool                    // 配置了专用模板的Severity
+	dataReader     *dataReader                     // 数据文件读取器，从一个或多个文件按行读取消息内容，未配置数据文件时为nil
+	hostname       string                          // 消息的HOSTNAME字段，创建发送器时确定，整个运行期间不变
+	procID         string                          // 消息的固定进程ID，为空时不输出
+	procIDTpl      bool                            // 进程ID包含模板变量，每条消息重新求值
+	originSD       string                          // 自动添加的origin结构化数据元素，未启用或非RFC5424时为空
+	sdElements     []sdElementTemplate             // 配置的结构化数据元素，按顺序添加到每条消息
+	fields         []fieldTemplate                 // JSON格式下配置的附加字段，按顺序添加到每条消息
+	replay         *replaySet                      // 按轮次重放的固定消息集合，未配置轮次时为nil
+	total          int64                           // 按总量发送时的消息总数，为0时按持续时间结束
+	issued         int64                           // 按总量发送时已取出的消息数，原子操作更新
+	clock          syslog.Clock                    // 消息时间戳的时钟，默认为系统时间
+
+	// 输出
+	stdout   io.Writer       // 演练模式消息的输出目标
+	progress io.Writer       // 有界发送的进度行输出目标（终端上的stderr），不显示进度时为nil
+	log      *logging.Logger // 统计、详细日志和警告
+	dryRunMu sync.Mutex      // 保证演练模式下多个协程输出的消息不交错
+
+	// 错误提示
+	tooLargeOnce sync.Once // 消息超过数据报上限的提示每次运行只输出一次
+
+	// 失败熔断
+	failureLimit *config.FailureLimit // 失败熔断阈值，未配置时为nil
+	epsTolerance float64              // 实际平均速率与目标速率的允许偏差比例，为0时不检查
+	abortOnce    sync.Once            // 保证只记录第一次提前结束的原因
+	abortErr     error                // 提前结束的原因，由Start返回
 }
 
 // Statistics 统计信息结构体
@@ -58,14 +112,51 @@ type Statistics struct {
 	Sent   int64 `json:"sent"`   // 已成功发送的消息数量，原子操作更新
 	Failed int64 `json:"failed"` // 发送失败的消息数量，原子操作更新
 
+	// loadgen模式下的提供负载
+	Offered int64 `json:"offered"` // 按目标速率产生的发送票据数量，原子操作更新
+	Missed  int64 `json:"missed"`  // 因工作协程跟不上、票据队列已满而错过的票据数量，原子操作更新
+
+	// 失败原因细分（同时计入Failed）
+	TooLarge int64 `json:"too_large"` // 超过UDP数据报上限（EMSGSIZE）而发送失败的消息数量，原子操作更新
+
 	// 时间戳
 	StartTime time.Time `json:"start_time"` // 统计开始时间，用于计算运行时长
 	EndTime   time.Time `json:"end_time"`   // 统计结束时间，用于计算总体性能指标
 
+	// 写入延迟
+	latency latencyHistogram // 每次写入调用（批量模式下为每批）的耗时分布
+
+	// 优先级分布
+	severityCounts [8]int64  // 生成的消息按Severity计数，原子操作更新
+	facilityCounts [24]int64 // 生成的消息按Facility计数，原子操作更新
+
 	// 并发控制
 	mutex sync.RWMutex // 读写锁，保护统计数据的并发访问
 }
 
+// StatsSnapshot 发送结果快照
+// 由Start返回，便于以库方式使用时直接断言发送结果，而不必解析输出
+type StatsSnapshot struct {
+	Sent     int64         `json:"sent"`      // 已成功发送的消息数量
+	Failed   int64         `json:"failed"`    // 发送失败的消息数量
+	Offered  int64         `json:"offered"`   // loadgen模式下产生的发送票据数量
+	Missed   int64         `json:"missed"`    // loadgen模式下错过的票据数量
+	TooLarge int64         `json:"too_large"` // 超过UDP数据报上限的消息数量
+	Duration time.Duration `json:"duration"`  // 运行时长，尚未结束时为到当前的时长
+	EPS      float64       `json:"eps"`       // 实际达到的平均发送速率
+	Targets  []TargetStats `json:"targets"`   // 每个目标的发送统计
+
+	// 写入延迟分位数（近似值，相对误差不超过25%），没有实际写入（如演练模式）时为0
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP90 time.Duration `json:"latency_p90"`
+	LatencyP99 time.Duration `json:"latency_p99"`
+	LatencyMax time.Duration `json:"latency_max"`
+
+	// 生成的消息按名称统计的Severity和Facility分布，用于核对配置的分布
+	Severities map[string]int64 `json:"severities"`
+	Facilities map[string]int64 `json:"facilities"`
+}
+
 // NewSender 创建新的发送器实例
 // 参数：
 //   - cfg: 发送器配置信息，包含连接、模板、速率限制等配置
@@ -74,38 +165,249 @@ type Statistics struct {
 //   - *Sender: 创建的发送器实例
 //   - error: 创建过程中的错误，如果创建成功则为nil
 func NewSender(cfg *config.Config) (*Sender, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Duration)
+	return NewSenderWithOutput(cfg, os.Stdout, os.Stderr)
+}
+
+// NewSenderWithOutput 创建输出到指定Writer的发送器，便于作为库嵌入其他程序
+// 参数：
+//   - cfg: 发送器配置信息
+//   - stdout: 统计、详细日志和演练模式消息的输出目标，为nil时使用标准输出
+//   - stderr: 警告的输出目标，为nil时使用标准错误
+//
+// 日志格式由cfg.LogFormat决定，JSON格式下所有日志事件都写到stdout（演练模式下写到stderr，避免与消息混在一起）
+//
+// 返回值：
+//   - *Sender: 创建的发送器实例
+//   - error: 创建过程中的错误，如果创建成功则为nil
+func NewSenderWithOutput(cfg *config.Config, stdout, stderr io.Writer) (*Sender, error) {
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	logOut := stdout
+	if cfg.DryRun && cfg.LogFormat == logging.FormatJSON {
+		logOut = stderr
+	}
+	logger, err := logging.New(cfg.LogFormat, logOut, stderr, false)
+	if err != nil {
+		return nil, err
+	}
+	// 配置了轮次或总量时发送完所有消息即结束，不受持续时间限制
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if cfg.Rounds > 0 || cfg.Total > 0 {
+		ctx, cancel = context.WithCancel(context.Background())
+	} else {
+		ctx, cancel = context.WithTimeout(context.Background(), cfg.Duration)
+	}
 
 	s := &Sender{
 		config: cfg,
 		ctx:    ctx,
 		cancel: cancel,
+		done:   make(chan struct{}),
 		stats:  &Statistics{StartTime: time.Now()},
+		stdout: stdout,
+		log:    logger,
+		clock:  syslog.SystemClock,
+	}
+
+	s.hostname = s.localHostname()
+	s.procID = staticProcID(cfg.ProcID)
+
+	s.failureLimit, err = config.ParseMaxFailures(cfg.MaxFailures)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s.epsTolerance, err = config.ParseEPSTolerance(cfg.RequireEPSTolerance)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// 初始化模板引擎和Severity分布，配置错误时在建立连接前失败
+	if err := s.initTemplates(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// 展开数据文件列表，文件在第一次读取时才打开
+	if specs := cfg.DataFileSpecs(); len(specs) > 0 {
+		files, err := config.ExpandDataFiles(specs)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		s.dataReader = newDataReader(files)
+	}
+
+	// 初始化连接池，演练模式下消息输出到标准输出，不建立网络连接
+	if !cfg.DryRun {
+		if err := s.initConnectionPool(); err != nil {
+			cancel()
+			return nil, fmt.Errorf("初始化连接池失败: %w", err)
+		}
+	}
+
+	// 启用origin时预先生成结构化数据元素，只对RFC5424格式生效
+	if cfg.Origin && cfg.GetSyslogFormat() == syslog.RFC5424 {
+		s.originSD = s.buildOriginSD()
+	}
+
+	// 配置了轮次时预先生成重放的消息集合
+	if cfg.Rounds > 0 {
+		if err := s.initReplay(); err != nil {
+			s.Stop()
+			return nil, err
+		}
 	}
 
-	// 初始化连接池
-	if err := s.initConnectionPool(); err != nil {
-		return nil, fmt.Errorf("初始化连接池失败: %w", err)
+	// 有界发送且stderr为终端时显示进度
+	s.progress = s.progressWriter(stderr)
+
+	// 结束条件与限速方式相互独立：按总量发送时发送完Total条结束
+	if cfg.Total > 0 {
+		s.total = cfg.Total
+	}
+
+	// 初始化速率限制器，配置令牌桶时暂停期间积累的令牌可以补发（按EPS发送和按轮次重放），
+	// 否则按总量发送时按换算出的间隔限速
+	switch {
+	case cfg.Limiter == config.LimiterTokenBucket:
+		s.rateLimiter = NewTokenBucketLimiter(cfg.EPS, cfg.BucketBurst())
+	case cfg.Total > 0:
+		s.rateLimiter = newIntervalLimiter(cfg.TotalInterval(), int(math.Round(cfg.TotalRate())))
+	default:
+		s.rateLimiter = NewRateLimiter(cfg.EPS)
+	}
+	if s.rateLimiter != nil && cfg.Limiter != config.LimiterTokenBucket {
+		s.rateLimiter.SetBurst(cfg.Burst)
 	}
 
-	// 初始化速率限制器
-	s.rateLimiter = NewRateLimiter(cfg.EPS)
+	// 配置了消息间隔分布时按分布随机间隔发送
+	if spec, err := config.ParseInterArrival(cfg.InterArrival); err != nil {
+		s.Stop()
+		return nil, err
+	} else if spec != nil {
+		s.arrivals = NewInterArrivalLimiter(spec)
+		s.ceiling = NewRateLimiter(cfg.MaxEPS)
+	}
 
 	return s, nil
 }
 
-// initConnectionPool 初始化连接池
+// initConnectionPool 为每个目标初始化连接池
+// 目标地址展开为多个时（如 10.0.0.[1-10]:514），每条消息发送到所有目标；
+// 其中一个目标创建失败时关闭已经创建的连接池再返回错误
 func (s *Sender) initConnectionPool() error {
-	var err error
-	s.connPool, err = NewConnectionPool(
-		s.config.Target,
-		s.config.Protocol,
-		s.config.Concurrency,
-		s.config.Timeout,
-		s.config.SourceIP,
-		s.config.Verbose,
+	addresses, err := config.ExpandTargets(s.config.Target)
+	if err != nil {
+		return err
+	}
+	if s.tlsConfig, err = newTLSConfig(s.config); err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		pool, err := s.newConnectionPool(address)
+		if err != nil {
+			if len(addresses) > 1 {
+				err = fmt.Errorf("%s: %w", address, err)
+			}
+			s.closeTargets()
+			s.targets = nil
+			return err
+		}
+		s.targets = append(s.targets, &sendTarget{address: address, pool: pool})
+	}
+	return nil
+}
+
+// newConnectionPool 创建到一个目标的连接池
+// 目标暂时不可用时（如采集端仍在启动）按配置的次数重试，
+// 重试间隔指数退避并带随机抖动；所有重试都失败时返回最后一次的错误
+func (s *Sender) newConnectionPool(address string) (*ConnectionPool, error) {
+	for attempt := 0; ; attempt++ {
+		pool, err := NewConnectionPool(
+			s.ctx,
+			address,
+			s.transportProtocol(),
+			s.poolSize(),
+			s.config.Timeout,
+			s.config.SourceIP,
+			s.config.SourcePort,
+			s.config.Spoof,
+			s.config.Verbose,
+			s.config.Compress,
+			s.tlsConfig,
+			s.log,
+		)
+		if err == nil {
+			pool.SetMaxUses(s.config.ReconnectEvery)
+			return pool, nil
+		}
+		if attempt >= s.config.RetryCount {
+			return nil, err
+		}
+
+		delay := retryDelay(s.config.RetryInterval, attempt)
+		if s.config.Verbose {
+			s.log.Info(fmt.Sprintf("创建连接池失败: %v，%v 后进行第 %d 次重试", err, delay.Truncate(time.Millisecond), attempt+1),
+				"error", err.Error(), "retry_in", delay, "attempt", attempt+1)
+		}
+		select {
+		case <-s.ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// transportProtocol 返回连接池使用的传输协议，空输出时为NullProtocol
+// 消息的换行和分帧仍按配置的协议处理，使测得的生成开销与实际发送一致
+func (s *Sender) transportProtocol() string {
+	if s.config.NullSink {
+		return NullProtocol
+	}
+	return s.config.Protocol
+}
+
+// buildOriginSD 生成origin结构化数据元素
+// 形如 [origin@32473 software="syslog_go" swVersion="1.0.0" ip="10.0.0.1"]，
+// ip优先使用配置的源IP，否则使用到达（第一个）目标的本地地址，无法确定（如unix套接字、演练模式）时省略
+func (s *Sender) buildOriginSD() string {
+	ip := s.config.SourceIP
+	if ip == "" && s.config.Protocol != "unix" && !s.config.NullSink && len(s.targets) > 0 {
+		// UDP的Dial不发送数据，只用于确定到达目标的本地地址
+		if conn, err := net.Dial("udp", s.targets[0].address); err == nil {
+			if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+				ip = addr.IP.String()
+			}
+			conn.Close()
+		}
+	}
+
+	return syslog.FormatSDElement(
+		syslog.SDID("origin", s.config.EnterpriseID),
+		syslog.SDParam{Name: "software", Value: SoftwareName},
+		syslog.SDParam{Name: "swVersion", Value: SoftwareVersion},
+		syslog.SDParam{Name: "ip", Value: ip},
 	)
-	return err
+}
+
+// retryDelay 计算第attempt次重试前的等待时间
+// 以base为基数指数退避，并叠加[0, base)的随机抖动，避免多个发送端同时重连
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	// 限制退避倍数，避免等待时间过长
+	if attempt > 5 {
+		attempt = 5
+	}
+	return base<<uint(attempt) + time.Duration(rand.Int63n(int64(base)))
 }
 
 // Start 开始发送
@@ -115,20 +417,63 @@ func (s *Sender) initConnectionPool() error {
 //   - 等待所有协程完成或超时
 //
 // 返回值：
-//   - error: 启动过程中的错误，如果启动成功则为nil
-func (s *Sender) Start() error {
+//   - *StatsSnapshot: 发送结束时的统计快照
+//   - error: 失败超过 --max-failures 阈值而提前结束时返回包装了ErrTooManyFailures的错误，
+//     实际平均速率超出 --require-eps-tolerance 时返回包装了ErrEPSOutOfTolerance的错误（快照仍有效），否则为nil
+func (s *Sender) Start() (*StatsSnapshot, error) {
 	if s.config.Verbose {
-		fmt.Printf("开始发送，目标: %s, 协议: %s, EPS: %d\n",
-			s.config.Target, s.config.Protocol, s.config.EPS)
+		protocol := s.config.Protocol
+		if s.config.UseTLS {
+			protocol = "tls"
+		}
+		s.log.Info(fmt.Sprintf("开始发送，目标: %s, 协议: %s, EPS: %d", s.config.Target, protocol, s.config.EPS),
+			"target", s.config.Target, "protocol", protocol, "eps", s.config.EPS)
 	}
 
-	// 启动统计监控
-	if s.config.EnableStats {
+	// 统计从开始发送时计算，不包括创建发送器到调用Start之间的时间（如SRV解析、建立连接池），
+	// 否则实际EPS和速率偏差会被低估。需要在启动读取统计的协程之前设置
+	s.stats.StartTime = time.Now()
+
+	// 启动统计监控，周期统计与verbose无关，只受统计间隔和静默模式控制
+	if s.config.EnableStats && s.config.StatsInterval > 0 && !s.config.Quiet {
 		s.wg.Add(1)
 		go s.statsMonitor()
 	}
 
+	// 有界发送时在终端上显示进度
+	if s.progress != nil {
+		s.wg.Add(1)
+		go s.progressMonitor()
+	}
+
+	// loadgen模式下由单独的协程按目标速率产生发送票据
+	if s.config.LoadGen {
+		s.tickets = make(chan struct{}, s.config.BufferSize)
+		s.wg.Add(1)
+		go s.produceTickets()
+	}
+
+	// 根据服务端反馈闭环调节EPS
+	if s.config.TargetReceivedEPS > 0 && s.rateLimiter != nil {
+		s.wg.Add(1)
+		go s.autoTune()
+	}
+
+	// 按窗口内的失败比例熔断
+	if s.failureLimit != nil && s.failureLimit.Rate > 0 {
+		s.wg.Add(1)
+		go s.watchFailureRate()
+	}
+
+	// 检测实际速率是否持续低于目标EPS，按需告警或自动扩容
+	if s.rateLimiter != nil && s.arrivals == nil {
+		s.wg.Add(1)
+		go s.watchShortfall()
+	}
+
 	// 启动发送协程
+	s.workers = int32(s.config.Concurrency)
+	s.spawned = int32(s.config.Concurrency)
 	for i := 0; i < s.config.Concurrency; i++ {
 		s.wg.Add(1)
 		go s.sendWorker(i)
@@ -140,57 +485,276 @@ func (s *Sender) Start() error {
 
 	// 打印最终统计
 	s.printFinalStats()
-	return nil
+	if s.abortErr != nil {
+		return s.Snapshot(), s.abortErr
+	}
+	return s.Snapshot(), s.checkEPSTolerance()
 }
 
 // sendWorker 发送工作协程
 func (s *Sender) sendWorker(workerID int) {
 	defer s.wg.Done()
+	// 最后一个退出的工作协程通知统计等后台协程结束（所有轮次发送完毕或熔断时）
+	defer func() {
+		if atomic.AddInt32(&s.workers, -1) == 0 {
+			s.finish()
+		}
+	}()
 
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
 		default:
+			if s.checkFailures() {
+				return
+			}
+			// 有界发送的消息已取完时直接退出，不再等待下一个发送间隔
+			if s.allSent() {
+				return
+			}
+
+			// 批量模式下一次生成多条消息并通过一次写入发送
+			if s.config.BatchSize > 1 {
+				s.sendBatch()
+				if s.allSent() {
+					return
+				}
+				continue
+			}
+
 			// 等待直到允许发送
-			s.rateLimiter.Wait()
+			if !s.acquire() {
+				return
+			}
 
 			// 生成消息
-			message, err := s.generateMessage()
+			message, err := s.nextMessage()
+			if errors.Is(err, errAllSent) {
+				return
+			}
 			if err != nil {
 				if s.config.Verbose {
-					fmt.Printf("生成消息失败: %v\n", err)
+					s.log.Error("生成消息失败: "+err.Error(), "error", err.Error())
 				}
 				atomic.AddInt64(&s.stats.Failed, 1)
 				continue
 			}
 
 			// 发送消息
+			// UDP除超过数据报上限和写入超时外的错误（如ICMP端口不可达）不计为失败
 			if s.config.Protocol == "udp" {
-				_ = s.sendMessage(message)
+				if err = s.sendMessage(message); errors.Is(err, ErrMessageTooLarge) || isWriteTimeout(err) {
+					atomic.AddInt64(&s.stats.Failed, 1)
+					if s.config.Verbose {
+						s.log.Error("发送消息失败: "+err.Error(), "error", err.Error())
+					}
+					continue
+				}
 				atomic.AddInt64(&s.stats.Sent, 1)
 				if s.config.Verbose {
-					fmt.Printf("发送消息: %s\n", message.Content)
+					s.log.Info("发送消息: "+message.Content, "content", message.Content)
 				}
 			} else if err = s.sendMessage(message); err != nil {
 				atomic.AddInt64(&s.stats.Failed, 1)
 				if s.config.Verbose {
-					fmt.Printf("发送消息失败: %v\n", err)
+					s.log.Error("发送消息失败: "+err.Error(), "error", err.Error())
 				}
 			} else {
 				atomic.AddInt64(&s.stats.Sent, 1)
 				if s.config.Verbose {
-					fmt.Printf("成功发送消息: %s\n", message.Content)
+					s.log.Info("成功发送消息: "+message.Content, "content", message.Content)
 				}
 			}
 		}
 	}
 }
 
+// initTemplates 初始化模板引擎和Severity、Facility分布
+// 功能：
+//   - 加载命令行消息或结构化模板文件为"message"模板
+//   - 为每个配置了专用模板的Severity加载对应模板
+//   - 解析Severity和Facility分布
+//
+// 返回值：
+//   - error: 配置无效或模板加载失败时返回错误
+func (s *Sender) initTemplates() error {
+	var err error
+	s.severities, err = s.config.SeverityDistribution()
+	if err != nil {
+		return err
+	}
+	for _, v := range s.severities {
+		s.severityTotal += v.Weight
+	}
+	s.facilities, err = s.config.FacilityDistribution()
+	if err != nil {
+		return err
+	}
+	for _, v := range s.facilities {
+		s.facilityTotal += v.Weight
+	}
+
+	severityTemplates, err := s.config.SeverityTemplateMap()
+	if err != nil {
+		return err
+	}
+	procIDTpl := strings.Contains(s.config.ProcID, "{{")
+	if s.config.Message == "" && s.config.TemplateFile == "" && len(severityTemplates) == 0 && len(s.config.StructuredData) == 0 && len(s.config.Fields) == 0 && !procIDTpl {
+		return nil
+	}
+
+	s.severityTpls = make(map[int]bool, len(severityTemplates))
+	for severity := range severityTemplates {
+		s.severityTpls[severity] = true
+	}
+	// 结构化数据的参数值和JSON格式附加字段的值可以包含模板变量
+	if err := s.initStructuredData(); err != nil {
+		return err
+	}
+	s.initFields()
+	s.procIDTpl = procIDTpl
+
+	engine, err := s.newTemplateEngine()
+	if err != nil {
+		return err
+	}
+	s.templateEngine.Store(engine)
+	return nil
+}
+
+// newTemplateEngine 创建模板引擎并加载所有模板
+// 初始化和重新加载时都通过它创建引擎，模板文件和自定义变量配置文件每次重新读取
+func (s *Sender) newTemplateEngine() (*template.Engine, error) {
+	// 确定自定义变量配置文件，未指定时使用当前目录下的template.yml
+	configPath, err := template.ResolveConfigPath(s.config.VarsFile)
+	if err != nil {
+		return nil, err
+	}
+	engine := template.NewEngineWithOutput(configPath, s.config.Verbose, s.log.Writer())
+
+	// 优先使用命令行指定的消息内容，其次是结构化模板文件
+	if s.config.Message != "" {
+		engine.LoadTemplate("message", s.config.Message)
+	} else if s.config.TemplateFile != "" {
+		if err := engine.LoadStructuredTemplate("message", s.config.TemplateFile); err != nil {
+			return nil, fmt.Errorf("加载模板文件失败: %w", err)
+		}
+	}
+
+	severityTemplates, err := s.config.SeverityTemplateMap()
+	if err != nil {
+		return nil, err
+	}
+	for severity, tmpl := range severityTemplates {
+		engine.LoadTemplate(severityTemplateName(severity), tmpl)
+	}
+	for _, tpl := range s.sdElements {
+		for i, name := range tpl.templates {
+			if name != "" {
+				engine.LoadTemplate(name, tpl.element.Params[i].Value)
+			}
+		}
+	}
+	for _, f := range s.fields {
+		if f.template != "" {
+			engine.LoadTemplate(f.template, f.value)
+		}
+	}
+	if s.procIDTpl {
+		engine.LoadTemplate(procIDTemplateName, s.config.ProcID)
+	}
+	return engine, nil
+}
+
+// engine 返回当前使用的模板引擎，未配置模板时为nil
+func (s *Sender) engine() *template.Engine {
+	return s.templateEngine.Load()
+}
+
+// severityTemplateName 返回Severity专用模板在引擎中的名称
+func severityTemplateName(severity int) string {
+	return "severity:" + syslog.GetSeverityName(severity)
+}
+
+// pickSeverity 按Severity分布随机选择本条消息的Severity
+// 未配置分布时返回配置的固定Severity
+func (s *Sender) pickSeverity() int {
+	return pickWeighted(s.severities, s.severityTotal, s.config.Severity)
+}
+
+// waitNext 等待直到允许发送下一条消息
+// 配置了消息间隔分布时按随机间隔等待，否则按EPS匀速等待。
+// 所有工作协程共用同一个限制器，总速率不随并发数增加（见RateLimiter）
+func (s *Sender) waitNext() {
+	if s.arrivals != nil {
+		s.arrivals.Wait()
+		// 间隔分布中的突发快于上限时顺延到限速器，保持间隔的形状但不超过上限
+		if s.ceiling != nil {
+			s.ceiling.Wait()
+		}
+		return
+	}
+	// 空输出不限速时没有速率限制器
+	if s.rateLimiter != nil {
+		s.rateLimiter.Wait()
+	}
+}
+
+// produceTickets 负载生成模式下的票据生产协程
+// 功能：
+//   - 按目标速率（或消息间隔分布）产生发送票据，与发送耗时无关
+//   - 票据队列已满（工作协程跟不上）时不阻塞，计为错过的票据
+//   - 结束时关闭票据队列，通知工作协程退出
+func (s *Sender) produceTickets() {
+	defer s.wg.Done()
+	defer close(s.tickets)
+
+	for {
+		s.waitNext()
+		if s.ctx.Err() != nil {
+			return
+		}
+		atomic.AddInt64(&s.stats.Offered, 1)
+		select {
+		case s.tickets <- struct{}{}:
+		default:
+			atomic.AddInt64(&s.stats.Missed, 1)
+		}
+	}
+}
+
+// acquire 获取发送下一条消息的许可
+// loadgen模式下从票据队列领取票据，否则按速率限制等待
+// 返回值：
+//   - bool: 发送器已停止或票据队列已关闭时返回false
+func (s *Sender) acquire() bool {
+	if s.tickets == nil {
+		s.waitNext()
+		return true
+	}
+	select {
+	case <-s.ctx.Done():
+		return false
+	case _, ok := <-s.tickets:
+		return ok
+	}
+}
+
+// reportTooLarge 记录一条超过数据报上限的消息
+// 计入TooLarge统计，并在第一次出现时提示改用TCP
+func (s *Sender) reportTooLarge(size int) {
+	atomic.AddInt64(&s.stats.TooLarge, 1)
+	s.tooLargeOnce.Do(func() {
+		s.log.Warn(fmt.Sprintf("消息长度 %d 字节超过UDP数据报上限，该消息未发送（同类错误不再提示），超长消息建议改用TCP (-p tcp)", size),
+			"size", size)
+	})
+}
+
 // generateMessage 生成Syslog消息
 // 功能：
 //   - 根据配置生成消息内容
-//   - 支持从命令行参数、模板文件或数据文件生成消息
+//   - 支持从命令行参数、结构化模板文件或数据文件生成消息
 //   - 自动处理消息格式和变量替换
 //
 // 返回值：
@@ -200,25 +764,20 @@ func (s *Sender) generateMessage() (*syslog.Message, error) {
 	var content string
 	var err error
 
-	// 优先使用命令行指定的消息内容
-	if s.config.Message != "" {
-		// 使用共享的模板引擎
-		if s.templateEngine == nil {
-			// 检查当前目录下是否存在template.yml
-			configPath := "template.yml"
-			if _, err := os.Stat(configPath); os.IsNotExist(err) {
-				configPath = "" // 如果文件不存在，使用空字符串
-			}
-			s.templateEngine = template.NewEngine(configPath, s.config.Verbose)
-			s.templateEngine.LoadTemplate("message", s.config.Message)
+	// 选择本条消息的Severity，配置了专用模板时优先使用
+	severity := s.pickSeverity()
+	if s.severityTpls[severity] {
+		content, err = s.engine().GenerateMessage(severityTemplateName(severity))
+		if err != nil {
+			return nil, fmt.Errorf("处理消息变量失败: %w", err)
 		}
-
-		// 处理消息中的变量
-		content, err = s.templateEngine.GenerateMessage("message")
+	} else if s.config.Message != "" || s.config.TemplateFile != "" {
+		// 命令行消息或结构化模板文件已在initTemplates中加载为"message"模板
+		content, err = s.engine().GenerateMessage("message")
 		if err != nil {
 			return nil, fmt.Errorf("处理消息变量失败: %w", err)
 		}
-	} else if s.config.DataFile != "" {
+	} else if s.dataReader != nil {
 		// 如果有数据文件，从文件读取
 		content, err = s.readFromDataFile()
 		if err != nil {
@@ -229,20 +788,46 @@ func (s *Sender) generateMessage() (*syslog.Message, error) {
 		content = fmt.Sprintf("Test message from syslog_go by saturn at %s", time.Now().Format(time.RFC3339))
 	}
 
-	// 获取主机名
-	hostname := "localhost"
-	if h, err := os.Hostname(); err == nil {
-		hostname = h
+	// 消息开头的 {{PRI:facility.severity}} 指令覆盖本条消息的优先级，指令不随消息发送
+	priority := s.pickFacility()*8 + severity
+	if pri, rest, ok, err := template.CutPriDirective(content); err != nil {
+		return nil, err
+	} else if ok {
+		priority, content = pri, rest
+	}
+	content = template.FillLevel(content, priority%8)
+
+	// 配置的结构化数据在origin之前，按配置顺序输出
+	var sd string
+	if len(s.sdElements) > 0 {
+		if sd, err = s.buildStructuredData(); err != nil {
+			return nil, err
+		}
+		sd = template.FillLevel(sd, priority%8)
 	}
 
 	// 创建Syslog消息
-	msg := syslog.NewMessage(
-		s.config.GetPriority(),
-		hostname,
+	msg := syslog.NewMessageWithClock(
+		s.clock,
+		priority,
+		s.hostname,
 		"syslog_go",
 		content,
-		"",
+		s.config.GetSyslogFormat(),
 	)
+	msg.TimeLayout = s.config.TimeLayout
+	if len(s.fields) > 0 {
+		if msg.Fields, err = s.buildFields(priority % 8); err != nil {
+			return nil, err
+		}
+	}
+	msg.AddStructuredData(sd)
+	msg.AddStructuredData(s.originSD)
+	pid, err := s.nextProcID()
+	if err != nil {
+		return nil, err
+	}
+	msg.SetPID(pid)
 
 	return msg, nil
 }
@@ -259,72 +844,188 @@ func (s *Sender) generateMessage() (*syslog.Message, error) {
 // 返回值：
 //   - error: 发送过程中的错误，如果发送成功则为nil
 func (s *Sender) sendMessage(msg *syslog.Message) error {
-	// 从连接池获取连接
-	conn, err := s.connPool.Get()
-	if err != nil {
-		if s.config.Verbose {
-			fmt.Printf("获取连接失败: %v\n", err)
+	buf := s.encodeMessage(msg)
+	defer putBuffer(buf)
+	data := *buf
+
+	if s.config.DryRun {
+		return s.writeDryRun(data)
+	}
+
+	// 序列化后发送到每个目标，任一目标失败时整条消息计为失败，返回第一个错误
+	var firstErr error
+	for _, t := range s.targets {
+		err := s.writeTo(t, data)
+		if err == nil || firstErr != nil {
+			continue
+		}
+		if isMessageTooLarge(err) {
+			s.reportTooLarge(len(data))
+			err = fmt.Errorf("消息长度 %d 字节: %w", len(data), ErrMessageTooLarge)
 		}
-		return fmt.Errorf("获取连接失败: %w", err)
+		firstErr = err
 	}
-	defer s.connPool.Put(conn)
+	return firstErr
+}
 
-	// 序列化并发送消息
-	data := msg.Bytes()
-	_, err = conn.Write(data)
-	if err != nil {
-		return fmt.Errorf("写入数据失败: %w", err)
+// setWriteDeadline 为本次写入设置截止时间，避免连接阻塞时工作协程被永久挂起
+// 超时时长为配置的Timeout，为0时不设置
+func (s *Sender) setWriteDeadline(conn net.Conn) {
+	if s.config.Timeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(s.config.Timeout))
 	}
+}
+
+// encodeMessage 将消息序列化到从池中取出的缓冲区
+// 按换行策略追加消息分隔符，已以换行结尾的消息不重复追加；
+// 八位组计数分帧时在消息前加 "字节数 空格"（RFC6587），不追加换行；
+// 缓冲区在所有目标写入完成后由调用方用putBuffer放回
+func (s *Sender) encodeMessage(msg *syslog.Message) *[]byte {
+	buf := getBuffer()
+	if s.config.OctetFraming() {
+		*buf = appendOctetFrame(*buf, msg)
+		return buf
+	}
+	data := msg.AppendFormat(*buf)
+	if s.config.ShouldAppendNewline() && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	*buf = data
+	return buf
+}
 
+// appendOctetFrame 将消息按八位组计数分帧追加到dst
+// 消息先序列化到dst末尾以得到字节数，再把长度前缀插入到消息之前
+func appendOctetFrame(dst []byte, msg *syslog.Message) []byte {
+	start := len(dst)
+	dst = msg.AppendFormat(dst)
+	var prefix [24]byte
+	p := strconv.AppendInt(prefix[:0], int64(len(dst)-start), 10)
+	p = append(p, ' ')
+	dst = append(dst, p...)
+	copy(dst[start+len(p):], dst[start:len(dst)-len(p)])
+	copy(dst[start:], p)
+	return dst
+}
+
+// writeDryRun 演练模式下将消息写到输出（默认为标准输出）
+// 输出的字节与实际发送的内容一致；不以换行结尾的消息（如UDP数据报）
+// 额外补一个换行以区分消息边界
+func (s *Sender) writeDryRun(data []byte) error {
+	s.dryRunMu.Lock()
+	defer s.dryRunMu.Unlock()
+
+	if _, err := s.stdout.Write(data); err != nil {
+		return fmt.Errorf("写入演练输出失败: %w", err)
+	}
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		s.stdout.Write([]byte("\n"))
+	}
 	return nil
 }
 
-// readFromDataFile 从数据文件读取内容
+// sendBatch 生成并批量发送一批消息
 // 功能：
-//   - 按行读取数据文件
-//   - 维护当前读取位置，支持循环读取
-//   - 返回下一行数据
-//
-// 返回值：
-//   - string: 读取的行内容
-//   - error: 读取过程中的错误
-func (s *Sender) readFromDataFile() (string, error) {
-	// 如果文件未打开，则打开文件
-	if s.dataFile == nil {
-		file, err := os.Open(s.config.DataFile)
+//   - 按速率限制逐条生成最多BatchSize条消息
+//   - 连接支持BatchWriter时一次写入整批消息，否则逐条写入
+//   - 按实际发送条数更新统计
+func (s *Sender) sendBatch() {
+	batch := make([][]byte, 0, s.config.BatchSize)
+	bufs := make([]*[]byte, 0, s.config.BatchSize)
+	defer func() {
+		for _, buf := range bufs {
+			putBuffer(buf)
+		}
+	}()
+	for len(batch) < s.config.BatchSize && s.ctx.Err() == nil {
+		if !s.acquire() {
+			break
+		}
+
+		message, err := s.nextMessage()
+		if errors.Is(err, errAllSent) {
+			break
+		}
 		if err != nil {
 			if s.config.Verbose {
-				fmt.Printf("打开数据文件失败: %v\n", err)
+				s.log.Error("生成消息失败: "+err.Error(), "error", err.Error())
 			}
-			return "", fmt.Errorf("打开数据文件失败: %w", err)
+			atomic.AddInt64(&s.stats.Failed, 1)
+			continue
+		}
+		buf := s.encodeMessage(message)
+		bufs = append(bufs, buf)
+		batch = append(batch, *buf)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	if s.config.DryRun {
+		for _, data := range batch {
+			s.writeDryRun(data)
 		}
-		s.dataFile = file
-		s.dataScanner = bufio.NewScanner(file)
+		atomic.AddInt64(&s.stats.Sent, int64(len(batch)))
+		return
 	}
 
-	// 如果已到文件末尾，重新开始读取
-	if !s.dataScanner.Scan() {
-		if err := s.dataScanner.Err(); err != nil {
-			return "", fmt.Errorf("读取数据文件失败: %w", err)
+	// 每个目标分别写入整批消息，只有所有目标都写入成功的消息计为已发送
+	n, err := len(batch), error(nil)
+	for _, t := range s.targets {
+		written, writeErr := s.writeBatchTo(t, batch)
+		if written < n {
+			n, err = written, writeErr
 		}
-		// 重置文件指针到开头
-		if _, err := s.dataFile.Seek(0, 0); err != nil {
-			return "", fmt.Errorf("重置文件指针失败: %w", err)
+		if writeErr != nil && s.config.Verbose && len(s.targets) > 1 {
+			s.log.Error(fmt.Sprintf("批量发送到 %s 失败（已发送 %d/%d 条）: %v", t.address, written, len(batch), writeErr),
+				"target", t.address, "sent", written, "batch", len(batch), "error", writeErr.Error())
 		}
-		s.dataScanner = bufio.NewScanner(s.dataFile)
-		if !s.dataScanner.Scan() {
-			return "", fmt.Errorf("数据文件为空")
+	}
+	atomic.AddInt64(&s.stats.Sent, int64(n))
+	if err != nil && n < len(batch) && isMessageTooLarge(err) {
+		s.reportTooLarge(len(batch[n]))
+	}
+	if err != nil {
+		atomic.AddInt64(&s.stats.Failed, int64(len(batch)-n))
+		if s.config.Verbose {
+			s.log.Error(fmt.Sprintf("批量发送失败（已发送 %d/%d 条）: %v", n, len(batch), err),
+				"sent", n, "batch", len(batch), "error", err.Error())
 		}
+	} else if s.config.Verbose {
+		s.log.Info(fmt.Sprintf("批量发送 %d 条消息", n), "sent", n)
+	}
+}
+
+// readFromDataFile 从数据文件读取内容
+// 功能：
+//   - 按行读取数据文件，配置了多个文件时按顺序或按权重在文件间切换
+//   - 维护每个文件的读取位置，读到末尾时循环读取
+//   - 返回下一行数据
+//
+// 返回值：
+//   - string: 读取的行内容
+//   - error: 读取过程中的错误
+func (s *Sender) readFromDataFile() (string, error) {
+	content, err := s.dataReader.next()
+	if err != nil && s.config.Verbose {
+		s.log.Error("读取数据文件失败: "+err.Error(), "error", err.Error())
 	}
+	return content, err
+}
 
-	return s.dataScanner.Text(), nil
+// finish 所有发送工作协程退出后调用
+// 关闭done通知统计监控结束，并取消上下文停止票据生产、EPS调节等其余后台协程，
+// 使Start在发送结束后立即返回
+func (s *Sender) finish() {
+	s.doneOnce.Do(func() { close(s.done) })
+	s.cancel()
 }
 
 // statsMonitor 统计监控协程
 // 功能：
 //   - 定期收集和输出发送统计信息
 //   - 监控发送性能和错误情况
-//   - 在收到停止信号时优雅退出
+//   - 在收到停止信号或所有发送工作协程退出时优雅退出
 func (s *Sender) statsMonitor() {
 	defer s.wg.Done()
 
@@ -337,6 +1038,9 @@ func (s *Sender) statsMonitor() {
 		case <-s.ctx.Done():
 			// 收到停止信号，退出协程
 			return
+		case <-s.done:
+			// 发送工作协程已全部退出，不再等待持续时间超时
+			return
 		case <-ticker.C:
 			// 定时输出统计信息
 			s.printStats()
@@ -348,12 +1052,7 @@ func (s *Sender) statsMonitor() {
 // 功能：
 //   - 计算并展示实时发送速率
 //   - 输出成功、失败、运行时间等统计数据
-//   - 仅在verbose模式下输出详细信息
 func (s *Sender) printStats() {
-	if !s.config.Verbose {
-		return
-	}
-
 	// 使用读锁保护并发访问
 	s.stats.mutex.RLock()
 	defer s.stats.mutex.RUnlock()
@@ -365,13 +1064,21 @@ func (s *Sender) printStats() {
 	rate := float64(sent) / elapsed.Seconds()
 
 	// 格式化输出统计信息
-	fmt.Printf("[统计] 已发送: %d, 失败: %d, 速率: %.2f/s, 运行时间: %v\n",
-		sent, failed, rate, elapsed.Truncate(time.Second))
+	s.log.Info(fmt.Sprintf("[统计] 已发送: %d, 失败: %d, 速率: %.2f/s, 运行时间: %v", sent, failed, rate, elapsed.Truncate(time.Second)),
+		"event", "stats", "sent", sent, "failed", failed, "eps", rate, "elapsed", elapsed)
+	if s.config.LoadGen {
+		offered := atomic.LoadInt64(&s.stats.Offered)
+		offeredRate := float64(offered) / elapsed.Seconds()
+		missed := atomic.LoadInt64(&s.stats.Missed)
+		s.log.Info(fmt.Sprintf("[负载] 提供: %.2f/s, 实际: %.2f/s, 错过: %d", offeredRate, rate, missed),
+			"event", "load", "offered_eps", offeredRate, "eps", rate, "missed", missed)
+	}
 }
 
 // printFinalStats 打印最终统计
+// 除静默模式外总是输出，不依赖verbose
 func (s *Sender) printFinalStats() {
-	if !s.config.Verbose {
+	if s.config.Quiet {
 		return
 	}
 
@@ -380,29 +1087,212 @@ func (s *Sender) printFinalStats() {
 	failed := atomic.LoadInt64(&s.stats.Failed)
 	rate := float64(sent) / elapsed.Seconds()
 
-	fmt.Printf("\n=== 发送完成 ===\n")
-	fmt.Printf("总发送数: %d\n", sent)
-	fmt.Printf("失败数: %d\n", failed)
-	fmt.Printf("成功率: %.2f%%\n", float64(sent)/float64(sent+failed)*100)
-	fmt.Printf("平均速率: %.2f/s\n", rate)
-	fmt.Printf("总耗时: %v\n", elapsed.Truncate(time.Millisecond))
+	// JSON格式下输出一个包含全部统计字段的事件
+	if s.log.JSON() {
+		s.logFinalStats()
+		return
+	}
+
+	fmt.Fprintf(s.stdout, "\n=== 发送完成 ===\n")
+	fmt.Fprintf(s.stdout, "总发送数: %d\n", sent)
+	fmt.Fprintf(s.stdout, "失败数: %d\n", failed)
+	if sent+failed > 0 {
+		fmt.Fprintf(s.stdout, "成功率: %.2f%%\n", float64(sent)/float64(sent+failed)*100)
+	}
+	fmt.Fprintf(s.stdout, "平均速率: %.2f/s\n", rate)
+	if target := s.targetEPS(); target > 0 {
+		fmt.Fprintf(s.stdout, "目标速率: %d/s (达成 %.1f%%)\n", target, rate/float64(target)*100)
+		// 按尝试发送数判断，发送失败不是增加并发能解决的
+		if float64(sent+failed)/elapsed.Seconds() < float64(target)*shortfallRatio && !s.config.LoadGen {
+			fmt.Fprintf(s.stdout, "警告: 实际速率未达到目标速率，可增加 --concurrency 或 --max-concurrency\n")
+		}
+	}
+	if s.epsTolerance > 0 && s.configuredEPS() > 0 {
+		_, deviation := s.epsDeviation()
+		status := "在容差内"
+		if deviation > s.epsTolerance {
+			status = "超出容差"
+		}
+		fmt.Fprintf(s.stdout, "速率偏差: %.1f%% (容差 %g%%，%s)\n", deviation*100, s.epsTolerance*100, status)
+	}
+	if spawned := int(atomic.LoadInt32(&s.spawned)); spawned > s.config.Concurrency {
+		fmt.Fprintf(s.stdout, "工作协程: %d (自动扩容自 %d)\n", spawned, s.config.Concurrency)
+	}
+	if s.replay != nil {
+		fmt.Fprintf(s.stdout, "重放: %d 轮 x %d 条\n", s.replay.rounds, len(s.replay.messages))
+	}
+	if s.total > 0 {
+		fmt.Fprintf(s.stdout, "计划总量: %d 条 / %v (%.2f/s)，实际耗时为计划的 %.1f%%\n",
+			s.total, s.config.Over, s.config.TotalRate(), elapsed.Seconds()/s.config.Over.Seconds()*100)
+	}
+	if sink := s.nullSink(); sink != nil {
+		fmt.Fprintf(s.stdout, "空输出: 丢弃 %d 次写入 (%d 字节)", atomic.LoadInt64(&sink.writes), atomic.LoadInt64(&sink.bytes))
+		if s.rateLimiter == nil {
+			fmt.Fprintf(s.stdout, "，不限速，平均速率即消息生成的最大吞吐量")
+		}
+		fmt.Fprintln(s.stdout)
+	}
+	if s.config.TargetReceivedEPS > 0 && s.rateLimiter != nil {
+		fmt.Fprintf(s.stdout, "自动调节后的EPS: %d (目标接收速率: %d/s)\n", s.rateLimiter.GetRate(), s.config.TargetReceivedEPS)
+	}
+	if tooLarge := atomic.LoadInt64(&s.stats.TooLarge); tooLarge > 0 {
+		fmt.Fprintf(s.stdout, "超过UDP数据报上限: %d\n", tooLarge)
+	}
+	if s.config.LoadGen {
+		offered := atomic.LoadInt64(&s.stats.Offered)
+		missed := atomic.LoadInt64(&s.stats.Missed)
+		fmt.Fprintf(s.stdout, "提供负载: %d (%.2f/s)\n", offered, float64(offered)/elapsed.Seconds())
+		fmt.Fprintf(s.stdout, "错过票据: %d\n", missed)
+		if missed > 0 {
+			fmt.Fprintf(s.stdout, "警告: 实际发送速率未能跟上目标速率，可增加 --concurrency 或检查网络\n")
+		}
+	}
+	if s.config.EnableStats && atomic.LoadInt64(&s.stats.latency.count) > 0 {
+		fmt.Fprintf(s.stdout, "写入延迟: p50=%v p90=%v p99=%v max=%v\n",
+			s.stats.latency.percentile(0.50), s.stats.latency.percentile(0.90),
+			s.stats.latency.percentile(0.99), s.stats.latency.maxLatency())
+	}
+	if len(s.severities) > 0 {
+		s.printMix("Severity分布", s.severities, s.stats.severityCounts[:], syslog.GetSeverityName)
+	}
+	if len(s.facilities) > 0 {
+		s.printMix("Facility分布", s.facilities, s.stats.facilityCounts[:], syslog.GetFacilityName)
+	}
+	if len(s.targets) > 1 {
+		fmt.Fprintf(s.stdout, "各目标:\n")
+		for _, t := range s.targetStats() {
+			fmt.Fprintf(s.stdout, "  %s: 已发送 %d, 失败 %d\n", t.Address, t.Sent, t.Failed)
+		}
+	}
+	if s.config.ReconnectEvery > 0 {
+		dials, retired := s.churn()
+		fmt.Fprintf(s.stdout, "连接重建: 每 %d 条消息重建一次，共关闭 %d 个连接，建立 %d 个连接\n", s.config.ReconnectEvery, retired, dials)
+	}
+	if s.config.Verbose {
+		if c := s.compression(); c != nil {
+			fmt.Fprintf(s.stdout, "压缩: %d 字节 -> %d 字节 (压缩后为原始大小的 %.1f%%)\n",
+				atomic.LoadInt64(&c.RawBytes), atomic.LoadInt64(&c.CompressedBytes), c.Ratio()*100)
+		}
+	}
+	fmt.Fprintf(s.stdout, "总耗时: %v\n", elapsed.Truncate(time.Millisecond))
+}
+
+// logFinalStats 以一个结构化事件输出最终统计
+func (s *Sender) logFinalStats() {
+	snap := s.Snapshot()
+	fields := []any{
+		"event", "final_stats",
+		"sent", snap.Sent,
+		"failed", snap.Failed,
+		"eps", snap.EPS,
+		"duration", snap.Duration,
+		"too_large", snap.TooLarge,
+		"workers", atomic.LoadInt32(&s.spawned),
+	}
+	if target := s.targetEPS(); target > 0 {
+		fields = append(fields, "target_eps", target)
+	}
+	if s.config.TargetReceivedEPS > 0 && s.rateLimiter != nil {
+		fields = append(fields, "tuned_eps", s.rateLimiter.GetRate(), "target_received_eps", s.config.TargetReceivedEPS)
+	}
+	if s.replay != nil {
+		fields = append(fields, "rounds", s.replay.rounds, "round_size", len(s.replay.messages))
+	}
+	if s.epsTolerance > 0 && s.configuredEPS() > 0 {
+		_, deviation := s.epsDeviation()
+		fields = append(fields, "eps_deviation", deviation, "eps_tolerance", s.epsTolerance)
+	}
+	if s.total > 0 {
+		fields = append(fields, "total", s.total, "over", s.config.Over, "total_eps", s.config.TotalRate())
+	}
+	if s.config.LoadGen {
+		fields = append(fields, "offered", snap.Offered, "missed", snap.Missed)
+	}
+	if s.config.EnableStats && atomic.LoadInt64(&s.stats.latency.count) > 0 {
+		fields = append(fields, "latency_p50", snap.LatencyP50, "latency_p90", snap.LatencyP90,
+			"latency_p99", snap.LatencyP99, "latency_max", snap.LatencyMax)
+	}
+	if s.config.ReconnectEvery > 0 {
+		dials, retired := s.churn()
+		fields = append(fields, "reconnect_every", s.config.ReconnectEvery, "dials", dials, "reconnects", retired)
+	}
+	if sink := s.nullSink(); sink != nil {
+		fields = append(fields, "null_writes", sink.writes, "null_bytes", sink.bytes)
+	}
+	if c := s.compression(); c != nil {
+		fields = append(fields, "raw_bytes", c.RawBytes, "compressed_bytes", c.CompressedBytes)
+	}
+	if len(s.severities) > 0 {
+		fields = append(fields, "severities", snap.Severities)
+	}
+	if len(s.facilities) > 0 {
+		fields = append(fields, "facilities", snap.Facilities)
+	}
+	if len(snap.Targets) > 1 {
+		fields = append(fields, "targets", snap.Targets)
+	}
+	s.log.Info("发送完成", fields...)
 }
 
 // Stop 停止发送
 // 功能：
-//   - 通过context取消信号停止所有工作协程
-//   - 关闭连接池释放资源
+//   - 通过context取消信号停止所有工作协程，并等待它们写完手头的批次
+//   - 排空并关闭连接池中的连接，保证已写出的消息都能被对端读到
 //   - 关闭数据文件
 //   - 确保资源完全释放和协程优雅退出
 func (s *Sender) Stop() {
 	s.cancel()
-	s.connPool.Close()
+	// 等待发送协程写完手头的批次（包括不足一批的剩余消息），再关闭连接
+	s.wg.Wait()
+	s.closeTargets()
 	// 关闭数据文件
-	if s.dataFile != nil {
-		s.dataFile.Close()
-		s.dataFile = nil
-		s.dataScanner = nil
+	if s.dataReader != nil {
+		s.dataReader.Close()
+	}
+}
+
+// SetClock 设置生成消息时间戳使用的时钟，需要在Start之前调用
+// 传入固定时间的时钟（如 syslog.FixedClock）可以得到可重复的输出，nil恢复为系统时间
+func (s *Sender) SetClock(clock syslog.Clock) {
+	if clock == nil {
+		clock = syslog.SystemClock
+	}
+	s.clock = clock
+}
+
+// Logger 返回发送器使用的日志，调用方可以用它输出与发送器格式一致的日志
+func (s *Sender) Logger() *logging.Logger {
+	return s.log
+}
+
+// Snapshot 获取当前的统计快照，发送过程中也可以调用
+func (s *Sender) Snapshot() *StatsSnapshot {
+	s.stats.mutex.RLock()
+	defer s.stats.mutex.RUnlock()
+
+	end := s.stats.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	snapshot := &StatsSnapshot{
+		Sent:       atomic.LoadInt64(&s.stats.Sent),
+		Failed:     atomic.LoadInt64(&s.stats.Failed),
+		Offered:    atomic.LoadInt64(&s.stats.Offered),
+		Missed:     atomic.LoadInt64(&s.stats.Missed),
+		TooLarge:   atomic.LoadInt64(&s.stats.TooLarge),
+		Targets:    s.targetStats(),
+		Severities: s.severityCounts(),
+		Facilities: s.facilityCounts(),
+		Duration:   end.Sub(s.stats.StartTime),
+		LatencyP50: s.stats.latency.percentile(0.50),
+		LatencyP90: s.stats.latency.percentile(0.90),
+		LatencyP99: s.stats.latency.percentile(0.99),
+		LatencyMax: s.stats.latency.maxLatency(),
+	}
+	if snapshot.Duration > 0 {
+		snapshot.EPS = float64(snapshot.Sent) / snapshot.Duration.Seconds()
 	}
+	return snapshot
 }
 
 // GetStats 获取统计信息
@@ -413,6 +1303,9 @@ func (s *Sender) GetStats() *Statistics {
 	return &Statistics{
 		Sent:      atomic.LoadInt64(&s.stats.Sent),
 		Failed:    atomic.LoadInt64(&s.stats.Failed),
+		Offered:   atomic.LoadInt64(&s.stats.Offered),
+		Missed:    atomic.LoadInt64(&s.stats.Missed),
+		TooLarge:  atomic.LoadInt64(&s.stats.TooLarge),
 		StartTime: s.stats.StartTime,
 		EndTime:   s.stats.EndTime,
 	}
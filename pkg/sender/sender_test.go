@@ -0,0 +1,121 @@
+package sender
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"syslog_go/pkg/config"
+)
+
+// TestSharedRateLimiterAccuracy 多个工作协程共享限制器时，整体速率仍为设置的EPS
+// 使用空输出，避免网络和连接有效性探测影响速率
+func TestSharedRateLimiterAccuracy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("需要运行5秒")
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Target = "127.0.0.1:514"
+	cfg.Format = "rfc5424"
+	cfg.Message = "rate accuracy test"
+	cfg.NullSink = true
+	cfg.EPS = 5000
+	cfg.Concurrency = 10
+	cfg.Duration = 5 * time.Second
+	cfg.Quiet = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("配置验证失败: %v", err)
+	}
+
+	s, err := NewSenderWithOutput(cfg, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatalf("创建发送器失败: %v", err)
+	}
+	defer s.Stop()
+
+	snapshot, err := s.Start()
+	if err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+
+	deviation := math.Abs(snapshot.EPS-float64(cfg.EPS)) / float64(cfg.EPS)
+	if deviation > 0.05 {
+		t.Fatalf("实际速率 %.2f EPS（发送 %d 条，用时 %v），与目标 %d EPS 相差 %.1f%%，超过5%%",
+			snapshot.EPS, snapshot.Sent, snapshot.Duration, cfg.EPS, deviation*100)
+	}
+}
+
+// startLineCounter 启动本地TCP接收端，按LF分帧统计收到的消息数
+func startLineCounter(t *testing.T) (string, *int64) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动TCP监听失败: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var count int64
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					atomic.AddInt64(&count, 1)
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), &count
+}
+
+// TestBatchedTCPFlushesPartialBatch 发送的消息数不是批量大小的整数倍时，最后不足一批的消息同样送达
+func TestBatchedTCPFlushesPartialBatch(t *testing.T) {
+	addr, count := startLineCounter(t)
+
+	cfg := config.DefaultConfig()
+	cfg.Target = addr
+	cfg.Protocol = "tcp"
+	cfg.Format = "rfc3164"
+	cfg.Message = "batched tail"
+	cfg.BatchSize = 10
+	cfg.Concurrency = 2
+	cfg.Total = 103
+	cfg.Over = 200 * time.Millisecond
+	cfg.Quiet = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("配置验证失败: %v", err)
+	}
+
+	s, err := NewSenderWithOutput(cfg, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatalf("创建发送器失败: %v", err)
+	}
+	snapshot, err := s.Start()
+	if err != nil {
+		s.Stop()
+		t.Fatalf("发送失败: %v", err)
+	}
+	// Stop排空并关闭连接后，接收端应已读到所有消息
+	s.Stop()
+	if snapshot.Sent != cfg.Total {
+		t.Fatalf("统计发送 %d 条，期望 %d 条", snapshot.Sent, cfg.Total)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt64(count) < cfg.Total && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt64(count); got != cfg.Total {
+		t.Fatalf("接收端收到 %d 条，期望 %d 条", got, cfg.Total)
+	}
+}
@@ -0,0 +1,102 @@
+package sender
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// 速率不足检测参数：每个窗口比较一次实际速率与目标速率，
+// 连续shortfallWindows个窗口低于目标的shortfallRatio时判定为持续不足
+const (
+	shortfallWindow  = time.Second
+	shortfallWindows = 3
+	shortfallRatio   = 0.9
+)
+
+// watchShortfall 速率不足检测协程
+// 发送耗时超过速率间隔时（如单个工作协程配合很高的EPS），实际速率会低于目标而没有任何提示。
+// 持续不足时配置了 --max-concurrency 则逐个增加工作协程直到上限，否则（或已达上限）输出一次告警
+func (s *Sender) watchShortfall() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(shortfallWindow)
+	defer ticker.Stop()
+
+	last := s.attempted()
+	lastTime := time.Now()
+	below := 0
+	warned := false
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		count := s.attempted()
+		now := time.Now()
+		achieved := float64(count-last) / now.Sub(lastTime).Seconds()
+		last, lastTime = count, now
+
+		target := s.rateLimiter.GetRate()
+		if achieved >= float64(target)*shortfallRatio {
+			below = 0
+			continue
+		}
+		if below++; below < shortfallWindows {
+			continue
+		}
+		below = 0
+
+		workers := atomic.LoadInt32(&s.workers)
+		if int(workers) < s.config.MaxConcurrency {
+			s.addWorker()
+			s.log.Info(fmt.Sprintf("[扩容] 实际速率 %.2f/s 低于目标 %d/s，工作协程 %d -> %d", achieved, target, workers, workers+1),
+				"event", "scale_up", "achieved_eps", achieved, "target_eps", target, "workers_before", workers, "workers_after", workers+1)
+			continue
+		}
+		if warned {
+			continue
+		}
+		warned = true
+		hint := "可增加 --concurrency 或使用 --max-concurrency 自动扩容"
+		if s.config.MaxConcurrency > 0 {
+			hint = "已达到 --max-concurrency 上限，可调高上限"
+		}
+		s.log.Warn(fmt.Sprintf("实际速率 %.2f/s 持续低于目标 %d/s（%d 个工作协程），%s", achieved, target, workers, hint),
+			"achieved_eps", achieved, "target_eps", target, "workers", workers)
+	}
+}
+
+// attempted 返回已尝试发送（成功和失败）的消息总数
+// 失败的发送同样消耗了工作协程的时间，速率不足按尝试数判断
+func (s *Sender) attempted() int64 {
+	return atomic.LoadInt64(&s.stats.Sent) + atomic.LoadInt64(&s.stats.Failed)
+}
+
+// addWorker 增加一个发送工作协程
+func (s *Sender) addWorker() {
+	id := int(atomic.AddInt32(&s.spawned, 1)) - 1
+	atomic.AddInt32(&s.workers, 1)
+	s.wg.Add(1)
+	go s.sendWorker(id)
+}
+
+// poolSize 每个目标连接池的容量，允许自动扩容时按上限预留，使新增的工作协程也能复用连接
+func (s *Sender) poolSize() int {
+	if s.config.MaxConcurrency > s.config.Concurrency {
+		return s.config.MaxConcurrency
+	}
+	return s.config.Concurrency
+}
+
+// targetEPS 用于和实际速率对比的目标速率
+// 按消息间隔分布发送或按服务端反馈自动调节EPS时没有固定的目标，返回0
+func (s *Sender) targetEPS() int {
+	if s.rateLimiter == nil || s.arrivals != nil || s.config.TargetReceivedEPS > 0 {
+		return 0
+	}
+	return int(s.rateLimiter.GetRate())
+}
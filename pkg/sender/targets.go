@@ -0,0 +1,137 @@
+package sender
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// TargetStats 单个目标的发送统计
+type TargetStats struct {
+	Address string `json:"address"` // 目标地址
+	Sent    int64  `json:"sent"`    // 该目标成功发送的消息数量
+	Failed  int64  `json:"failed"`  // 该目标发送失败的消息数量
+}
+
+// sendTarget 扇出发送的一个目标
+// 每个目标有独立的连接池和计数，一个目标不可用不影响其他目标
+type sendTarget struct {
+	address string
+	pool    *ConnectionPool
+	sent    int64 // 原子操作更新
+	failed  int64 // 原子操作更新
+}
+
+// writeTo 向一个目标写入序列化后的消息
+func (s *Sender) writeTo(t *sendTarget, data []byte) error {
+	conn, err := t.pool.Get()
+	if err != nil {
+		atomic.AddInt64(&t.failed, 1)
+		if s.config.Verbose {
+			s.log.Error(fmt.Sprintf("获取连接失败（%s）: %v", t.address, err), "target", t.address, "error", err.Error())
+		}
+		return fmt.Errorf("获取连接失败: %w", err)
+	}
+
+	s.setWriteDeadline(conn)
+	start := time.Now()
+	_, err = conn.Write(data)
+	s.stats.latency.record(time.Since(start))
+	releaseConn(t.pool, conn, 1, err)
+	if err != nil {
+		atomic.AddInt64(&t.failed, 1)
+		return fmt.Errorf("写入数据失败: %w", err)
+	}
+	atomic.AddInt64(&t.sent, 1)
+	return nil
+}
+
+// writeBatchTo 向一个目标写入一批消息，返回成功写入的条数
+func (s *Sender) writeBatchTo(t *sendTarget, batch [][]byte) (int, error) {
+	conn, err := t.pool.Get()
+	if err != nil {
+		atomic.AddInt64(&t.failed, int64(len(batch)))
+		return 0, fmt.Errorf("获取连接失败: %w", err)
+	}
+
+	s.setWriteDeadline(conn)
+	start := time.Now()
+	n, err := writeBatch(conn, batch)
+	s.stats.latency.record(time.Since(start))
+	releaseConn(t.pool, conn, n, err)
+	atomic.AddInt64(&t.sent, int64(n))
+	atomic.AddInt64(&t.failed, int64(len(batch)-n))
+	return n, err
+}
+
+// releaseConn 写入完成后归还连接，n为本次写入的消息数
+// 写入超时的连接可能只写出了部分数据（TCP流或压缩流已无法继续使用），直接关闭，
+// 下次获取时由连接池重新建立
+func releaseConn(pool *ConnectionPool, conn net.Conn, n int, err error) {
+	if isWriteTimeout(err) {
+		pool.Discard(conn)
+		return
+	}
+	pool.Release(conn, n)
+}
+
+// targetStats 返回每个目标的发送统计
+func (s *Sender) targetStats() []TargetStats {
+	stats := make([]TargetStats, 0, len(s.targets))
+	for _, t := range s.targets {
+		stats = append(stats, TargetStats{
+			Address: t.address,
+			Sent:    atomic.LoadInt64(&t.sent),
+			Failed:  atomic.LoadInt64(&t.failed),
+		})
+	}
+	return stats
+}
+
+// compression 汇总所有目标连接池的压缩统计，未启用压缩时返回nil
+func (s *Sender) compression() *CompressionStats {
+	var total *CompressionStats
+	for _, t := range s.targets {
+		if c := t.pool.Compression(); c != nil {
+			if total == nil {
+				total = &CompressionStats{}
+			}
+			total.RawBytes += atomic.LoadInt64(&c.RawBytes)
+			total.CompressedBytes += atomic.LoadInt64(&c.CompressedBytes)
+		}
+	}
+	return total
+}
+
+// nullSink 汇总所有目标的空输出计数，未使用空输出时返回nil
+func (s *Sender) nullSink() *nullTransport {
+	var total *nullTransport
+	for _, t := range s.targets {
+		if sink, ok := t.pool.transport.(*nullTransport); ok {
+			if total == nil {
+				total = &nullTransport{}
+			}
+			total.writes += atomic.LoadInt64(&sink.writes)
+			total.bytes += atomic.LoadInt64(&sink.bytes)
+		}
+	}
+	return total
+}
+
+// churn 汇总所有目标连接池建立的连接数和按 --reconnect-every 主动关闭的连接数
+func (s *Sender) churn() (dials, retired int64) {
+	for _, t := range s.targets {
+		d, r := t.pool.Churn()
+		dials += d
+		retired += r
+	}
+	return dials, retired
+}
+
+// closeTargets 关闭所有目标的连接池
+func (s *Sender) closeTargets() {
+	for _, t := range s.targets {
+		t.pool.Close()
+	}
+}
@@ -0,0 +1,131 @@
+package sender
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"syslog_go/pkg/config"
+)
+
+// writeSelfSignedCert 生成127.0.0.1的自签名证书，证书和私钥以PEM格式写入dir
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "syslog_go test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成证书失败: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// TestSendOverTLS 用自签名CA验证服务器证书，消息经TLS送达接收端
+func TestSendOverTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("启动TLS监听失败: %v", err)
+	}
+	defer listener.Close()
+
+	// 接收端读取第一条消息，握手在第一次读取时完成
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- "accept: " + err.Error()
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			received <- "read: " + err.Error()
+			return
+		}
+		received <- line
+	}()
+
+	cfg := config.DefaultConfig()
+	cfg.Target = listener.Addr().String()
+	cfg.Protocol = "tls"
+	cfg.Format = "rfc5424"
+	cfg.Message = "delivered over tls"
+	cfg.TLSCAFile = certFile
+	cfg.Rounds = 1
+	cfg.RoundSize = 1
+	cfg.Quiet = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("配置验证失败: %v", err)
+	}
+	if !cfg.UseTLS || cfg.Protocol != "tcp" {
+		t.Fatalf("tls协议应启用TLS并使用TCP，实际 UseTLS=%v Protocol=%s", cfg.UseTLS, cfg.Protocol)
+	}
+
+	s, err := NewSenderWithOutput(cfg, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatalf("创建发送器失败: %v", err)
+	}
+	defer s.Stop()
+
+	snapshot, err := s.Start()
+	if err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if snapshot.Sent != 1 || snapshot.Failed != 0 {
+		t.Fatalf("发送 %d 条，失败 %d 条，期望发送1条", snapshot.Sent, snapshot.Failed)
+	}
+
+	select {
+	case line := <-received:
+		if !strings.HasPrefix(line, "<") || !strings.HasSuffix(line, "delivered over tls\n") {
+			t.Fatalf("接收端收到 %q", line)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("接收端没有收到消息")
+	}
+}
@@ -0,0 +1,53 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// ErrEPSOutOfTolerance 实际平均速率与目标速率的偏差超过 --require-eps-tolerance
+// 发送本身已正常结束，用于在CI中把"是否达到了预期的负载"作为可自动判断的条件
+var ErrEPSOutOfTolerance = errors.New("实际速率超出容差")
+
+// configuredEPS 检查速率偏差使用的目标速率，按总量发送时为换算出的精确速率
+// 没有固定目标（消息间隔分布、自动调节EPS、不限速）时返回0
+func (s *Sender) configuredEPS() float64 {
+	if s.total > 0 {
+		return s.config.TotalRate()
+	}
+	return float64(s.targetEPS())
+}
+
+// epsDeviation 返回实际平均速率（成功发送数/总耗时，与最终统计的平均速率相同）及其相对目标速率的偏差比例
+// 没有目标速率时偏差为0
+func (s *Sender) epsDeviation() (achieved, deviation float64) {
+	elapsed := s.stats.EndTime.Sub(s.stats.StartTime).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	achieved = float64(atomic.LoadInt64(&s.stats.Sent)) / elapsed
+	if target := s.configuredEPS(); target > 0 {
+		deviation = math.Abs(achieved-target) / target
+	}
+	return achieved, deviation
+}
+
+// checkEPSTolerance 发送结束后检查实际平均速率是否在容差内，超出时返回包装了ErrEPSOutOfTolerance的错误
+// 静默模式下同样检查，未配置容差或没有目标速率时返回nil
+func (s *Sender) checkEPSTolerance() error {
+	target := s.configuredEPS()
+	if s.epsTolerance <= 0 || target <= 0 {
+		return nil
+	}
+	achieved, deviation := s.epsDeviation()
+	if deviation <= s.epsTolerance {
+		return nil
+	}
+	err := fmt.Errorf("%w: 平均速率 %.2f/s，目标 %.2f/s，偏差 %.1f%%，超过 --require-eps-tolerance %g%%",
+		ErrEPSOutOfTolerance, achieved, target, deviation*100, s.epsTolerance*100)
+	s.log.Error(err.Error(), "event", "eps_out_of_tolerance", "achieved_eps", achieved, "target_eps", target,
+		"deviation", deviation, "tolerance", s.epsTolerance)
+	return err
+}
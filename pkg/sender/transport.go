@@ -0,0 +1,376 @@
+package sender
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"syslog_go/pkg/logging"
+)
+
+// Transport 建立到目标的连接
+// 连接池通过Transport拨号并复用得到的连接，写入、批量写入（BatchWriter）和关闭都作用于返回的net.Conn；
+// 新的传输方式（如HTTP、Kafka）实现该接口并用RegisterTransport注册到协议名下，无需修改连接池
+type Transport interface {
+	// Dial 建立一个到address的连接，ctx取消时应尽快返回
+	Dial(ctx context.Context, address string) (net.Conn, error)
+}
+
+// TransportOptions 创建传输时使用的连接参数
+type TransportOptions struct {
+	Timeout    time.Duration   // 拨号超时时间
+	SourceIP   string          // 源IP地址，为空时使用系统默认地址
+	SourcePort int             // 源端口，为0时由系统分配
+	Spoof      bool            // 是否允许对非本机源IP使用原始套接字伪造
+	Verbose    bool            // 是否输出详细日志
+	TLS        *tls.Config     // TCP连接的TLS配置，为nil时不使用TLS
+	Logger     *logging.Logger // 详细日志和警告
+}
+
+// TransportFactory 按协议名和连接参数创建传输，参数无效时返回错误
+type TransportFactory func(protocol string, opts TransportOptions) (Transport, error)
+
+// transports 协议名到传输工厂的注册表，内置udp、tcp、unix和空输出
+var (
+	transports = map[string]TransportFactory{
+		"udp":        newNetTransport,
+		"tcp":        newNetTransport,
+		"unix":       newUnixTransport,
+		NullProtocol: newNullTransport,
+	}
+	transportsMu sync.RWMutex
+)
+
+// RegisterTransport 注册自定义传输，注册后连接池按协议名选用
+// 命令行只接受内置协议，自定义传输用于以库的方式使用发送器（如在测试中替换为内存连接）
+// 参数：
+//   - protocol: 协议名，与Config.Protocol对应
+//   - factory: 传输工厂
+//
+// 返回值：
+//   - error: 协议名已注册时返回错误
+func RegisterTransport(protocol string, factory TransportFactory) error {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if _, ok := transports[protocol]; ok {
+		return fmt.Errorf("协议 %s 的传输已注册", protocol)
+	}
+	transports[protocol] = factory
+	return nil
+}
+
+// newTransport 按协议名创建已注册的传输
+func newTransport(protocol string, opts TransportOptions) (Transport, error) {
+	transportsMu.RLock()
+	factory, ok := transports[protocol]
+	transportsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("不支持的协议: %s", protocol)
+	}
+	return factory(protocol, opts)
+}
+
+// netTransport 内置的UDP/TCP传输
+// 源IP为本机地址时直接绑定，非本机地址且开启伪造时使用原始套接字，原始套接字不可用时回退到标准连接
+type netTransport struct {
+	network    string
+	timeout    time.Duration
+	sourceIP   string
+	sourcePort int
+	spoof      bool
+	verbose    bool
+	tls        *tls.Config // 不为nil时TCP连接在建立后进行TLS握手
+	log        *logging.Logger
+
+	fallbackOnce sync.Once // 保证原始套接字回退警告只输出一次
+	fallbackIP   string    // 伪造被禁用时原本指定的源IP，详细日志中标注连接已回退到系统默认地址
+}
+
+// newNetTransport 创建UDP/TCP传输
+// 非本机源IP且未开启伪造时返回错误；需要伪造时预先检查原始套接字权限，
+// 权限不足时明确告知伪造已禁用，而不是在每个连接上静默回退到系统默认源地址
+func newNetTransport(protocol string, opts TransportOptions) (Transport, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.Default()
+	}
+	sourceIP, spoof := opts.SourceIP, opts.Spoof
+
+	// 非本机源IP且未开启伪造时直接报错，避免意外触发权限错误
+	if sourceIP != "" && !spoof && !isLocalIP(sourceIP) {
+		return nil, fmt.Errorf("源IP %s 不是本机地址，如需伪造源地址请使用 --spoof（需要root权限）", sourceIP)
+	}
+
+	fallbackIP := ""
+	if sourceIP != "" && spoof && !isLocalIP(sourceIP) {
+		if err := checkRawSocketPrivilege(); err != nil {
+			warnSpoofDisabled(logger, sourceIP, err)
+			fallbackIP, sourceIP, spoof = sourceIP, "", false
+		}
+	}
+
+	return &netTransport{
+		network:    protocol,
+		timeout:    opts.Timeout,
+		sourceIP:   sourceIP,
+		sourcePort: opts.SourcePort,
+		spoof:      spoof,
+		verbose:    opts.Verbose,
+		tls:        opts.TLS,
+		log:        logger,
+		fallbackIP: fallbackIP,
+	}, nil
+}
+
+// spoofing 是否使用原始套接字伪造非本机源IP
+func (t *netTransport) spoofing() bool {
+	return t.sourceIP != "" && t.spoof && !isLocalIP(t.sourceIP)
+}
+
+// Dial 建立UDP/TCP连接，拨号受ctx控制，ctx取消时立即返回
+func (t *netTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	// 如果指定了源IP地址且不是本机IP，在开启伪造时尝试使用原始套接字
+	if t.spoofing() {
+		return t.dialRaw(ctx, address)
+	}
+
+	// 使用Dialer以支持设置源IP地址
+	dialer := &net.Dialer{
+		Timeout: t.timeout,
+	}
+
+	// 如果指定了本机源IP地址或源端口，设置本地地址
+	// IPv6地址由JoinHostPort加上方括号（如 [2001:db8::1]:0），链路本地地址可以带区域（如 fe80::1%eth0）
+	localIP := ""
+	if t.sourceIP != "" && isLocalIP(t.sourceIP) {
+		localIP = t.sourceIP
+		if err := checkAddressFamily(localIP, address); err != nil {
+			return nil, err
+		}
+	}
+	if localIP != "" || t.sourcePort > 0 {
+		local := net.JoinHostPort(localIP, strconv.Itoa(t.sourcePort))
+		var err error
+		switch t.network {
+		case "tcp":
+			dialer.LocalAddr, err = resolveTCPAddr(local)
+		case "udp":
+			dialer.LocalAddr, err = resolveUDPAddr(local)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析源地址 %s 失败: %w", local, err)
+		}
+	}
+
+	conn, err := t.dialContext(ctx, dialer, address)
+	if err != nil {
+		if t.sourcePort > 0 && errors.Is(err, syscall.EADDRINUSE) {
+			return nil, fmt.Errorf("源端口 %d 已被占用: %w（并发数大于1或有多个目标时每个连接都会绑定该端口，可改用 --source-port 0 由系统分配）", t.sourcePort, err)
+		}
+		return nil, err
+	}
+	note := ""
+	if t.fallbackIP != "" {
+		note = "源IP伪造已禁用，使用系统默认地址而不是 " + t.fallbackIP
+	}
+	t.logInterfaceForConn(conn, address, note)
+	return conn, nil
+}
+
+// dialContext 用dialer建立连接，配置了TLS时同时完成握手
+// dialer的Timeout同时限制建立连接和TLS握手的总时长
+func (t *netTransport) dialContext(ctx context.Context, dialer *net.Dialer, address string) (net.Conn, error) {
+	if t.tls == nil || t.network != "tcp" {
+		return dialer.DialContext(ctx, t.network, address)
+	}
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: t.tls}
+	conn, err := tlsDialer.DialContext(ctx, t.network, address)
+	if err != nil {
+		return nil, fmt.Errorf("TLS连接 %s 失败: %w", address, err)
+	}
+	return conn, nil
+}
+
+// resolveTCPAddr 解析TCP本地地址，失败时返回nil接口而不是包含nil指针的net.Addr
+func resolveTCPAddr(address string) (net.Addr, error) {
+	addr, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+// resolveUDPAddr 解析UDP本地地址，失败时返回nil接口而不是包含nil指针的net.Addr
+func resolveUDPAddr(address string) (net.Addr, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+// checkAddressFamily 检查源IP与IP字面量目标的地址族是否一致
+// 不一致时拨号只会报 "no suitable address found"，这里给出明确的原因；目标为主机名时由解析结果按源地址的地址族选择
+func checkAddressFamily(sourceIP, address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil
+	}
+	target := parseIPZone(host)
+	source := parseIPZone(sourceIP)
+	if target == nil || source == nil {
+		return nil
+	}
+	if (source.To4() != nil) != (target.To4() != nil) {
+		return fmt.Errorf("源IP %s 与目标 %s 的地址族不同（IPv4与IPv6不能混用）", sourceIP, address)
+	}
+	return nil
+}
+
+// parseIPZone 解析IP地址，忽略IPv6区域（如 fe80::1%eth0 中的 %eth0），无效时返回nil
+func parseIPZone(s string) net.IP {
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		s = s[:i]
+	}
+	return net.ParseIP(s)
+}
+
+// dialRaw 使用原始套接字伪造源IP，创建失败时回退到不指定源IP的标准连接
+func (t *netTransport) dialRaw(ctx context.Context, address string) (net.Conn, error) {
+	t.log.Info("尝试使用原始套接字模拟源IP地址: "+t.sourceIP, "source_ip", t.sourceIP)
+	// 尝试创建原始套接字连接
+	rawConn, err := newRawSocketConn(t.sourceIP, t.sourcePort, address, t.network, true, t.log) // 启用详细日志
+	if err != nil {
+		t.fallbackOnce.Do(func() { warnSpoofDisabled(t.log, t.sourceIP, err) })
+		// 回退到标准连接，不设置源IP
+		baseDialer := &net.Dialer{Timeout: t.timeout}
+		conn, derr := baseDialer.DialContext(ctx, t.network, address)
+		if derr != nil {
+			return nil, derr
+		}
+		t.logInterfaceForConn(conn, address, "源IP伪造失败，已回退到系统默认地址而不是 "+t.sourceIP)
+		return conn, nil
+	}
+	note := "原始套接字伪造源IP，出口网卡由路由决定"
+	if t.network == "udp" && t.sourcePort == 0 {
+		note += "，源端口每个数据包随机"
+	}
+	t.logInterfaceForConn(rawConn, address, note)
+	return rawConn, nil
+}
+
+// logInterfaceForConn 详细模式下输出连接实际使用的本地地址和网卡
+// 用于确认 --source-ip 是否生效：没有其他说明且本地地址与指定的源IP不一致时在日志中标注
+// 参数：
+//   - conn: 新建立的连接（标准连接或原始套接字）
+//   - address: 实际连接的目标地址（SRV目标为解析后的地址）
+//   - note: 附加说明，如已回退到系统默认地址，为空时不输出
+func (t *netTransport) logInterfaceForConn(conn net.Conn, address, note string) {
+	if !t.verbose || conn == nil {
+		return
+	}
+	la := conn.LocalAddr()
+	var ip net.IP
+	switch a := la.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	}
+	if note == "" && t.sourceIP != "" && ip != nil && !ip.Equal(parseIPZone(t.sourceIP)) {
+		note = "与指定的源IP " + t.sourceIP + " 不一致"
+	}
+
+	msg := "已建立连接"
+	fields := []any{"local_addr", la.String(), "target", address, "protocol", t.network}
+	if name := lookupInterfaceNameByIP(ip); name != "" {
+		msg += " 使用网卡: " + name
+		fields = append(fields, "interface", name)
+	}
+	msg += fmt.Sprintf(" 本地地址: %s -> 目标: %s 协议: %s", la.String(), address, t.network)
+	if note != "" {
+		msg += "（" + note + "）"
+		fields = append(fields, "note", note)
+	}
+	t.log.Info(msg, fields...)
+}
+
+// unixTransport 内置的本地unix套接字传输（如 /dev/log）
+type unixTransport struct {
+	timeout time.Duration
+}
+
+// newUnixTransport 创建unix套接字传输，unix套接字不支持指定源IP或源端口
+func newUnixTransport(_ string, opts TransportOptions) (Transport, error) {
+	return &unixTransport{timeout: opts.Timeout}, nil
+}
+
+// Dial 连接本地unix套接字
+// 系统日志套接字通常是数据报类型，因此先尝试unixgram，类型不匹配时再使用流式unix
+func (t *unixTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: t.timeout}
+	conn, err := dialer.DialContext(ctx, "unixgram", address)
+	if err == nil {
+		return conn, nil
+	}
+	if !errors.Is(err, syscall.EPROTOTYPE) {
+		return nil, err
+	}
+	return dialer.DialContext(ctx, "unix", address)
+}
+
+// NullProtocol 空输出传输的协议名，--null-sink 时代替配置的协议
+const NullProtocol = "null"
+
+// nullTransport 丢弃所有写入的传输，只计数，用于在没有网络目标时测量消息生成和速率限制的吞吐量
+// 同一传输创建的所有连接共用计数
+type nullTransport struct {
+	writes int64 // 写入调用次数，原子操作更新
+	bytes  int64 // 写入的字节数，原子操作更新
+}
+
+// newNullTransport 创建空输出传输，忽略所有连接参数
+func newNullTransport(_ string, _ TransportOptions) (Transport, error) {
+	return &nullTransport{}, nil
+}
+
+// Dial 返回一个丢弃写入的连接，不建立任何网络连接
+func (t *nullTransport) Dial(_ context.Context, address string) (net.Conn, error) {
+	return &nullConn{transport: t, addr: nullAddr(address)}, nil
+}
+
+// nullConn 空输出连接，Write只计数并返回len(b), nil
+type nullConn struct {
+	transport *nullTransport
+	addr      nullAddr
+}
+
+func (c *nullConn) Read(_ []byte) (int, error) { return 0, io.EOF }
+
+func (c *nullConn) Write(b []byte) (int, error) {
+	atomic.AddInt64(&c.transport.writes, 1)
+	atomic.AddInt64(&c.transport.bytes, int64(len(b)))
+	return len(b), nil
+}
+
+func (c *nullConn) Close() error                       { return nil }
+func (c *nullConn) LocalAddr() net.Addr                { return nullAddr("") }
+func (c *nullConn) RemoteAddr() net.Addr               { return c.addr }
+func (c *nullConn) SetDeadline(_ time.Time) error      { return nil }
+func (c *nullConn) SetReadDeadline(_ time.Time) error  { return nil }
+func (c *nullConn) SetWriteDeadline(_ time.Time) error { return nil }
+
+// nullAddr 空输出连接的地址，保留配置的目标地址便于日志显示
+type nullAddr string
+
+func (a nullAddr) Network() string { return NullProtocol }
+func (a nullAddr) String() string  { return string(a) }
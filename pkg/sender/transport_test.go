@@ -0,0 +1,69 @@
+package sender
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestDialIPv6SourceIP 指定IPv6源IP时连接从该地址发出，源地址带方括号拼接端口
+func TestDialIPv6SourceIP(t *testing.T) {
+	t.Run("tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp6", "[::1]:0")
+		if err != nil {
+			t.Skipf("IPv6不可用: %v", err)
+		}
+		defer listener.Close()
+
+		transport, err := newTransport("tcp", TransportOptions{Timeout: 2 * time.Second, SourceIP: "::1"})
+		if err != nil {
+			t.Fatalf("创建传输失败: %v", err)
+		}
+		conn, err := transport.Dial(context.Background(), listener.Addr().String())
+		if err != nil {
+			t.Fatalf("连接 %s 失败: %v", listener.Addr(), err)
+		}
+		defer conn.Close()
+
+		accepted, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("接受连接失败: %v", err)
+		}
+		defer accepted.Close()
+		if ip := accepted.RemoteAddr().(*net.TCPAddr).IP; !ip.Equal(net.IPv6loopback) {
+			t.Fatalf("接收端看到的源IP为 %s，期望 ::1", ip)
+		}
+	})
+
+	t.Run("udp", func(t *testing.T) {
+		listener, err := net.ListenPacket("udp6", "[::1]:0")
+		if err != nil {
+			t.Skipf("IPv6不可用: %v", err)
+		}
+		defer listener.Close()
+
+		transport, err := newTransport("udp", TransportOptions{Timeout: 2 * time.Second, SourceIP: "::1"})
+		if err != nil {
+			t.Fatalf("创建传输失败: %v", err)
+		}
+		conn, err := transport.Dial(context.Background(), listener.LocalAddr().String())
+		if err != nil {
+			t.Fatalf("连接 %s 失败: %v", listener.LocalAddr(), err)
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte("<14>ipv6")); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+
+		listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+		buf := make([]byte, 64)
+		_, from, err := listener.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("接收数据报失败: %v", err)
+		}
+		if ip := from.(*net.UDPAddr).IP; !ip.Equal(net.IPv6loopback) {
+			t.Fatalf("接收端看到的源IP为 %s，期望 ::1", ip)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// 确认模式
+const (
+	AckNone = "none" // 不回复确认（默认），与普通syslog接收端行为一致
+	AckByte = "byte" // 每成功解析一条消息回复一个字节
+	AckRELP = "relp" // 按RELP协议收发，TCP连接上的每个syslog命令回复rsp帧
+)
+
+// defaultAckByte byte模式默认回复的字节（ASCII ACK）
+const defaultAckByte = 0x06
+
+// maxRELPDataLen 接受的RELP帧数据最大长度，避免异常的长度字段导致分配过多内存
+const maxRELPDataLen = 128 * 1024 * 1024
+
+// SetAckMode 设置确认模式，只对TCP连接生效，必须在Start之前调用
+// 参数：
+//   - mode: none；byte（回复0x06）或 byte=N（N为十进制或0x开头的十六进制字节值）；
+//     relp（TCP连接按RELP协议解析帧并回复rsp，可作为RELP接收端）
+//
+// 返回值：
+//   - error: 模式无效时返回错误
+func (s *Server) SetAckMode(mode string) error {
+	name, value, hasValue := strings.Cut(strings.ToLower(strings.TrimSpace(mode)), "=")
+	switch name {
+	case "", AckNone:
+		s.ackMode = AckNone
+	case AckByte:
+		s.ackMode, s.ackByte = AckByte, defaultAckByte
+		if hasValue {
+			b, err := strconv.ParseUint(value, 0, 8)
+			if err != nil {
+				return fmt.Errorf("确认字节必须是0-255的数值: %s", value)
+			}
+			s.ackByte = byte(b)
+		}
+		return nil
+	case AckRELP:
+		s.ackMode = AckRELP
+	default:
+		return fmt.Errorf("确认模式必须是 none、byte、byte=N 或 relp: %s", mode)
+	}
+	if hasValue {
+		return fmt.Errorf("确认模式 %s 不接受参数", name)
+	}
+	return nil
+}
+
+// AckMode 返回当前的确认模式
+func (s *Server) AckMode() string {
+	if s.ackMode == "" {
+		return AckNone
+	}
+	return s.ackMode
+}
+
+// writeAcks byte模式下为成功解析的消息回复确认字节
+func (s *Server) writeAcks(conn net.Conn, count int) {
+	if s.ackMode != AckByte || count == 0 {
+		return
+	}
+	acks := make([]byte, count)
+	for i := range acks {
+		acks[i] = s.ackByte
+	}
+	if _, err := conn.Write(acks); err != nil {
+		s.tracef("向 %s 回复确认失败: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// relpFrame 一个RELP帧：TXNR SP COMMAND SP DATALEN [SP DATA] LF
+type relpFrame struct {
+	txnr    string
+	command string
+	data    string
+}
+
+// readRELPFrame 读取一个RELP帧
+func readRELPFrame(reader *bufio.Reader) (*relpFrame, error) {
+	txnr, err := readRELPToken(reader)
+	if err != nil {
+		return nil, err
+	}
+	command, err := readRELPToken(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// DATALEN之后是空格（有数据）或LF（无数据）
+	dataLen := 0
+	for digits := 0; ; digits++ {
+		c, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if c == '\n' || c == ' ' {
+			if digits == 0 {
+				return nil, fmt.Errorf("RELP帧格式无效: 缺少数据长度")
+			}
+			if c == '\n' {
+				if dataLen != 0 {
+					return nil, fmt.Errorf("RELP帧格式无效: 数据长度为 %d 但没有数据", dataLen)
+				}
+				return &relpFrame{txnr: txnr, command: command}, nil
+			}
+			break
+		}
+		if c < '0' || c > '9' || dataLen > maxRELPDataLen/10 {
+			return nil, fmt.Errorf("RELP帧格式无效: 数据长度无效")
+		}
+		dataLen = dataLen*10 + int(c-'0')
+	}
+
+	data := make([]byte, dataLen+1)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+	if data[dataLen] != '\n' {
+		return nil, fmt.Errorf("RELP帧缺少结尾的LF")
+	}
+	return &relpFrame{txnr: txnr, command: command, data: string(data[:dataLen])}, nil
+}
+
+// readRELPToken 读取以空格结尾的字段，跳过帧之间多余的换行
+func readRELPToken(reader *bufio.Reader) (string, error) {
+	token, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	token = strings.TrimLeft(strings.TrimSuffix(token, " "), "\r\n")
+	if token == "" {
+		return "", fmt.Errorf("RELP帧格式无效: 字段为空")
+	}
+	return token, nil
+}
+
+// writeRELPResponse 回复rsp帧：TXNR rsp DATALEN [SP 状态码 说明[LF 附加数据]] LF
+func writeRELPResponse(w io.Writer, txnr, data string) error {
+	if data == "" {
+		_, err := fmt.Fprintf(w, "%s rsp 0\n", txnr)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s rsp %d %s\n", txnr, len(data), data)
+	return err
+}
+
+// handleRELP 按RELP协议处理TCP连接
+// 支持open、syslog、close命令：open回复协议参数，syslog解析其中的消息，
+// 成功时回复200 OK，解析失败时回复500；close回复后关闭连接
+func (s *Server) handleRELP(conn net.Conn, reader *bufio.Reader, remoteAddr net.Addr) {
+	s.tracef("来自 %s 的TCP连接按RELP协议处理", remoteAddr)
+	writer := bufio.NewWriter(conn)
+	for {
+		frame, err := readRELPFrame(reader)
+		if err != nil {
+			select {
+			case <-s.shutdown:
+			default:
+				if err != io.EOF {
+					s.log.Errorf("读取来自 %s 的RELP帧失败: %v", remoteAddr, err)
+				}
+			}
+			return
+		}
+
+		var response string
+		switch frame.command {
+		case "open":
+			response = "200 OK\nrelp_version=0\nrelp_software=syslog_go\ncommands=syslog"
+		case "syslog":
+			if s.handleMessage(remoteAddr, strings.TrimSuffix(frame.data, "\n")) {
+				response = "200 OK"
+			} else {
+				response = "500 parse error"
+			}
+		case "close":
+			writeRELPResponse(writer, frame.txnr, "")
+			writer.Flush()
+			return
+		default:
+			response = "500 unsupported command " + frame.command
+		}
+
+		if err := writeRELPResponse(writer, frame.txnr, response); err != nil {
+			s.log.Errorf("向 %s 回复RELP响应失败: %v", remoteAddr, err)
+			return
+		}
+		// 缓冲区中没有更多待处理的帧时再刷新，一次写出多个响应
+		if reader.Buffered() == 0 {
+			if err := writer.Flush(); err != nil {
+				s.log.Errorf("向 %s 回复RELP响应失败: %v", remoteAddr, err)
+				return
+			}
+		}
+	}
+}
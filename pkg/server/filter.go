@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SetSourceFilter 设置按来源地址过滤消息的允许和拒绝列表，必须在Start之前调用
+// 来源地址为UDP数据包或TCP连接的对端地址（不是消息中的HOSTNAME）；
+// 命中拒绝列表，或设置了允许列表但未命中时，消息被丢弃，只计入Filtered
+// 参数：
+//   - allow: 允许的CIDR或单个IP地址，为空时允许所有未被拒绝的来源
+//   - deny: 拒绝的CIDR或单个IP地址，优先于允许列表
+//
+// 返回值：
+//   - error: 地址格式无效时返回错误
+func (s *Server) SetSourceFilter(allow, deny []string) error {
+	allowNets, err := parseSourceNets(allow)
+	if err != nil {
+		return fmt.Errorf("允许的来源地址无效: %w", err)
+	}
+	denyNets, err := parseSourceNets(deny)
+	if err != nil {
+		return fmt.Errorf("拒绝的来源地址无效: %w", err)
+	}
+	s.allowSrc = allowNets
+	s.denySrc = denyNets
+	return nil
+}
+
+// parseSourceNets 解析CIDR列表，单个IP地址按/32（IPv6为/128）处理
+func parseSourceNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q 不是有效的IP地址或CIDR", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是有效的IP地址或CIDR", entry)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// sourcePermitted 判断来源地址是否允许，未设置过滤时始终允许
+func (s *Server) sourcePermitted(remoteAddr net.Addr) bool {
+	if len(s.allowSrc) == 0 && len(s.denySrc) == 0 {
+		return true
+	}
+	var ip net.IP
+	switch addr := remoteAddr.(type) {
+	case *net.UDPAddr:
+		ip = addr.IP
+	case *net.TCPAddr:
+		ip = addr.IP
+	default:
+		return false
+	}
+
+	for _, ipNet := range s.denySrc {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	if len(s.allowSrc) == 0 {
+		return true
+	}
+	for _, ipNet := range s.allowSrc {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filtered 返回因来源地址不允许而丢弃的消息数量
+func (s *Server) Filtered() int64 {
+	return atomic.LoadInt64(&s.filtered)
+}
+
+// discardTCP 读取并丢弃来源不允许的TCP连接上的数据，按LF分帧计入Filtered
+// 连接保持打开直到对端关闭或服务器停止，不解析、不输出也不回复确认，
+// 发送端不会因连接被拒绝而报错，共用接收端的其他来源不受影响；
+// 压缩连接和RELP帧同样按LF计数，只是近似值
+func (s *Server) discardTCP(conn net.Conn, reader *bufio.Reader, remoteAddr net.Addr) {
+	s.tracef("来源 %s 不在允许范围内，丢弃该TCP连接的数据", remoteAddr)
+	buffer := make([]byte, s.bufferSize)
+	pending := false // 上次读取的末尾是否有尚未以LF结束的消息
+	for {
+		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			data := buffer[:n]
+			atomic.AddInt64(&s.filtered, int64(bytes.Count(data, []byte{'\n'})))
+			pending = data[n-1] != '\n'
+		}
+		if err == nil {
+			continue
+		}
+		select {
+		case <-s.shutdown:
+			return
+		default:
+		}
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			continue
+		}
+		// 连接关闭时末尾没有LF的最后一条消息同样计数
+		if pending {
+			atomic.AddInt64(&s.filtered, 1)
+		}
+		return
+	}
+}
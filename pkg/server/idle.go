@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// SetIdleTimeout 设置空闲超时，必须在Start之前调用
+// 从启动或最后一条消息起超过该时长未收到消息时，服务器自动停止（与调用Stop相同），Done返回的通道随之关闭；
+// 因来源地址不允许而丢弃的消息不算作收到消息
+// 参数：
+//   - timeout: 空闲超时时长，0表示不自动停止
+//
+// 返回值：
+//   - error: 时长为负数时返回错误
+func (s *Server) SetIdleTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("无效的空闲超时: %v", timeout)
+	}
+	s.idleTimeout = timeout
+	return nil
+}
+
+// Done 返回服务器停止后关闭的通道，用于等待空闲超时触发的自动停止
+func (s *Server) Done() <-chan struct{} {
+	return s.stopped
+}
+
+// touch 记录收到消息的时间，未设置空闲超时时不记录
+func (s *Server) touch() {
+	if s.idleTimeout > 0 {
+		atomic.StoreInt64(&s.lastReceived, time.Now().UnixNano())
+	}
+}
+
+// idleMonitor 空闲超时监控协程，超时后停止服务器
+// 不计入wg，Stop等待处理协程退出时不会等待它自身
+func (s *Server) idleMonitor() {
+	timer := time.NewTimer(s.idleTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-timer.C:
+		}
+		idle := time.Since(time.Unix(0, atomic.LoadInt64(&s.lastReceived)))
+		if idle < s.idleTimeout {
+			timer.Reset(s.idleTimeout - idle)
+			continue
+		}
+		s.log.Info(fmt.Sprintf("已超过 %v 未收到消息，自动停止", s.idleTimeout),
+			"event", "idle_timeout", "idle_timeout", s.idleTimeout.String())
+		s.Stop()
+		return
+	}
+}
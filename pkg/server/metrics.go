@@ -0,0 +1,157 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsPath 计数器的HTTP路径，发送端的EPS自动调节从这里读取收到的消息数
+const MetricsPath = "/metrics"
+
+// 健康检查的HTTP路径
+const (
+	HealthPath = "/healthz" // 存活检查，所有监听器绑定成功后返回200，停止过程中仍返回200
+	ReadyPath  = "/readyz"  // 就绪检查，监听器就绪且服务器未在停止时返回200
+)
+
+// SetMetricsAddr 设置HTTP计数器接口的监听地址，必须在Start之前调用
+// 设置后 GET /metrics 以Prometheus文本格式返回收到的消息数等计数器，
+// 同时提供 /healthz 和 /readyz 健康检查
+// 参数：
+//   - addr: 监听地址，如 127.0.0.1:9514，为空时不启动HTTP接口
+func (s *Server) SetMetricsAddr(addr string) {
+	s.metricsAddr = addr
+}
+
+// SetHealthAddr 设置健康检查接口的监听地址，必须在Start之前调用
+// 用于只需要健康检查（如Kubernetes探针）而不暴露计数器，或需要单独端口的场景；
+// 与计数器接口地址相同时只启动一个HTTP接口
+// 参数：
+//   - addr: 监听地址，如 0.0.0.0:8080，为空时不单独启动
+func (s *Server) SetHealthAddr(addr string) {
+	s.healthAddr = addr
+}
+
+// Ready 返回服务器是否已就绪：所有监听器都已绑定且未在停止
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+// Received 返回收到的消息总数（包括解析失败的消息）
+func (s *Server) Received() int64 {
+	return atomic.LoadInt64(&s.receivedTotal)
+}
+
+// startHTTP 启动HTTP计数器接口和健康检查接口
+// 先同步绑定地址，绑定失败时由Start返回错误
+func (s *Server) startHTTP() error {
+	if s.metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc(MetricsPath, s.serveMetrics)
+		s.handleProbes(mux)
+		if err := s.listenHTTP(s.metricsAddr, mux, "HTTP计数器接口", MetricsPath); err != nil {
+			return err
+		}
+	}
+	if s.healthAddr != "" && s.healthAddr != s.metricsAddr {
+		mux := http.NewServeMux()
+		s.handleProbes(mux)
+		if err := s.listenHTTP(s.healthAddr, mux, "健康检查接口", HealthPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listenHTTP 绑定地址并在后台提供HTTP服务
+func (s *Server) listenHTTP(addr string, handler http.Handler, name, path string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("启动%s失败: %v", name, err)
+	}
+
+	httpServer := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	s.httpServers = append(s.httpServers, httpServer)
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.log.Errorf("%s异常退出: %v", name, err)
+		}
+	}()
+	s.log.Infof("%s已启动: http://%s%s", name, listener.Addr(), path)
+	return nil
+}
+
+// stopHTTP 关闭HTTP计数器和健康检查接口
+func (s *Server) stopHTTP() {
+	for _, httpServer := range s.httpServers {
+		httpServer.Close()
+	}
+	s.httpServers = nil
+}
+
+// handleProbes 注册健康检查路径
+func (s *Server) handleProbes(mux *http.ServeMux) {
+	mux.HandleFunc(HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, atomic.LoadInt32(&s.started) == 1)
+	})
+	mux.HandleFunc(ReadyPath, func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, s.Ready())
+	})
+}
+
+// writeProbe 检查通过时返回200，否则返回503
+// 监听器绑定前都视为未通过，编排系统据此等待接收端启动后再开始发送
+func writeProbe(w http.ResponseWriter, ok bool) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "not ready")
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// serveMetrics 以Prometheus文本格式输出计数器
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeCounter(w, "syslog_go_received_total", "收到的消息总数", s.Received())
+	writeCounter(w, "syslog_go_truncated_total", "可能被截断的读取次数", s.Truncated())
+	writeCounter(w, "syslog_go_nonconforming_total", "不符合要求格式的消息数", s.NonConforming())
+	writeCounter(w, "syslog_go_messages_dropped_total", "因消息通道已满而丢弃的消息数", s.MessagesDropped())
+
+	// 按格式和解析结果分别输出消息数和字节数
+	stats := s.Stats()
+	formats := []struct {
+		label string
+		stats FormatStats
+	}{{"rfc3164", stats.RFC3164}, {"rfc5424", stats.RFC5424}, {"unparsed", stats.Unparsed}}
+	fmt.Fprintf(w, "# HELP syslog_go_messages_total 按格式分类的消息数\n# TYPE syslog_go_messages_total counter\n")
+	for _, f := range formats {
+		fmt.Fprintf(w, "syslog_go_messages_total{format=%q} %d\n", f.label, f.stats.Messages)
+	}
+	fmt.Fprintf(w, "# HELP syslog_go_message_bytes_total 按格式分类的消息字节数\n# TYPE syslog_go_message_bytes_total counter\n")
+	for _, f := range formats {
+		fmt.Fprintf(w, "syslog_go_message_bytes_total{format=%q} %d\n", f.label, f.stats.Bytes)
+	}
+
+	// 解析失败按原因分别输出，标签值按字母顺序排列
+	counts := s.ParseErrors()
+	causes := make([]string, 0, len(counts))
+	for cause := range counts {
+		causes = append(causes, cause)
+	}
+	sort.Strings(causes)
+	fmt.Fprintf(w, "# HELP syslog_go_parse_errors_total 解析失败的消息数\n# TYPE syslog_go_parse_errors_total counter\n")
+	for _, cause := range causes {
+		fmt.Fprintf(w, "syslog_go_parse_errors_total{cause=%q} %d\n", cause, counts[cause])
+	}
+}
+
+// writeCounter 输出一个Prometheus计数器
+func writeCounter(w http.ResponseWriter, name, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// maxOctetFrameLen 接受的八位组计数帧最大长度，避免异常的长度字段导致读取过多数据
+const maxOctetFrameLen = 128 * 1024 * 1024
+
+// maxOctetLenDigits 八位组计数帧长度前缀的最大位数，maxOctetFrameLen为9位，多留一位
+const maxOctetLenDigits = 10
+
+// isOctetCounted 判断TCP连接的开头是否为八位组计数的长度前缀（RFC6587）：[1-9][0-9]* SP
+// 只看首字节不够：宽松解析时缺少尖括号的 "13: msg" 同样以数字开始，应按LF分帧处理。
+// 逐字节Peek，读到能够判断的字节为止，不会为了凑满固定长度而等待更多数据
+// 返回值：
+//   - bool: 开头符合长度前缀的格式时返回true
+//   - error: 判断完成前读取失败（如超时或连接关闭）时返回错误，已读到的数据仍在reader中
+func isOctetCounted(reader *bufio.Reader) (bool, error) {
+	for n := 1; n <= maxOctetLenDigits+1; n++ {
+		head, err := reader.Peek(n)
+		if err != nil {
+			return false, err
+		}
+		c := head[n-1]
+		switch {
+		case n == 1:
+			if c < '1' || c > '9' {
+				return false, nil
+			}
+		case c == ' ':
+			return true, nil
+		case c < '0' || c > '9':
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+// readOctetFrame 读取一个八位组计数帧：MSG-LEN SP SYSLOG-MSG
+// 超过max字节的消息只保留前max字节，其余部分读取后丢弃，
+// 返回消息内容和帧声明的长度
+func readOctetFrame(reader *bufio.Reader, max int) ([]byte, int, error) {
+	length := 0
+	for digits := 0; ; digits++ {
+		c, err := reader.ReadByte()
+		if err != nil {
+			// 帧之间连接正常关闭时返回EOF
+			if err == io.EOF && digits > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, 0, err
+		}
+		if c == ' ' {
+			if digits == 0 {
+				return nil, 0, fmt.Errorf("八位组计数帧格式无效: 缺少长度")
+			}
+			break
+		}
+		// 部分发送端在帧之间多发一个换行，跳过
+		if digits == 0 && (c == '\n' || c == '\r') {
+			digits--
+			continue
+		}
+		if c < '0' || c > '9' || (digits == 0 && c == '0') || length > maxOctetFrameLen/10 {
+			return nil, 0, fmt.Errorf("八位组计数帧格式无效: 长度无效")
+		}
+		length = length*10 + int(c-'0')
+	}
+	if length > maxOctetFrameLen {
+		return nil, 0, fmt.Errorf("八位组计数帧长度 %d 超过上限 %d", length, maxOctetFrameLen)
+	}
+
+	keep := length
+	if keep > max {
+		keep = max
+	}
+	data := make([]byte, keep)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	if _, err := reader.Discard(length - keep); err != nil {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	return data, length, nil
+}
+
+// handleOctetTCP 处理使用八位组计数分帧的TCP连接
+// 每帧一条消息，消息中可以包含换行；连接由对端关闭或服务器停止时返回
+func (s *Server) handleOctetTCP(conn net.Conn, reader *bufio.Reader, remoteAddr net.Addr) {
+	s.tracef("来自 %s 的TCP连接使用八位组计数分帧", remoteAddr)
+	// 帧读取到一半超时后无法恢复，不设置读取超时，服务器停止时连接会被关闭
+	conn.SetReadDeadline(time.Time{})
+
+	acks := 0
+	for {
+		data, length, err := readOctetFrame(reader, s.bufferSize)
+		if err != nil {
+			select {
+			case <-s.shutdown:
+			default:
+				if err != io.EOF {
+					s.log.Errorf("读取来自 %s 的八位组计数帧失败: %v", remoteAddr, err)
+				}
+			}
+			s.writeAcks(conn, acks)
+			return
+		}
+		if length > s.bufferSize {
+			s.checkTruncated("TCP", remoteAddr, length)
+		}
+
+		line := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		if line != "" && s.handleMessage(remoteAddr, line) {
+			acks++
+		}
+		// 缓冲区中没有更多待处理的帧时再回复，一次写出多个确认
+		if reader.Buffered() == 0 {
+			s.writeAcks(conn, acks)
+			acks = 0
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeChunks 在独立的协程中按给定的分段依次写入，写完后关闭连接
+// 每段是一次单独的Write，net.Pipe上对端的每次Read最多得到一段
+func writeChunks(t *testing.T, conn net.Conn, chunks []string) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		defer conn.Close()
+		for _, chunk := range chunks {
+			if _, err := conn.Write([]byte(chunk)); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	return done
+}
+
+// splitEvery 把数据切成每段n字节
+func splitEvery(data string, n int) []string {
+	var chunks []string
+	for len(data) > n {
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return append(chunks, data)
+}
+
+// octetFrame 按 MSG-LEN SP SYSLOG-MSG 为消息加上长度前缀
+func octetFrame(msg string) string {
+	return strconv.Itoa(len(msg)) + " " + msg
+}
+
+// octetStream 两个八位组计数帧：第二条消息包含换行
+const octetStream = "13 <13>first msg" + "19 <14>second\nline two"
+
+// TestReadOctetFrameSplitWrites 帧的长度前缀、分隔空格和消息体被拆到多次写入时按长度重新拼接
+func TestReadOctetFrameSplitWrites(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+	}{
+		{"single write", []string{octetStream}},
+		{"byte by byte", splitEvery(octetStream, 1)},
+		{"split in length", []string{"1", "3 <13>first msg1", "9 <14>second\nline two"}},
+		{"split before space", []string{"13", " <13>first msg19", " <14>second\nline two"}},
+		{"split in body", []string{"13 <13>fi", "rst msg19 <14>sec", "ond\n", "line two"}},
+		{"three bytes", splitEvery(octetStream, 3)},
+	}
+	want := []string{"<13>first msg", "<14>second\nline two"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer server.Close()
+			written := writeChunks(t, client, tt.chunks)
+
+			reader := bufio.NewReader(server)
+			for i, expected := range want {
+				data, length, err := readOctetFrame(reader, DefaultBufferSize)
+				if err != nil {
+					t.Fatalf("读取第 %d 帧失败: %v", i+1, err)
+				}
+				if string(data) != expected || length != len(expected) {
+					t.Fatalf("第 %d 帧为 %q（长度 %d），期望 %q", i+1, data, length, expected)
+				}
+			}
+			if _, _, err := readOctetFrame(reader, DefaultBufferSize); err != io.EOF {
+				t.Fatalf("帧之间关闭连接应返回EOF，实际为 %v", err)
+			}
+			if err := <-written; err != nil {
+				t.Fatalf("写入失败: %v", err)
+			}
+		})
+	}
+}
+
+// TestReadOctetFrameTruncated 超过上限的帧只保留前max字节，剩余部分丢弃后下一帧仍然对齐
+func TestReadOctetFrameTruncated(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	written := writeChunks(t, client, splitEvery(octetStream, 4))
+
+	reader := bufio.NewReader(server)
+	data, length, err := readOctetFrame(reader, 8)
+	if err != nil {
+		t.Fatalf("读取第一帧失败: %v", err)
+	}
+	if string(data) != "<13>firs" || length != 13 {
+		t.Fatalf("截断后为 %q（长度 %d），期望 %q（长度 13）", data, length, "<13>firs")
+	}
+	data, _, err = readOctetFrame(reader, DefaultBufferSize)
+	if err != nil || string(data) != "<14>second\nline two" {
+		t.Fatalf("截断后的下一帧为 %q (%v)", data, err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+}
+
+// TestReadOctetFrameUnexpectedEOF 帧读到一半连接关闭时返回ErrUnexpectedEOF
+func TestReadOctetFrameUnexpectedEOF(t *testing.T) {
+	for _, partial := range []string{"13", "13 <13>fir"} {
+		client, server := net.Pipe()
+		written := writeChunks(t, client, []string{partial})
+		_, _, err := readOctetFrame(bufio.NewReader(server), DefaultBufferSize)
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("读取 %q 后关闭连接返回 %v，期望 %v", partial, err, io.ErrUnexpectedEOF)
+		}
+		<-written
+		server.Close()
+	}
+}
+
+// TestHandleOctetTCPSplitWrites 通过TCP连接处理流程检测八位组计数分帧，拆分写入的帧解析为完整的消息
+func TestHandleOctetTCPSplitWrites(t *testing.T) {
+	frames := octetFrame("<13>Oct  1 12:00:00 h a: x") + octetFrame("<14>Oct  1 12:00:01 h b: y\nmore")
+	for _, size := range []int{1, 2, 5, 7, len(frames)} {
+		s := NewServer("127.0.0.1", 0)
+		s.SetQuiet(true)
+		if err := s.SetMessageBuffer(10); err != nil {
+			t.Fatal(err)
+		}
+
+		client, server := net.Pipe()
+		s.trackConn(server)
+		s.wg.Add(1)
+		go s.handleTCPConnection(server)
+		written := writeChunks(t, client, splitEvery(frames, size))
+
+		for i, want := range []string{"x", "y\nmore"} {
+			select {
+			case msg := <-s.Messages():
+				if msg.Content != want {
+					t.Fatalf("每次写入 %d 字节: 第 %d 条消息内容为 %q，期望 %q", size, i+1, msg.Content, want)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("每次写入 %d 字节: 没有收到第 %d 条消息", size, i+1)
+			}
+		}
+		if err := <-written; err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+		s.wg.Wait()
+		if got := s.Received(); got != 2 {
+			t.Fatalf("每次写入 %d 字节: 收到 %d 条消息，期望 2 条", size, got)
+		}
+	}
+}
+
+// TestIsOctetCounted 只有 [1-9][0-9]* SP 开头的连接按八位组计数分帧
+func TestIsOctetCounted(t *testing.T) {
+	tests := []struct {
+		head string
+		want bool
+	}{
+		{"13 <13>msg", true},
+		{"1 x", true},
+		{"1234567890 ", true},
+		{"13: msg\n", false},
+		{"13:msg\n", false},
+		{"<13>msg\n", false},
+		{"0 x", false},
+		{"12345678901 x", false},
+		{" 13 x", false},
+		{"13\n", false},
+	}
+	for _, tt := range tests {
+		got, err := isOctetCounted(bufio.NewReader(strings.NewReader(tt.head)))
+		if err != nil {
+			t.Errorf("%q: %v", tt.head, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("isOctetCounted(%q) = %v，期望 %v", tt.head, got, tt.want)
+		}
+	}
+
+	// 只有数字时连接关闭，无法判断
+	if _, err := isOctetCounted(bufio.NewReader(strings.NewReader("13"))); err != io.EOF {
+		t.Errorf("只有数字时应返回EOF，实际为 %v", err)
+	}
+}
+
+// TestLenientDigitPriorityOverTCP 宽松模式下以 "13: " 开头的TCP消息按LF分帧解析，不误判为八位组计数
+func TestLenientDigitPriorityOverTCP(t *testing.T) {
+	s, addr := startTCPServer(t, func(s *Server) { s.SetLenient(true) })
+	sendTCP(t, addr, "13: first\n13: second\n")
+
+	for i, want := range []string{"first", "second"} {
+		select {
+		case msg := <-s.Messages():
+			if msg.Priority != 13 || msg.Content != want {
+				t.Fatalf("第 %d 条消息: 优先级 %d，内容 %q，期望 13 和 %q", i+1, msg.Priority, msg.Content, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("没有收到第 %d 条消息", i+1)
+		}
+	}
+	if got := s.LenientParsed(); got != 2 {
+		t.Fatalf("宽松解析 %d 条，期望 2 条", got)
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"syslog_go/pkg/syslog"
+)
+
+// 输出文件格式
+const (
+	OutputRaw  = "raw"  // 原样写入收到的消息（每条一行），可被 send --data-file --raw 重放
+	OutputText = "text" // 与控制台相同的文本格式（设置了日志模板时使用模板）
+	OutputJSON = "json" // 每行一个JSON对象，包含解析后的各个字段
+)
+
+// outputRecord JSON输出格式中的一条记录
+type outputRecord struct {
+	ReceivedAt     time.Time `json:"received_at"`               // 服务器收到消息的时间
+	RemoteAddr     string    `json:"remote_addr"`               // 发送端地址
+	Format         string    `json:"format"`                    // 识别出的Syslog格式
+	Priority       int       `json:"priority"`                  // 优先级
+	Facility       string    `json:"facility"`                  // Facility名称
+	Severity       string    `json:"severity"`                  // Severity名称
+	Timestamp      time.Time `json:"timestamp"`                 // 消息中的时间戳
+	Hostname       string    `json:"hostname,omitempty"`        // 主机名
+	Tag            string    `json:"tag,omitempty"`             // 应用名称或进程标签
+	PID            string    `json:"pid,omitempty"`             // 进程ID
+	MsgID          string    `json:"msgid,omitempty"`           // RFC5424 MSGID
+	StructuredData string    `json:"structured_data,omitempty"` // RFC5424结构化数据原文
+	Content        string    `json:"content"`                   // 消息内容
+}
+
+// SetOutput 设置消息输出文件，文件已存在时追加写入
+// 参数：
+//   - path: 输出文件路径，为空时不输出到文件
+//
+// 返回值：
+//   - error: 打开文件失败时返回错误
+func (s *Server) SetOutput(path string) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开输出文件失败: %w", err)
+	}
+	s.output = file
+	return nil
+}
+
+// SetOutputFormat 设置输出文件的格式
+// 参数：
+//   - format: raw、text或json，为空时使用text
+//
+// 返回值：
+//   - error: 格式不支持时返回错误
+func (s *Server) SetOutputFormat(format string) error {
+	switch format = strings.ToLower(format); format {
+	case "":
+		s.outputFormat = OutputText
+	case OutputRaw, OutputText, OutputJSON:
+		s.outputFormat = format
+	default:
+		return fmt.Errorf("不支持的输出格式: %s（支持 raw、text、json）", format)
+	}
+	return nil
+}
+
+// SetSample 设置抽样输出，每n条消息只输出1条（控制台和输出文件）
+// 解析、计数和消息通道不受抽样影响
+// 参数：
+//   - n: 抽样间隔，1表示输出全部消息
+//
+// 返回值：
+//   - error: n小于1时返回错误
+func (s *Server) SetSample(n int) error {
+	if n < 1 {
+		return fmt.Errorf("抽样间隔必须大于等于1: %d", n)
+	}
+	s.sample = int64(n)
+	return nil
+}
+
+// SetQuiet 设置静默模式
+// 静默模式下不在控制台输出逐条消息和连接读写日志，只保留启动、停止等关键日志
+func (s *Server) SetQuiet(quiet bool) {
+	s.quiet = quiet
+}
+
+// tracef 输出逐条消息相关的日志，静默模式下不输出
+func (s *Server) tracef(format string, args ...interface{}) {
+	if !s.quiet {
+		s.log.Infof(format, args...)
+	}
+}
+
+// sampled 判断当前消息是否在抽样范围内
+func (s *Server) sampled() bool {
+	if s.sample <= 1 {
+		return true
+	}
+	return (atomic.AddInt64(&s.received, 1)-1)%s.sample == 0
+}
+
+// writeOutput 将一行写入输出文件
+func (s *Server) writeOutput(line string) {
+	if s.output == nil {
+		return
+	}
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+	if _, err := s.output.WriteString(line); err != nil {
+		s.log.Errorf("写入输出文件失败: %v", err)
+	}
+}
+
+// writeJSON 以JSON格式将解析后的消息写入输出文件
+func (s *Server) writeJSON(remoteAddr net.Addr, message *syslog.Message) {
+	data, err := json.Marshal(outputRecord{
+		ReceivedAt:     time.Now(),
+		RemoteAddr:     remoteAddr.String(),
+		Format:         string(message.SyslogFormat),
+		Priority:       message.Priority,
+		Facility:       message.FacilityName(),
+		Severity:       message.SeverityName(),
+		Timestamp:      message.Timestamp,
+		Hostname:       message.Hostname,
+		Tag:            message.Tag,
+		PID:            message.PID,
+		MsgID:          message.MsgID,
+		StructuredData: message.StructuredData,
+		Content:        message.Content,
+	})
+	if err != nil {
+		s.log.Errorf("序列化消息失败: %v", err)
+		return
+	}
+	s.writeOutput(string(data))
+}
+
+// closeOutput 关闭输出文件
+func (s *Server) closeOutput() {
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+	if s.output != nil {
+		s.output.Close()
+		s.output = nil
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultSeqField 默认的序号字段名，与发送端模板 seq={{SEQ}} 对应
+const DefaultSeqField = "seq"
+
+// SeqStats 一个来源的序号统计
+// 按来源IP区分，同一发送进程的多个连接（不同源端口）共用一个序号序列
+type SeqStats struct {
+	Source    string // 来源IP
+	Received  int64  // 带序号的消息数
+	First     int64  // 收到的第一个序号
+	Max       int64  // 收到的最大序号
+	Lost      int64  // 丢失的消息数：序号缺口中至今仍未收到的数量
+	Gaps      int64  // 出现序号跳跃的次数
+	Reordered int64  // 乱序到达的消息数：比已收到的最大序号小
+}
+
+// LossRate 返回丢失率，即丢失数与序号范围内应收消息数之比
+func (s SeqStats) LossRate() float64 {
+	expected := s.Max - s.First + 1
+	if expected <= 0 {
+		return 0
+	}
+	return float64(s.Lost) / float64(expected)
+}
+
+// seqTracker 按来源跟踪消息序号
+type seqTracker struct {
+	field   string
+	pattern *regexp.Regexp
+	sources map[string]*SeqStats
+	mutex   sync.Mutex
+}
+
+// SetSeqField 启用序号检测，必须在Start之前调用
+// 启用后从每条消息中提取 字段名=N（也匹配结构化数据中的 字段名="N"），
+// 按来源IP统计序号缺口和乱序，停止后可通过SeqStats获取结果
+// 参数：
+//   - field: 序号字段名，如 seq，为空时不检测
+//
+// 返回值：
+//   - error: 字段名包含空白、=或引号时返回错误
+func (s *Server) SetSeqField(field string) error {
+	if field == "" {
+		s.seq = nil
+		return nil
+	}
+	if !regexp.MustCompile(`^[^\s="]+$`).MatchString(field) {
+		return fmt.Errorf("序号字段名无效: %q", field)
+	}
+	s.seq = &seqTracker{
+		field:   field,
+		pattern: regexp.MustCompile(`(?:^|[^\w.-])` + regexp.QuoteMeta(field) + `="?(\d+)`),
+		sources: make(map[string]*SeqStats),
+	}
+	return nil
+}
+
+// SeqField 返回序号字段名，未启用序号检测时为空
+func (s *Server) SeqField() string {
+	if s.seq == nil {
+		return ""
+	}
+	return s.seq.field
+}
+
+// SeqStats 返回各来源的序号统计，按来源排序，未启用序号检测时返回nil
+func (s *Server) SeqStats() []SeqStats {
+	if s.seq == nil {
+		return nil
+	}
+	s.seq.mutex.Lock()
+	defer s.seq.mutex.Unlock()
+
+	stats := make([]SeqStats, 0, len(s.seq.sources))
+	for _, st := range s.seq.sources {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Source < stats[j].Source })
+	return stats
+}
+
+// track 提取消息中的序号并更新来源的统计，消息中没有序号时忽略
+// 规则：
+//   - 序号等于期望值（已收到的最大序号+1）时正常
+//   - 大于期望值时记一次缺口，跳过的序号计为丢失
+//   - 小于期望值时计为乱序，迟到的消息之前已计为丢失，丢失数相应减少（不单独识别重复消息）
+func (t *seqTracker) track(remoteAddr net.Addr, msg string) {
+	match := t.pattern.FindStringSubmatch(msg)
+	if match == nil {
+		return
+	}
+	seq, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return
+	}
+
+	source := remoteAddr.String()
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	st, ok := t.sources[source]
+	if !ok {
+		t.sources[source] = &SeqStats{Source: source, Received: 1, First: seq, Max: seq}
+		return
+	}
+	st.Received++
+	switch expected := st.Max + 1; {
+	case seq == expected:
+		st.Max = seq
+	case seq > expected:
+		st.Gaps++
+		st.Lost += seq - expected
+		st.Max = seq
+	default:
+		st.Reordered++
+		if seq < st.First {
+			// 比第一个序号还早到的消息扩大了序号范围，不对应之前的缺口
+			st.First = seq
+		} else if st.Lost > 0 {
+			st.Lost--
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// startTCPServer 在127.0.0.1的空闲端口上启动只监听TCP的服务器，测试结束时停止
+// configure在Start之前调用，用于设置宽松解析、确认模式等选项
+func startTCPServer(t *testing.T, configure func(s *Server)) (*Server, string) {
+	t.Helper()
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("获取空闲端口失败: %v", err)
+	}
+	port := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	s := NewServer("127.0.0.1", port)
+	s.SetQuiet(true)
+	if err := s.SetUDPPort(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMessageBuffer(100); err != nil {
+		t.Fatal(err)
+	}
+	if configure != nil {
+		configure(s)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("启动服务器失败: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s, net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
+
+// sendTCP 建立TCP连接写入数据后关闭连接
+func sendTCP(t *testing.T, addr, data string) {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		t.Fatalf("连接服务器失败: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(data)); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+}
+
+// waitReceived 等待服务器收到n条消息（包括解析失败的消息）
+func waitReceived(t *testing.T, s *Server, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Received() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("服务器收到 %d 条消息，期望 %d 条", s.Received(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestLenientParsing 宽松模式下优先级格式有误的消息解析成功并计入LenientParsed，严格模式下解析失败
+func TestLenientParsing(t *testing.T) {
+	data := "13: first\n< 13 >second\n<13> third\n"
+	want := []string{"first", "second", "third"}
+
+	t.Run("lenient", func(t *testing.T) {
+		s, addr := startTCPServer(t, func(s *Server) { s.SetLenient(true) })
+		sendTCP(t, addr, data)
+		for i, content := range want {
+			select {
+			case msg := <-s.Messages():
+				if msg.Priority != 13 || msg.Content != content {
+					t.Fatalf("第 %d 条消息: 优先级 %d，内容 %q，期望 13 和 %q", i+1, msg.Priority, msg.Content, content)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("没有收到第 %d 条消息", i+1)
+			}
+		}
+		if got := s.LenientParsed(); got != int64(len(want)) {
+			t.Fatalf("宽松解析 %d 条，期望 %d 条", got, len(want))
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		s, addr := startTCPServer(t, nil)
+		sendTCP(t, addr, data)
+		waitReceived(t, s, int64(len(want)))
+
+		var failed int64
+		for _, n := range s.ParseErrors() {
+			failed += n
+		}
+		if failed != int64(len(want)) {
+			t.Fatalf("解析失败 %d 条，期望 %d 条: %v", failed, len(want), s.ParseErrors())
+		}
+		if got := s.LenientParsed(); got != 0 {
+			t.Fatalf("严格模式下宽松解析 %d 条，期望 0 条", got)
+		}
+		select {
+		case msg := <-s.Messages():
+			t.Fatalf("严格模式下不应解析出消息，收到 %+v", msg)
+		default:
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	"syslog_go/pkg/syslog"
+)
+
+// 消息分类，formatCounters的下标
+const (
+	classRFC3164 = iota
+	classRFC5424
+	classUnparsed
+	classCount
+)
+
+// FormatStats 一类消息的数量和字节数
+type FormatStats struct {
+	Messages int64 `json:"messages"` // 消息数量
+	Bytes    int64 `json:"bytes"`    // 消息字节数（分帧后的原始消息，不含换行符和长度前缀）
+}
+
+// Stats 按解析结果分类的接收统计
+// 用于确认被测来源实际发送的格式以及有多少消息无法解析
+type Stats struct {
+	Received int64       `json:"received"` // 收到的消息总数
+	RFC3164  FormatStats `json:"rfc3164"`  // 按RFC3164解析成功的消息
+	RFC5424  FormatStats `json:"rfc5424"`  // 按RFC5424解析成功的消息
+	Unparsed FormatStats `json:"unparsed"` // 解析失败的消息（要求格式时包括不符合该格式的消息）
+}
+
+// Summary 服务器运行期间的接收统计摘要，用于停止时写出JSON供测试脚本比对
+type Summary struct {
+	Received int64                  `json:"received"` // 收到的消息总数
+	Bytes    int64                  `json:"bytes"`    // 收到的消息字节数（各分类之和）
+	RFC3164  FormatStats            `json:"rfc3164"`  // 按RFC3164解析成功的消息
+	RFC5424  FormatStats            `json:"rfc5424"`  // 按RFC5424解析成功的消息
+	Unparsed FormatStats            `json:"unparsed"` // 解析失败的消息
+	Filtered int64                  `json:"filtered"` // 因来源地址不允许而丢弃的消息，不计入以上统计
+	Lenient  int64                  `json:"lenient"`  // 宽松解析成功的消息，已计入RFC3164或RFC5424
+	Duration time.Duration          `json:"duration"` // 运行时长（纳秒），尚未停止时为到当前的时长
+	AvgEPS   float64                `json:"avg_eps"`  // 运行期间的平均接收速率
+	Sources  map[string]FormatStats `json:"sources"`  // 按来源地址（不含端口）统计的消息数和字节数
+}
+
+// formatCounter 一类消息的计数，原子操作更新
+type formatCounter struct {
+	messages int64
+	bytes    int64
+}
+
+// countFormat 按解析结果和来源记录一条消息，message为nil表示解析失败
+func (s *Server) countFormat(remoteAddr net.Addr, message *syslog.Message, size int) {
+	class := classUnparsed
+	if message != nil {
+		class = classRFC3164
+		if message.SyslogFormat == syslog.RFC5424 {
+			class = classRFC5424
+		}
+	}
+	atomic.AddInt64(&s.formats[class].messages, 1)
+	atomic.AddInt64(&s.formats[class].bytes, int64(size))
+
+	// 同一主机的不同源端口（如UDP每次发送使用新端口）合并统计
+	source := remoteAddr.String()
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	s.summaryMu.Lock()
+	st, ok := s.sources[source]
+	if !ok {
+		st = &FormatStats{}
+		s.sources[source] = st
+	}
+	st.Messages++
+	st.Bytes += int64(size)
+	s.summaryMu.Unlock()
+}
+
+// Stats 返回按格式和解析结果分类的接收统计，运行中也可以调用
+func (s *Server) Stats() Stats {
+	load := func(class int) FormatStats {
+		return FormatStats{
+			Messages: atomic.LoadInt64(&s.formats[class].messages),
+			Bytes:    atomic.LoadInt64(&s.formats[class].bytes),
+		}
+	}
+	return Stats{
+		Received: s.Received(),
+		RFC3164:  load(classRFC3164),
+		RFC5424:  load(classRFC5424),
+		Unparsed: load(classUnparsed),
+	}
+}
+
+// Summary 返回接收统计摘要，运行中也可以调用
+func (s *Server) Summary() Summary {
+	stats := s.Stats()
+	summary := Summary{
+		Received: stats.Received,
+		Bytes:    stats.RFC3164.Bytes + stats.RFC5424.Bytes + stats.Unparsed.Bytes,
+		RFC3164:  stats.RFC3164,
+		RFC5424:  stats.RFC5424,
+		Unparsed: stats.Unparsed,
+		Filtered: s.Filtered(),
+		Lenient:  s.LenientParsed(),
+	}
+
+	s.summaryMu.Lock()
+	defer s.summaryMu.Unlock()
+	if !s.startTime.IsZero() {
+		end := s.stopTime
+		if end.IsZero() {
+			end = time.Now()
+		}
+		summary.Duration = end.Sub(s.startTime)
+	}
+	if seconds := summary.Duration.Seconds(); seconds > 0 {
+		summary.AvgEPS = float64(summary.Received) / seconds
+	}
+	summary.Sources = make(map[string]FormatStats, len(s.sources))
+	for source, st := range s.sources {
+		summary.Sources[source] = *st
+	}
+	return summary
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// SetTLS 为TCP监听器启用TLS（RFC5425），必须在Start之前调用
+// 启用后TCP端口只接受TLS连接，握手完成后按与普通TCP连接相同的方式分帧和解析
+// 参数：
+//   - certFile: 服务器证书文件（PEM），为空时不启用TLS
+//   - keyFile: 服务器私钥文件（PEM），必须与certFile同时指定
+//
+// 返回值：
+//   - error: 只指定了其中一个文件或证书加载失败时返回错误
+func (s *Server) SetTLS(certFile, keyFile string) error {
+	if certFile == "" && keyFile == "" {
+		s.tlsConfig = nil
+		return nil
+	}
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("TLS证书和私钥必须同时指定")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("加载TLS证书失败: %w", err)
+	}
+	s.tlsConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return nil
+}
+
+// TLSEnabled 返回TCP监听器是否使用TLS
+func (s *Server) TLSEnabled() bool {
+	return s.tlsConfig != nil
+}
@@ -0,0 +1,23 @@
+package syslog
+
+import "time"
+
+// Clock 提供生成消息时间戳使用的当前时间
+// 默认使用系统时间，测试或需要可重复的输出时可以注入固定时间
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 返回系统当前时间
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock 系统时间，未指定时钟时使用
+var SystemClock Clock = systemClock{}
+
+// FixedClock 始终返回同一时间的时钟
+type FixedClock time.Time
+
+// Now 返回固定的时间
+func (c FixedClock) Now() time.Time { return time.Time(c) }
@@ -0,0 +1,97 @@
+package syslog
+
+import (
+	"errors"
+	"strings"
+)
+
+// 解析错误的原因，解析函数返回的错误用%w包装这些值，调用方可以用errors.Is区分
+var (
+	ErrFormatMismatch    = errors.New("格式不匹配")   // 消息整体不符合该格式，如缺少<PRI>或头部字段
+	ErrBadPriority       = errors.New("优先级无效")   // <PRI>不是0-191的数字
+	ErrBadVersion        = errors.New("不支持的版本")  // RFC5424的版本号不是1
+	ErrBadTimestamp      = errors.New("时间戳无效")   // 时间戳无法解析
+	ErrBadStructuredData = errors.New("结构化数据无效") // RFC5424的结构化数据格式错误
+)
+
+// parseErrorCauses 错误原因与ParseErrorCause返回的名称，按判断顺序排列
+var parseErrorCauses = []struct {
+	err  error
+	name string
+}{
+	{ErrBadPriority, "priority"},
+	{ErrBadVersion, "version"},
+	{ErrBadTimestamp, "timestamp"},
+	{ErrBadStructuredData, "structured_data"},
+	{ErrFormatMismatch, "format"},
+}
+
+// ParseErrorCause 返回解析错误原因的简短名称，用于计数器标签和日志字段
+// 返回值为 priority、version、timestamp、structured_data、format 之一，
+// 不是解析错误时返回 other
+func ParseErrorCause(err error) string {
+	for _, cause := range parseErrorCauses {
+		if errors.Is(err, cause.err) {
+			return cause.name
+		}
+	}
+	return "other"
+}
+
+// ParseAuto 自动识别格式并解析消息
+// 先尝试RFC5424，失败时尝试RFC3164；两种都失败时，
+// 消息具有RFC5424的头部特征（<PRI>1 ）则返回RFC5424的错误，否则返回RFC3164的错误，
+// 使错误原因对应发送方实际使用的格式
+//
+// 参数：
+//   - msg: 要解析的Syslog消息字符串
+//
+// 返回值：
+//   - *Message: 解析成功后的消息对象
+//   - error: 两种格式都无法解析时返回错误，可以用errors.Is判断原因
+func ParseAuto(msg string) (*Message, error) {
+	message, err := ParseRFC5424(msg)
+	if err == nil {
+		return message, nil
+	}
+	message, err3164 := ParseRFC3164(msg)
+	if err3164 == nil {
+		return message, nil
+	}
+	if looksLikeRFC5424(msg) {
+		return nil, err
+	}
+	return nil, err3164
+}
+
+// looksLikeRFC5424 判断消息是否以RFC5424的 "<PRI>1 " 开头
+func looksLikeRFC5424(msg string) bool {
+	end := strings.IndexByte(msg, '>')
+	return strings.HasPrefix(msg, "<") && end > 0 && strings.HasPrefix(msg[end+1:], "1 ")
+}
+
+// parsePriority 解析消息开头的<PRI>
+// 返回值：
+//   - int: 优先级（0-191）
+//   - string: <PRI>之后的内容
+//   - error: 不以<开头时为ErrFormatMismatch，数字无效或超出范围时为ErrBadPriority
+func parsePriority(msg string) (int, string, error) {
+	if !strings.HasPrefix(msg, "<") {
+		return 0, "", ErrFormatMismatch
+	}
+	end := strings.IndexByte(msg, '>')
+	if end < 2 || end > 4 {
+		return 0, "", ErrBadPriority
+	}
+	priority := 0
+	for _, c := range msg[1:end] {
+		if c < '0' || c > '9' {
+			return 0, "", ErrBadPriority
+		}
+		priority = priority*10 + int(c-'0')
+	}
+	if priority > 191 {
+		return 0, "", ErrBadPriority
+	}
+	return priority, msg[end+1:], nil
+}
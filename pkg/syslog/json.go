@@ -0,0 +1,115 @@
+package syslog
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Field JSON格式消息中附加的一个顶层字段
+type Field struct {
+	Key   string // 字段名
+	Value string // 字段值，按JSON字符串输出
+}
+
+// JSON格式消息的标准字段名，附加字段不能与之重名
+const (
+	JSONKeyTimestamp = "timestamp"
+	JSONKeyHostname  = "hostname"
+	JSONKeyAppName   = "app_name"
+	JSONKeyProcID    = "procid"
+	JSONKeyFacility  = "facility"
+	JSONKeySeverity  = "severity"
+	JSONKeyMessage   = "message"
+)
+
+// IsReservedJSONKey 判断字段名是否为JSON格式消息的标准字段
+func IsReservedJSONKey(key string) bool {
+	switch key {
+	case JSONKeyTimestamp, JSONKeyHostname, JSONKeyAppName, JSONKeyProcID,
+		JSONKeyFacility, JSONKeySeverity, JSONKeyMessage:
+		return true
+	}
+	return false
+}
+
+// appendJSON 按JSON格式追加消息，输出一个单行JSON对象
+// 标准字段在前（进程ID为空时省略），附加字段按Fields的顺序作为顶层字段输出，示例：
+// {"timestamp":"2003-10-11T22:14:15.003Z","hostname":"mymachine","app_name":"su","facility":"auth","severity":"crit","message":"'su root' failed","user":"root"}
+func (m *Message) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"`+JSONKeyTimestamp+`":"`...)
+	dst = m.appendTimestamp5424(dst)
+	dst = append(dst, '"')
+	dst = appendJSONField(dst, JSONKeyHostname, m.Hostname)
+	dst = appendJSONField(dst, JSONKeyAppName, m.Tag)
+	if m.PID != "" {
+		dst = appendJSONField(dst, JSONKeyProcID, m.PID)
+	}
+	dst = appendJSONField(dst, JSONKeyFacility, m.FacilityName())
+	dst = appendJSONField(dst, JSONKeySeverity, m.SeverityName())
+	dst = appendJSONField(dst, JSONKeyMessage, m.Content)
+	for _, f := range m.Fields {
+		dst = appendJSONField(dst, f.Key, f.Value)
+	}
+	return append(dst, '}')
+}
+
+// appendJSONField 追加 ,"key":"value"
+func appendJSONField(dst []byte, key, value string) []byte {
+	dst = append(dst, ',')
+	dst = appendJSONString(dst, key)
+	dst = append(dst, ':')
+	return appendJSONString(dst, value)
+}
+
+// appendJSONString 追加带引号并转义的JSON字符串
+// 转义引号、反斜杠和控制字符，无效的UTF-8字节替换为U+FFFD，与encoding/json的输出一致（不转义HTML字符）
+func appendJSONString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028和U+2029在JavaScript中是换行符，与encoding/json一样转义
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, `\u202`...)
+			dst = strconv.AppendInt(dst, int64(r&0xf), 16)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,99 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseLenient 宽松解析消息，容忍部分设备常见的优先级格式错误
+// 先按ParseAuto严格解析，失败时只容忍以下偏差：
+//  1. PRI前后有空白，如 "  <13>Oct 11 22:14:15 host app: msg"、"<13> Oct 11 ..."
+//  2. 尖括号内有空白，如 "< 13 >msg"、"<13 >msg"
+//  3. 没有尖括号，以1-3位数字加冒号表示优先级，如 "13: msg"、"13:msg"；
+//     冒号后紧跟数字时不视为优先级（如 "12:30:45 msg" 是时间而不是优先级）
+//  4. 优先级有效但之后没有可解析的头部，如 "<13>msg"：与RFC3164 4.3.2节对无效时间戳的处理相同，
+//     按RFC3164返回，时间戳为接收时间，优先级之后的全部内容作为Content
+//
+// 优先级本身仍必须是0-191的数字，没有可识别优先级的消息（如纯文本）仍然解析失败；
+// 优先级修正后符合RFC5424或RFC3164时按对应格式返回完整的头部字段，
+// 具有RFC5424头部（<PRI>1 ）但其他字段有误的消息不按第4条兜底，仍返回RFC5424的错误
+//
+// 参数：
+//   - msg: 要解析的Syslog消息字符串
+//
+// 返回值：
+//   - *Message: 解析成功后的消息对象
+//   - error: 没有可识别的优先级时返回错误，包装ErrFormatMismatch或ErrBadPriority；
+//     RFC5424消息的其他字段有误时返回ParseRFC5424的错误
+func ParseLenient(msg string) (*Message, error) {
+	message, err := ParseAuto(msg)
+	if err == nil {
+		return message, nil
+	}
+
+	priority, rest, perr := parseLenientPriority(msg)
+	if perr != nil {
+		return nil, fmt.Errorf("无法识别优先级: %w", perr)
+	}
+	normalized := "<" + strconv.Itoa(priority) + ">" + rest
+	message, err = ParseAuto(normalized)
+	if err == nil {
+		return message, nil
+	}
+	// 具有RFC5424头部特征的消息是结构化数据等字段有误，不按RFC3164兜底
+	if looksLikeRFC5424(normalized) {
+		return nil, err
+	}
+	return &Message{
+		Priority:     priority,
+		Timestamp:    time.Now(),
+		Content:      rest,
+		SyslogFormat: RFC3164,
+	}, nil
+}
+
+// parseLenientPriority 解析可能带有空白或缺少尖括号的优先级
+// 返回值：
+//   - int: 优先级（0-191）
+//   - string: 优先级之后去掉前导空白的内容
+//   - error: 没有可识别的优先级时为ErrFormatMismatch，数字无效或超出范围时为ErrBadPriority
+func parseLenientPriority(msg string) (int, string, error) {
+	s := strings.TrimLeft(msg, " \t")
+	var digits, rest string
+	if strings.HasPrefix(s, "<") {
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return 0, "", ErrFormatMismatch
+		}
+		digits, rest = strings.Trim(s[1:end], " \t"), s[end+1:]
+	} else {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(s) || s[n] != ':' {
+			return 0, "", ErrFormatMismatch
+		}
+		if n+1 < len(s) && s[n+1] >= '0' && s[n+1] <= '9' {
+			return 0, "", ErrFormatMismatch
+		}
+		digits, rest = s[:n], s[n+1:]
+	}
+
+	if len(digits) == 0 || len(digits) > 3 {
+		return 0, "", ErrBadPriority
+	}
+	priority := 0
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, "", ErrBadPriority
+		}
+		priority = priority*10 + int(c-'0')
+	}
+	if priority > 191 {
+		return 0, "", ErrBadPriority
+	}
+	return priority, strings.TrimLeft(rest, " \t"), nil
+}
@@ -0,0 +1,67 @@
+package syslog
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParseLenient 优先级带空白、缺少尖括号或之后有多余空白的消息按修正后的优先级解析
+func TestParseLenient(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		priority int
+		format   SyslogFormat
+		hostname string
+		content  string
+	}{
+		{"missing brackets", "13: msg", 13, RFC3164, "", "msg"},
+		{"missing brackets no space", "  13:msg", 13, RFC3164, "", "msg"},
+		{"spaces in brackets", "< 13 >msg", 13, RFC3164, "", "msg"},
+		{"space after priority", "<13> msg", 13, RFC3164, "", "msg"},
+		{"spaces before RFC3164 header", "< 13 >Oct  1 12:00:00 host app: hi", 13, RFC3164, "host", "hi"},
+		{"strict RFC5424", "<13>1 2024-01-02T03:04:05Z h a - - - x", 13, RFC5424, "h", "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseLenient(tt.msg)
+			if err != nil {
+				t.Fatalf("解析 %q 失败: %v", tt.msg, err)
+			}
+			if m.Priority != tt.priority || m.SyslogFormat != tt.format || m.Hostname != tt.hostname || m.Content != tt.content {
+				t.Fatalf("解析 %q 得到优先级 %d、格式 %s、主机 %q、内容 %q，期望 %d、%s、%q、%q",
+					tt.msg, m.Priority, m.SyslogFormat, m.Hostname, m.Content,
+					tt.priority, tt.format, tt.hostname, tt.content)
+			}
+		})
+	}
+}
+
+// TestParseLenientRejects 没有可识别优先级的消息仍然解析失败
+func TestParseLenientRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want error
+	}{
+		{"empty", "", ErrFormatMismatch},
+		{"plain text", "plain", ErrFormatMismatch},
+		{"time not priority", "12:30:45 msg", ErrFormatMismatch},
+		{"digits without colon", "13 msg", ErrFormatMismatch},
+		{"unclosed bracket", "<13 msg", ErrFormatMismatch},
+		{"empty brackets", "<>msg", ErrBadPriority},
+		{"out of range", "<192>msg", ErrBadPriority},
+		{"not a number", "<1a>msg", ErrBadPriority},
+		{"too many digits", "1234: msg", ErrBadPriority},
+		{"bad RFC5424 header", "<13>1 bad", ErrFormatMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLenient(tt.msg); !errors.Is(err, tt.want) {
+				t.Fatalf("解析 %q 返回 %v，期望 %v", tt.msg, err, tt.want)
+			}
+		})
+	}
+}
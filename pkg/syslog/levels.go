@@ -0,0 +1,94 @@
+package syslog
+
+import (
+	"fmt"
+	"sync"
+)
+
+// 默认的严重性映射表，下标为Syslog Severity（0-7）
+var (
+	// defaultGELFLevels GELF的level与Syslog Severity含义一致，直接一一对应
+	defaultGELFLevels = [8]int{0, 1, 2, 3, 4, 5, 6, 7}
+
+	// defaultCEFSeverities CEF的Severity范围为0-10且数值越大越严重，
+	// 按 round((7-severity)*10/7) 缩放：emerg=10 ... debug=0
+	defaultCEFSeverities = [8]int{10, 9, 7, 6, 4, 3, 1, 0}
+)
+
+// 当前生效的映射表，可通过Set*Mapping覆盖
+var (
+	gelfLevels    = defaultGELFLevels
+	cefSeverities = defaultCEFSeverities
+	levelsMutex   sync.RWMutex
+)
+
+// SeverityToGELFLevel 将Syslog Severity转换为GELF level
+// 参数：
+//   - severity: Syslog Severity（0-7），超出范围时取低3位
+//
+// 返回值：
+//   - int: GELF level（0-7）
+func SeverityToGELFLevel(severity int) int {
+	levelsMutex.RLock()
+	defer levelsMutex.RUnlock()
+	return gelfLevels[severity&0x07]
+}
+
+// SeverityToCEFSeverity 将Syslog Severity转换为CEF Severity
+// 参数：
+//   - severity: Syslog Severity（0-7），超出范围时取低3位
+//
+// 返回值：
+//   - int: CEF Severity（0-10）
+func SeverityToCEFSeverity(severity int) int {
+	levelsMutex.RLock()
+	defer levelsMutex.RUnlock()
+	return cefSeverities[severity&0x07]
+}
+
+// SetGELFLevelMapping 覆盖Syslog Severity到GELF level的映射
+// 参数：
+//   - mapping: Severity到GELF level的映射，未包含的Severity保持当前值
+//
+// 返回值：
+//   - error: Severity不在0-7或level不在0-7范围内时返回错误，此时映射不变
+func SetGELFLevelMapping(mapping map[int]int) error {
+	return setLevelMapping(&gelfLevels, mapping, 7, "GELF level")
+}
+
+// SetCEFSeverityMapping 覆盖Syslog Severity到CEF Severity的映射
+// 参数：
+//   - mapping: Severity到CEF Severity的映射，未包含的Severity保持当前值
+//
+// 返回值：
+//   - error: Severity不在0-7或CEF Severity不在0-10范围内时返回错误，此时映射不变
+func SetCEFSeverityMapping(mapping map[int]int) error {
+	return setLevelMapping(&cefSeverities, mapping, 10, "CEF Severity")
+}
+
+// ResetLevelMappings 恢复默认的GELF和CEF映射
+func ResetLevelMappings() {
+	levelsMutex.Lock()
+	defer levelsMutex.Unlock()
+	gelfLevels = defaultGELFLevels
+	cefSeverities = defaultCEFSeverities
+}
+
+// setLevelMapping 校验并写入映射表
+func setLevelMapping(table *[8]int, mapping map[int]int, maxLevel int, name string) error {
+	for severity, level := range mapping {
+		if severity < 0 || severity > 7 {
+			return fmt.Errorf("Severity必须在0-7范围内: %d", severity)
+		}
+		if level < 0 || level > maxLevel {
+			return fmt.Errorf("%s必须在0-%d范围内: %d", name, maxLevel, level)
+		}
+	}
+
+	levelsMutex.Lock()
+	defer levelsMutex.Unlock()
+	for severity, level := range mapping {
+		table[severity] = level
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package syslog
+
+import "testing"
+
+// TestDefaultLevelMappings 默认映射覆盖全部8个Severity
+func TestDefaultLevelMappings(t *testing.T) {
+	ResetLevelMappings()
+	tests := []struct {
+		severity int
+		name     string
+		gelf     int
+		cef      int
+	}{
+		{0, "emerg", 0, 10},
+		{1, "alert", 1, 9},
+		{2, "crit", 2, 7},
+		{3, "err", 3, 6},
+		{4, "warning", 4, 4},
+		{5, "notice", 5, 3},
+		{6, "info", 6, 1},
+		{7, "debug", 7, 0},
+	}
+	for _, tt := range tests {
+		if got := SeverityToGELFLevel(tt.severity); got != tt.gelf {
+			t.Errorf("%s: GELF level为 %d，期望 %d", tt.name, got, tt.gelf)
+		}
+		if got := SeverityToCEFSeverity(tt.severity); got != tt.cef {
+			t.Errorf("%s: CEF Severity为 %d，期望 %d", tt.name, got, tt.cef)
+		}
+	}
+}
+
+// TestLevelMappingsOutOfRange 超出0-7的Severity取低3位，不会越界
+func TestLevelMappingsOutOfRange(t *testing.T) {
+	ResetLevelMappings()
+	tests := []struct {
+		severity int
+		same     int // 取低3位后对应的Severity
+	}{
+		{8, 0},
+		{15, 7},
+		{-1, 7},
+		{-8, 0},
+		{190, 6},
+	}
+	for _, tt := range tests {
+		if got, want := SeverityToGELFLevel(tt.severity), SeverityToGELFLevel(tt.same); got != want {
+			t.Errorf("Severity %d: GELF level为 %d，期望与Severity %d相同（%d）", tt.severity, got, tt.same, want)
+		}
+		if got, want := SeverityToCEFSeverity(tt.severity), SeverityToCEFSeverity(tt.same); got != want {
+			t.Errorf("Severity %d: CEF Severity为 %d，期望与Severity %d相同（%d）", tt.severity, got, tt.same, want)
+		}
+	}
+}
+
+// TestLevelMappingOverrideAndReset 覆盖部分映射后其余保持不变，ResetLevelMappings恢复默认
+func TestLevelMappingOverrideAndReset(t *testing.T) {
+	t.Cleanup(ResetLevelMappings)
+	ResetLevelMappings()
+
+	if err := SetGELFLevelMapping(map[int]int{4: 3, 7: 6}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetCEFSeverityMapping(map[int]int{0: 8, 6: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for severity, want := range [8]int{0, 1, 2, 3, 3, 5, 6, 6} {
+		if got := SeverityToGELFLevel(severity); got != want {
+			t.Errorf("覆盖后Severity %d的GELF level为 %d，期望 %d", severity, got, want)
+		}
+	}
+	for severity, want := range [8]int{8, 9, 7, 6, 4, 3, 2, 0} {
+		if got := SeverityToCEFSeverity(severity); got != want {
+			t.Errorf("覆盖后Severity %d的CEF Severity为 %d，期望 %d", severity, got, want)
+		}
+	}
+
+	ResetLevelMappings()
+	for severity := 0; severity < 8; severity++ {
+		if got := SeverityToGELFLevel(severity); got != defaultGELFLevels[severity] {
+			t.Errorf("恢复后Severity %d的GELF level为 %d，期望 %d", severity, got, defaultGELFLevels[severity])
+		}
+		if got := SeverityToCEFSeverity(severity); got != defaultCEFSeverities[severity] {
+			t.Errorf("恢复后Severity %d的CEF Severity为 %d，期望 %d", severity, got, defaultCEFSeverities[severity])
+		}
+	}
+}
+
+// TestLevelMappingRejectsInvalid 无效的映射返回错误且不修改当前映射
+func TestLevelMappingRejectsInvalid(t *testing.T) {
+	t.Cleanup(ResetLevelMappings)
+	ResetLevelMappings()
+
+	invalid := []struct {
+		name string
+		set  func(map[int]int) error
+		m    map[int]int
+	}{
+		{"gelf severity", SetGELFLevelMapping, map[int]int{8: 1}},
+		{"gelf negative severity", SetGELFLevelMapping, map[int]int{-1: 1}},
+		{"gelf level", SetGELFLevelMapping, map[int]int{3: 8}},
+		{"gelf mixed", SetGELFLevelMapping, map[int]int{2: 5, 3: -1}},
+		{"cef severity", SetCEFSeverityMapping, map[int]int{8: 1}},
+		{"cef level", SetCEFSeverityMapping, map[int]int{0: 11}},
+		{"cef mixed", SetCEFSeverityMapping, map[int]int{1: 5, 2: -1}},
+	}
+	for _, tt := range invalid {
+		if err := tt.set(tt.m); err == nil {
+			t.Errorf("%s: %v 应返回错误", tt.name, tt.m)
+		}
+	}
+	if gelfLevels != defaultGELFLevels || cefSeverities != defaultCEFSeverities {
+		t.Fatalf("无效的映射修改了当前映射: GELF %v，CEF %v", gelfLevels, cefSeverities)
+	}
+}
@@ -5,6 +5,7 @@ package syslog
 import (
 	"fmt"
 	"regexp"  // 用于正则表达式匹配
+	"strconv" // 数值解析
 	"strings" // 字符串处理
 	"time"    // 时间处理
 )
@@ -17,18 +18,31 @@ type SyslogFormat string
 const (
 	RFC3164 SyslogFormat = "rfc3164" // BSD Syslog协议（传统格式）
 	RFC5424 SyslogFormat = "rfc5424" // Syslog协议（现代格式）
+	Raw     SyslogFormat = "raw"     // 原样透传，不添加优先级、时间戳等头部
+	JSON    SyslogFormat = "json"    // 单行JSON对象，标准字段和附加字段（Fields）均为顶层字段
 )
 
+// RFC3164TimeLayout RFC3164时间戳的默认格式，如 Oct 11 22:14:15
+const RFC3164TimeLayout = "Jan 02 15:04:05"
+
 // Message 表示一个Syslog消息
 // 包含了Syslog消息的所有组成部分
 type Message struct {
-	Priority     int          // 优先级 (Facility * 8 + Severity)
-	Timestamp    time.Time    // 消息生成的时间戳
-	Hostname     string       // 生成消息的主机名
-	Tag          string       // 生成消息的程序名称
-	PID          string       // 生成消息的进程ID
-	Content      string       // 消息的实际内容
-	SyslogFormat SyslogFormat // 使用的Syslog格式（RFC3164或RFC5424）
+	Priority       int          // 优先级 (Facility * 8 + Severity)
+	Timestamp      time.Time    // 消息生成的时间戳
+	Hostname       string       // 生成消息的主机名
+	Tag            string       // 生成消息的程序名称
+	PID            string       // 生成消息的进程ID
+	MsgID          string       // 消息类型标识（RFC5424 MSGID），为空时输出"-"
+	StructuredData string       // 结构化数据原文（RFC5424 STRUCTURED-DATA，如 [id@32473 k="v"]），为空时输出"-"
+	Content        string       // 消息的实际内容
+	SyslogFormat   SyslogFormat // 使用的Syslog格式（RFC3164或RFC5424）
+	TimeLayout     string       // RFC3164时间戳的Go时间格式，为空时使用RFC3164TimeLayout
+	Fields         []Field      // JSON格式下附加的顶层字段，按顺序输出，其他格式忽略
+
+	// 解析时的原始时间戳文本，时间戳未被修改时按原文输出，保证解析后重新格式化不丢失精度和时区
+	rawTimestamp    string
+	parsedTimestamp time.Time
 }
 
 // NewMessage 创建新的Syslog消息
@@ -42,9 +56,18 @@ type Message struct {
 // 返回值：
 //   - *Message: 新创建的Syslog消息对象
 func NewMessage(priority int, hostname, tag, content string, format SyslogFormat) *Message {
+	return NewMessageWithClock(SystemClock, priority, hostname, tag, content, format)
+}
+
+// NewMessageWithClock 创建新的Syslog消息，时间戳取自指定的时钟
+// clock为nil时使用系统时间
+func NewMessageWithClock(clock Clock, priority int, hostname, tag, content string, format SyslogFormat) *Message {
+	if clock == nil {
+		clock = SystemClock
+	}
 	return &Message{
 		Priority:     priority,
-		Timestamp:    time.Now(), // 使用当前时间
+		Timestamp:    clock.Now(), // 使用时钟的当前时间
 		Hostname:     hostname,
 		Tag:          tag,
 		Content:      content,
@@ -53,91 +76,129 @@ func NewMessage(priority int, hostname, tag, content string, format SyslogFormat
 }
 
 // Format 将消息格式化为指定的Syslog格式字符串
-// 根据消息的SyslogFormat字段选择相应的格式化方法
+// 根据消息的SyslogFormat字段选择相应的格式化方法，
+// Raw格式（或未指定格式）时原样返回消息内容
 // 返回值：
 //   - string: 格式化后的Syslog消息字符串
 func (m *Message) Format() string {
+	return string(m.AppendFormat(make([]byte, 0, m.formattedSize())))
+}
+
+// AppendFormat 将格式化后的消息追加到dst，返回追加后的切片
+// 输出与Format相同，但不产生中间字符串，发送的热路径配合可复用的缓冲区使用，
+// dst容量足够时不分配内存
+func (m *Message) AppendFormat(dst []byte) []byte {
 	switch m.SyslogFormat {
 	case RFC5424:
-		return m.formatRFC5424()
+		return m.appendRFC5424(dst)
 	case RFC3164:
-		return m.formatRFC3164()
+		return m.appendRFC3164(dst)
+	case JSON:
+		return m.appendJSON(dst)
 	default:
-		return m.Content
+		return append(dst, m.Content...)
 	}
 }
 
-// formatRFC3164 格式化为RFC3164格式
+// formattedSize 估算格式化后的长度，用于一次分配足够的容量
+// 头部的优先级、时间戳和分隔符不超过64字节
+func (m *Message) formattedSize() int {
+	size := 64 + len(m.Hostname) + len(m.Tag) + len(m.PID) + len(m.MsgID) + len(m.StructuredData) + len(m.rawTimestamp) + len(m.Content)
+	if m.SyslogFormat == JSON {
+		// 标准字段名、facility/severity名称和每个附加字段的引号与分隔符
+		size += 96
+		for _, f := range m.Fields {
+			size += len(f.Key) + len(f.Value) + 6
+		}
+	}
+	return size
+}
+
+// appendRFC3164 按RFC3164格式追加消息
 // RFC3164格式规范：
 // <Priority>Timestamp Hostname Tag[PID]: Content
 // 示例：<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed
-func (m *Message) formatRFC3164() string {
-	// RFC3164时间戳格式: Jan 02 15:04:05
-	timestamp := m.Timestamp.Format("Jan 02 15:04:05")
+func (m *Message) appendRFC3164(dst []byte) []byte {
+	dst = appendPriority(dst, m.Priority)
+	// RFC3164时间戳默认格式: Jan 02 15:04:05，部分设备要求的格式不同时由TimeLayout指定
+	layout := m.TimeLayout
+	if layout == "" {
+		layout = RFC3164TimeLayout
+	}
+	dst = m.Timestamp.AppendFormat(dst, layout)
+	dst = append(dst, ' ')
+	dst = append(dst, m.Hostname...)
+	dst = append(dst, ' ')
 
 	// 构建标签部分
 	// 如果有PID，格式为"Tag[PID]"
-	// 如果没有PID，只使用Tag
-	var tagPart string
-	if m.PID != "" {
-		tagPart = fmt.Sprintf("%s[%s]", m.Tag, m.PID)
-	} else {
-		tagPart = m.Tag
-	}
-
-	// 如果标签为空，使用默认标签
-	if tagPart == "" {
-		tagPart = "syslog_go"
+	// 如果没有PID，只使用Tag；标签为空时使用默认标签
+	switch {
+	case m.PID != "":
+		dst = append(dst, m.Tag...)
+		dst = append(dst, '[')
+		dst = append(dst, m.PID...)
+		dst = append(dst, ']')
+	case m.Tag != "":
+		dst = append(dst, m.Tag...)
+	default:
+		dst = append(dst, "syslog_go"...)
 	}
 
-	// 组装最终的消息格式
-	return fmt.Sprintf("<%d>%s %s %s: %s",
-		m.Priority, // 优先级
-		timestamp,  // 时间戳
-		m.Hostname, // 主机名
-		tagPart,    // 标签（可能包含PID）
-		m.Content)  // 消息内容
+	dst = append(dst, ": "...)
+	return append(dst, m.Content...)
 }
 
-// formatRFC5424 格式化为RFC5424格式
+// appendRFC5424 按RFC5424格式追加消息
 // RFC5424格式规范：
 // <Priority>Version Timestamp Hostname App-Name ProcID MsgID Structured-Data Msg
 // 示例：<34>1 2003-10-11T22:14:15.003Z mymachine su - ID47 - 'su root' failed
-func (m *Message) formatRFC5424() string {
-	// RFC5424时间戳格式: 2006-01-02T15:04:05.000Z
-	// 使用UTC时间并格式化为ISO格式
-	timestamp := m.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z")
+func (m *Message) appendRFC5424(dst []byte) []byte {
+	dst = appendPriority(dst, m.Priority)
+	dst = append(dst, "1 "...)
+	dst = m.appendTimestamp5424(dst)
 
-	// 处理各个字段，空值用 "-" 表示
+	// 各个字段空值用 "-"（NILVALUE）表示
 	// RFC5424规定必须字段不能为空，应该用"-"代替
-	hostname := m.Hostname
-	if hostname == "" {
-		hostname = "-"
+	for _, field := range [...]string{m.Hostname, m.Tag, m.PID, m.MsgID, m.StructuredData} {
+		dst = append(dst, ' ')
+		dst = append(dst, nilValue(field)...)
 	}
 
-	appName := m.Tag
-	if appName == "" {
-		appName = "syslog_go"
+	// 消息内容为空时不追加多余的空格
+	if m.Content == "" {
+		return dst
 	}
+	dst = append(dst, ' ')
+	return append(dst, m.Content...)
+}
 
-	procID := m.PID
-	if procID == "" {
-		procID = "-"
-	}
+// appendPriority 追加 <Priority> 部分
+func appendPriority(dst []byte, priority int) []byte {
+	dst = append(dst, '<')
+	dst = strconv.AppendInt(dst, int64(priority), 10)
+	return append(dst, '>')
+}
 
-	msgID := "-"          // 消息ID，通常为空
-	structuredData := "-" // 结构化数据，暂时不支持
+// appendTimestamp5424 追加RFC5424时间戳
+// 解析得到且未被修改的时间戳按原文输出；零值输出"-"；
+// 其余情况使用UTC毫秒精度，格式: 2006-01-02T15:04:05.000Z
+func (m *Message) appendTimestamp5424(dst []byte) []byte {
+	if m.rawTimestamp != "" && m.Timestamp.Equal(m.parsedTimestamp) {
+		return append(dst, m.rawTimestamp...)
+	}
+	if m.Timestamp.IsZero() {
+		return append(dst, '-')
+	}
+	return m.Timestamp.UTC().AppendFormat(dst, "2006-01-02T15:04:05.000Z")
+}
 
-	// 组装最终的消息格式
-	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
-		m.Priority,     // 优先级
-		timestamp,      // ISO格式的时间戳
-		hostname,       // 主机名
-		appName,        // 应用名称
-		procID,         // 进程ID
-		msgID,          // 消息ID
-		structuredData, // 结构化数据
-		m.Content)      // 消息内容
+// nilValue 空字符串返回RFC5424的NILVALUE "-"
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
 
 // ParseRFC3164 解析RFC3164格式的syslog消息
@@ -150,8 +211,14 @@ func (m *Message) formatRFC5424() string {
 //
 // 返回值：
 //   - *Message: 解析成功后的消息对象
-//   - error: 解析过程中的错误，如果格式不正确则返回错误
+//   - error: 解析过程中的错误，包装ErrFormatMismatch、ErrBadPriority或ErrBadTimestamp
 func ParseRFC3164(msg string) (*Message, error) {
+	// 解析优先级（Priority = Facility * 8 + Severity）
+	priority, _, err := parsePriority(msg)
+	if err != nil {
+		return nil, fmt.Errorf("无效的RFC3164格式: %w", err)
+	}
+
 	// 使用正则表达式匹配RFC3164格式
 	// 正则表达式分组：
 	// 1. Priority: 优先级数字
@@ -159,23 +226,19 @@ func ParseRFC3164(msg string) (*Message, error) {
 	// 3. Hostname: 主机名
 	// 4. Tag: 程序名称
 	// 5. PID: 可选的进程ID
-	// 6. Content: 消息内容
-	pattern := regexp.MustCompile(`^<(\d+)>([A-Za-z]{3}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2})\s+([^\s]+)\s+([^:\[]+)(?:\[(\d+)\])?:\s+(.+)$`)
+	// 6. Content: 消息内容，可以跨多行（八位组计数分帧的消息可以包含换行）
+	pattern := regexp.MustCompile(`^<(\d+)>([A-Za-z]{3}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2})\s+([^\s]+)\s+([^:\[]+)(?:\[(\d+)\])?:\s+(?s:(.+))$`)
 	matches := pattern.FindStringSubmatch(msg)
 	if matches == nil {
-		return nil, fmt.Errorf("无效的RFC3164格式")
+		return nil, fmt.Errorf("无效的RFC3164格式: %w", ErrFormatMismatch)
 	}
 
-	// 解析优先级（Priority = Facility * 8 + Severity）
-	var priority int
-	fmt.Sscanf(matches[1], "%d", &priority)
-
 	// 解析时间戳
 	// RFC3164的时间戳不包含年份，需要添加当前年份
 	currentYear := time.Now().Year()
 	timestamp, err := time.Parse("Jan 2 15:04:05 2006", matches[2]+fmt.Sprintf(" %d", currentYear))
 	if err != nil {
-		return nil, fmt.Errorf("无效的时间戳格式: %v", err)
+		return nil, fmt.Errorf("%w: %v", ErrBadTimestamp, err)
 	}
 
 	// 创建并返回消息对象
@@ -202,62 +265,126 @@ func ParseRFC3164(msg string) (*Message, error) {
 //
 // 返回值：
 //   - *Message: 解析成功后的消息对象
-//   - error: 解析过程中的错误，如果格式不正确则返回错误
+//   - error: 解析过程中的错误，包装ErrFormatMismatch、ErrBadPriority、ErrBadVersion、
+//     ErrBadTimestamp或ErrBadStructuredData
+//
+// 结构化数据只检查元素的方括号和引号是否配对，原文保存在StructuredData中；
+// 需要按SD-ID和参数名读取时使用StructuredDataMap或ParseStructuredData
 func ParseRFC5424(msg string) (*Message, error) {
-	// 使用正则表达式匹配RFC5424格式
-	// 正则表达式分组：
-	// 1. Priority: 优先级数字
-	// 2. Timestamp: ISO格式的时间戳
-	// 3. Hostname: 主机名
-	// 4. App-Name: 应用名称
-	// 5. ProcID: 进程ID
-	// 6. MsgID: 消息ID
-	// 7. Structured-Data: 结构化数据
-	// 8. Msg: 消息内容
-	pattern := regexp.MustCompile(`^<(\d+)>1\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s+(.+)$`)
-	matches := pattern.FindStringSubmatch(msg)
-	if matches == nil {
-		return nil, fmt.Errorf("无效的RFC5424格式")
+	// 头部格式: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID
+	// 之后是STRUCTURED-DATA（"-"或若干个[...]元素），最后是可选的MSG
+	priority, rest, err := parsePriority(msg)
+	if err != nil {
+		return nil, fmt.Errorf("无效的RFC5424格式: %w", err)
 	}
 
-	// 解析优先级（Priority = Facility * 8 + Severity）
-	var priority int
-	fmt.Sscanf(matches[1], "%d", &priority)
+	// 版本号及其后的5个头部字段
+	fields := make([]string, 6)
+	for i := range fields {
+		rest = strings.TrimLeft(rest, " ")
+		sp := strings.IndexByte(rest, ' ')
+		if sp <= 0 {
+			return nil, fmt.Errorf("无效的RFC5424格式: %w", ErrFormatMismatch)
+		}
+		fields[i], rest = rest[:sp], rest[sp+1:]
+	}
+	if fields[0] != "1" {
+		return nil, fmt.Errorf("无效的RFC5424格式: %w %s", ErrBadVersion, fields[0])
+	}
 
-	// 解析时间戳（RFC5424使用ISO格式的时间戳）
-	timestamp, err := time.Parse(time.RFC3339, matches[2])
+	// 解析结构化数据
+	rest = strings.TrimLeft(rest, " ")
+	sd, content, err := splitStructuredData(rest)
 	if err != nil {
-		return nil, fmt.Errorf("无效的时间戳格式: %v", err)
+		return nil, err
 	}
 
-	// 处理特殊值（RFC5424使用"-"表示空值）
-	hostname := matches[3]
-	if hostname == "-" {
-		hostname = ""
+	message := &Message{
+		Priority:       priority,                // 优先级
+		Hostname:       fromNilValue(fields[2]), // 主机名
+		Tag:            fromNilValue(fields[3]), // 应用名称
+		PID:            fromNilValue(fields[4]), // 进程ID
+		MsgID:          fromNilValue(fields[5]), // 消息ID
+		StructuredData: sd,                      // 结构化数据原文
+		Content:        content,                 // 消息内容
+		SyslogFormat:   RFC5424,                 // 标记为RFC5424格式
 	}
 
-	appName := matches[4]
-	if appName == "-" {
-		appName = ""
+	// 解析时间戳（RFC5424使用ISO格式的时间戳，"-"表示未知时间）
+	if fields[1] != "-" {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadTimestamp, err)
+		}
+		message.Timestamp = timestamp
+		message.rawTimestamp = fields[1]
+		message.parsedTimestamp = timestamp
 	}
 
-	procID := matches[5]
-	if procID == "-" {
-		procID = ""
+	return message, nil
+}
+
+// fromNilValue 将RFC5424的NILVALUE "-" 转换为空字符串
+func fromNilValue(s string) string {
+	if s == "-" {
+		return ""
 	}
+	return s
+}
 
-	// 创建并返回消息对象
-	message := &Message{
-		Priority:     priority,   // 优先级
-		Timestamp:    timestamp,  // 解析后的时间戳
-		Hostname:     hostname,   // 主机名
-		Tag:          appName,    // 应用名称
-		PID:          procID,     // 进程ID
-		Content:      matches[8], // 消息内容
-		SyslogFormat: RFC5424,    // 标记为RFC5424格式
+// splitStructuredData 从RFC5424头部之后的文本中拆分结构化数据和消息内容
+// 结构化数据为"-"或连续的[SD-ID param="value" ...]元素，参数值中的 \" \] \\ 为转义字符
+// 返回值：
+//   - string: 结构化数据原文，NILVALUE时为空
+//   - string: 消息内容（结构化数据后的单个空格之后的全部文本）
+//   - error: 结构化数据格式错误
+func splitStructuredData(s string) (string, string, error) {
+	var sd string
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "[") {
+		i := 0
+		for i < len(s) && s[i] == '[' {
+			end := sdElementEnd(s[i:])
+			if end < 0 {
+				return "", "", fmt.Errorf("无效的RFC5424格式: %w: 缺少 ]", ErrBadStructuredData)
+			}
+			i += end + 1
+		}
+		sd, s = s[:i], s[i:]
+	} else {
+		return "", "", fmt.Errorf("无效的RFC5424格式: %w: 必须为 - 或 [...]", ErrBadStructuredData)
 	}
 
-	return message, nil
+	// 结构化数据后为可选的 " MSG"
+	if s == "" {
+		return sd, "", nil
+	}
+	if s[0] != ' ' {
+		return "", "", fmt.Errorf("无效的RFC5424格式: %w: 后面缺少空格", ErrBadStructuredData)
+	}
+	return sd, s[1:], nil
+}
+
+// sdElementEnd 返回以 [ 开头的结构化数据元素结束的 ] 的位置，跳过引号内（包括转义）的内容
+// 找不到结束位置时返回-1
+func sdElementEnd(s string) int {
+	inQuote := false
+	for j := 1; j < len(s); j++ {
+		c := s[j]
+		if inQuote {
+			if c == '\\' {
+				j++
+			} else if c == '"' {
+				inQuote = false
+			}
+		} else if c == '"' {
+			inQuote = true
+		} else if c == ']' {
+			return j
+		}
+	}
+	return -1
 }
 
 // SetTimestamp 设置自定义时间戳
@@ -265,6 +392,7 @@ func ParseRFC5424(msg string) (*Message, error) {
 //   - t: 要设置的新时间戳
 func (m *Message) SetTimestamp(t time.Time) {
 	m.Timestamp = t
+	m.rawTimestamp = ""
 }
 
 // SetPID 设置进程ID
@@ -274,6 +402,37 @@ func (m *Message) SetPID(pid string) {
 	m.PID = pid
 }
 
+// ValidateProcID 检查进程ID能否写入消息头
+// RFC5424的PROCID为1到128个可打印ASCII字符（6.2.6）；RFC3164中写在TAG后的方括号内，因此也不能包含方括号
+func ValidateProcID(pid string) error {
+	if pid == "" || len(pid) > 128 {
+		return fmt.Errorf("进程ID长度必须为1-128: %q", pid)
+	}
+	for i := 0; i < len(pid); i++ {
+		if c := pid[i]; c < 33 || c > 126 || c == '[' || c == ']' {
+			return fmt.Errorf("进程ID包含无效字符 %q: %q", c, pid)
+		}
+	}
+	return nil
+}
+
+// ValidateTimeLayout 检查RFC3164时间戳的Go时间格式
+// 格式必须至少包含一个时间元素，按该格式输出的时间戳能用同一格式重新解析，
+// 且不能包含换行（会破坏按行分帧）
+func ValidateTimeLayout(layout string) error {
+	if strings.ContainsAny(layout, "\r\n") {
+		return fmt.Errorf("时间格式不能包含换行: %q", layout)
+	}
+	sample := time.Date(2025, time.March, 7, 9, 5, 4, 123456789, time.UTC).Format(layout)
+	if sample == layout {
+		return fmt.Errorf("时间格式 %q 不包含任何时间元素，请使用Go时间格式，如 %q", layout, RFC3164TimeLayout)
+	}
+	if _, err := time.Parse(layout, sample); err != nil {
+		return fmt.Errorf("时间格式 %q 的输出 %q 无法解析: %v", layout, sample, err)
+	}
+	return nil
+}
+
 // SetHostname 设置主机名
 // 参数：
 //   - hostname: 要设置的主机名字符串
@@ -316,6 +475,28 @@ func (m *Message) GetSeverity() int {
 	return m.Priority & 0x07
 }
 
+// FacilityName 获取设施名称
+// 返回值：
+//   - string: 设施名称，如 "local0"
+func (m *Message) FacilityName() string {
+	return GetFacilityName(m.GetFacility())
+}
+
+// SeverityName 获取严重性名称
+// 返回值：
+//   - string: 严重性名称，如 "info"
+func (m *Message) SeverityName() string {
+	return GetSeverityName(m.GetSeverity())
+}
+
+// PriorityString 返回解码后的优先级描述
+// 返回值：
+//   - string: 同时包含名称和数值的描述，如 "facility=local0(16) severity=info(6)"
+func (m *Message) PriorityString() string {
+	return fmt.Sprintf("facility=%s(%d) severity=%s(%d)",
+		m.FacilityName(), m.GetFacility(), m.SeverityName(), m.GetSeverity())
+}
+
 // GetSyslogFormat 获取消息格式
 // 返回值：
 //   - SyslogFormat: 消息使用的Syslog格式（RFC3164或RFC5424）
@@ -327,7 +508,7 @@ func (m *Message) GetSyslogFormat() SyslogFormat {
 // 返回值：
 //   - []byte: 消息的字节数组表示
 func (m *Message) Bytes() []byte {
-	return []byte(m.Format())
+	return m.AppendFormat(make([]byte, 0, m.formattedSize()))
 }
 
 // String 返回消息的字符串表示
@@ -339,7 +520,7 @@ func (m *Message) String() string {
 
 // ParseFormat 解析格式字符串
 // 参数：
-//   - format: 要解析的格式字符串，支持"rfc3164"、"rfc5424"和"5424"（不区分大小写）
+//   - format: 要解析的格式字符串，支持"rfc3164"、"rfc5424"、"5424"和"json"（不区分大小写）
 //
 // 返回值：
 //   - SyslogFormat: 解析后的Syslog格式，默认返回RFC3164格式
@@ -352,6 +533,8 @@ func ParseFormat(format string) SyslogFormat {
 	switch strings.ToLower(format) {
 	case "rfc5424", "5424":
 		return RFC5424 // 新格式
+	case "json":
+		return JSON // 结构化JSON事件
 	default:
 		return RFC3164 // 默认使用RFC3164格式
 	}
@@ -372,6 +555,10 @@ func GetFacilityName(facility int) string {
 		9:  "cron",
 		10: "authpriv",
 		11: "ftp",
+		12: "ntp",
+		13: "security",
+		14: "console",
+		15: "solaris-cron",
 		16: "local0",
 		17: "local1",
 		18: "local2",
@@ -406,3 +593,102 @@ func GetSeverityName(severity int) string {
 	}
 	return fmt.Sprintf("unknown(%d)", severity)
 }
+
+// severityAliases Severity名称的常见别名
+var severityAliases = map[string]int{
+	"emergency":     0,
+	"panic":         0,
+	"critical":      2,
+	"error":         3,
+	"warn":          4,
+	"informational": 6,
+}
+
+// facilityAliases Facility名称的常见别名
+var facilityAliases = map[string]int{
+	"kern": 0,
+}
+
+// ParseSeverity 解析Severity名称或数值
+// 参数：
+//   - s: Severity名称（如 "err"、"warning"，支持 "error"、"warn" 等别名，不区分大小写）或0-7的数值
+//
+// 返回值：
+//   - int: Severity值（0-7）
+//   - error: 无法识别时返回错误
+func ParseSeverity(s string) (int, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 0 || n > 7 {
+			return 0, fmt.Errorf("Severity必须在0-7范围内: %d", n)
+		}
+		return n, nil
+	}
+	for severity := 0; severity <= 7; severity++ {
+		if GetSeverityName(severity) == name {
+			return severity, nil
+		}
+	}
+	if severity, ok := severityAliases[name]; ok {
+		return severity, nil
+	}
+	return 0, fmt.Errorf("未知的Severity: %s", s)
+}
+
+// ParseFacility 解析Facility名称或数值
+// 参数：
+//   - s: Facility名称（如 "local0"、"auth"，支持 "kern" 等别名，不区分大小写）或0-23的数值
+//
+// 返回值：
+//   - int: Facility值（0-23）
+//   - error: 无法识别时返回错误
+func ParseFacility(s string) (int, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 0 || n > 23 {
+			return 0, fmt.Errorf("Facility必须在0-23范围内: %d", n)
+		}
+		return n, nil
+	}
+	for facility := 0; facility <= 23; facility++ {
+		if GetFacilityName(facility) == name {
+			return facility, nil
+		}
+	}
+	if facility, ok := facilityAliases[name]; ok {
+		return facility, nil
+	}
+	return 0, fmt.Errorf("未知的Facility: %s", s)
+}
+
+// ParsePriorityString 解析 "Facility.Severity" 形式的优先级
+// 参数：
+//   - s: 如 "local0.err"、"auth.warning"，Facility和Severity可以是名称或数值；
+//     也可以直接是0-191的PRI数值
+//
+// 返回值：
+//   - int: 优先级（Facility * 8 + Severity）
+//   - error: 无法识别时返回错误
+func ParsePriorityString(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	facilityName, severityName, ok := strings.Cut(s, ".")
+	if !ok {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("优先级格式应为 Facility.Severity 或0-191的数值: %s", s)
+		}
+		if n < 0 || n > 191 {
+			return 0, fmt.Errorf("优先级必须在0-191范围内: %d", n)
+		}
+		return n, nil
+	}
+	facility, err := ParseFacility(facilityName)
+	if err != nil {
+		return 0, err
+	}
+	severity, err := ParseSeverity(severityName)
+	if err != nil {
+		return 0, err
+	}
+	return facility*8 + severity, nil
+}
@@ -0,0 +1,40 @@
+package syslog
+
+import "testing"
+
+// TestRFC5424RoundTrip 解析后重新格式化的RFC5424消息与原文逐字节相同
+// 样本取自RFC5424第6.5节的示例，以及NILVALUE、时区偏移、转义等常见情况
+func TestRFC5424RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+	}{
+		{"rfc example 1", `<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed for lonvick on /dev/pts/8`},
+		{"rfc example 2 offset and microseconds", `<165>1 2003-08-24T05:14:15.000003-07:00 192.0.2.1 myproc 8710 - - %% It's time to make the do-nuts.`},
+		{"rfc example 3 bom", "<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut=\"3\" eventSource=\"Application\" eventID=\"1011\"] \xEF\xBB\xBFAn application event log entry..."},
+		{"rfc example 4 multiple sd no msg", `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high"]`},
+		{"all nil", `<14>1 - - - - - -`},
+		{"nil header with msg", `<0>1 - - - - - - message with nil header fields`},
+		{"positive offset", `<191>1 2024-02-29T12:00:00+05:30 host app 42 MSG1 - leap day`},
+		{"nanoseconds", `<13>1 2024-01-02T03:04:05.123456789Z host app - - - nanos`},
+		{"no fraction", `<13>1 2024-01-02T03:04:05Z host app - - - whole seconds`},
+		{"escaped sd values", `<13>1 2024-01-02T03:04:05.000Z host app 123 ID1 [req@32473 path="/a\]b" agent="\"curl\" \\ 8"][meta sequenceId="7"] escaped`},
+		{"msgid only", `<86>1 2024-01-02T03:04:05.000Z host sshd - LOGIN - user logged in`},
+		{"content with spaces", `<13>1 2024-01-02T03:04:05.000Z host app - - -   leading and trailing  `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseRFC5424(tt.msg)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if got := m.Format(); got != tt.msg {
+				t.Fatalf("重新格式化为\n%q\n期望\n%q", got, tt.msg)
+			}
+			if got := string(m.AppendFormat(nil)); got != tt.msg {
+				t.Fatalf("AppendFormat输出\n%q\n期望\n%q", got, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package syslog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SDParam RFC5424结构化数据元素中的一个参数
+type SDParam struct {
+	Name  string // 参数名（PARAM-NAME）
+	Value string // 参数值，格式化时自动转义
+}
+
+// sdValueEscaper 转义参数值中的 "、\ 和 ]（RFC5424 6.3.3）
+var sdValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// SDID 生成带企业号的SD-ID
+// 参数：
+//   - name: SD-ID名称，如 origin、meta
+//   - enterpriseID: 私有企业号（IANA PEN）
+//
+// 返回值：
+//   - string: 形如 name@32473 的SD-ID
+func SDID(name string, enterpriseID int) string {
+	return name + "@" + strconv.Itoa(enterpriseID)
+}
+
+// FormatSDElement 格式化一个RFC5424结构化数据元素
+// 参数：
+//   - id: SD-ID，如 origin@32473
+//   - params: 参数列表，按顺序输出，值为空的参数被忽略
+//
+// 返回值：
+//   - string: 形如 [id name="value" ...] 的结构化数据元素
+func FormatSDElement(id string, params ...SDParam) string {
+	var b strings.Builder
+	b.WriteString("[")
+	b.WriteString(id)
+	for _, p := range params {
+		if p.Value == "" {
+			continue
+		}
+		b.WriteString(" ")
+		b.WriteString(p.Name)
+		b.WriteString(`="`)
+		b.WriteString(sdValueEscaper.Replace(p.Value))
+		b.WriteString(`"`)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// AddStructuredData 在消息已有的结构化数据之后追加一个元素
+// 参数：
+//   - element: 已格式化的结构化数据元素，为空时不做任何修改
+func (m *Message) AddStructuredData(element string) {
+	if element == "" {
+		return
+	}
+	if m.StructuredData == "" || m.StructuredData == "-" {
+		m.StructuredData = element
+		return
+	}
+	m.StructuredData += element
+}
+
+// registeredSDIDs IANA注册的SD-ID（RFC5424 7），只有这些SD-ID可以不带 @企业号
+var registeredSDIDs = map[string]bool{"timeQuality": true, "origin": true, "meta": true}
+
+// SDElement 一个RFC5424结构化数据元素
+type SDElement struct {
+	ID     string    // SD-ID，如 meta 或 exampleSDID@32473
+	Params []SDParam // 参数列表，按顺序输出
+}
+
+// String 格式化为 [id name="value" ...]
+// 与FormatSDElement不同，值为空的参数同样输出（RFC5424允许空的PARAM-VALUE）
+func (e SDElement) String() string {
+	var b strings.Builder
+	b.WriteString("[")
+	b.WriteString(e.ID)
+	for _, p := range e.Params {
+		b.WriteString(" ")
+		b.WriteString(p.Name)
+		b.WriteString(`="`)
+		b.WriteString(sdValueEscaper.Replace(p.Value))
+		b.WriteString(`"`)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// ValidateSDName 检查SD-NAME（参数名或SD-ID）的语法
+// 1到32个可打印ASCII字符，不能包含 =、空格、] 和 "（RFC5424 6.3）
+func ValidateSDName(name string) error {
+	if name == "" || len(name) > 32 {
+		return fmt.Errorf("%w: 名称长度必须为1-32: %q", ErrBadStructuredData, name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			return fmt.Errorf("%w: 名称包含无效字符 %q: %q", ErrBadStructuredData, c, name)
+		}
+	}
+	return nil
+}
+
+// ValidateSDID 检查SD-ID的语法
+// 形如 name@企业号（企业号为数字，可带 .子编号），或IANA注册的 timeQuality、origin、meta（RFC5424 7.2.2）
+func ValidateSDID(id string) error {
+	if err := ValidateSDName(id); err != nil {
+		return err
+	}
+	name, pen, ok := strings.Cut(id, "@")
+	if !ok {
+		if !registeredSDIDs[id] {
+			return fmt.Errorf("%w: SD-ID %s 未在IANA注册，请使用 名称@企业号 的形式", ErrBadStructuredData, id)
+		}
+		return nil
+	}
+	if name == "" || strings.Contains(pen, "@") {
+		return fmt.Errorf("%w: SD-ID格式应为 名称@企业号: %s", ErrBadStructuredData, id)
+	}
+	for _, part := range strings.Split(pen, ".") {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return fmt.Errorf("%w: SD-ID中的企业号必须是数字: %s", ErrBadStructuredData, id)
+		}
+	}
+	return nil
+}
+
+// ParseSDElement 解析一个结构化数据元素
+// 参数：
+//   - s: 形如 [id name="value" ...] 的元素，两侧的方括号可以省略；
+//     参数值必须用双引号括起，其中的 "、\ 和 ] 用反斜杠转义
+//
+// 返回值：
+//   - SDElement: 解析后的元素，参数值已去除转义
+//   - error: SD-ID、参数名或参数值格式无效时返回错误
+func ParseSDElement(s string) (SDElement, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		if !strings.HasSuffix(s, "]") {
+			return SDElement{}, fmt.Errorf("%w: 缺少 ]: %s", ErrBadStructuredData, s)
+		}
+		s = s[1 : len(s)-1]
+	}
+
+	id, rest, _ := strings.Cut(s, " ")
+	if err := ValidateSDID(id); err != nil {
+		return SDElement{}, err
+	}
+	element := SDElement{ID: id}
+	seen := make(map[string]bool)
+
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if rest == "" {
+			return element, nil
+		}
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return SDElement{}, fmt.Errorf("%w: 参数格式应为 名称=\"值\": %s", ErrBadStructuredData, rest)
+		}
+		name := rest[:eq]
+		if err := ValidateSDName(name); err != nil {
+			return SDElement{}, err
+		}
+		if seen[name] {
+			return SDElement{}, fmt.Errorf("%w: 参数 %s 重复", ErrBadStructuredData, name)
+		}
+		seen[name] = true
+
+		value, n, err := readSDValue(rest[eq+1:])
+		if err != nil {
+			return SDElement{}, fmt.Errorf("参数 %s: %w", name, err)
+		}
+		element.Params = append(element.Params, SDParam{Name: name, Value: value})
+		rest = rest[eq+1+n:]
+		if rest != "" && rest[0] != ' ' {
+			return SDElement{}, fmt.Errorf("%w: 参数之间必须用空格分隔: %s", ErrBadStructuredData, rest)
+		}
+	}
+}
+
+// readSDValue 读取一个带双引号的参数值，返回去除转义后的值和消耗的字节数
+func readSDValue(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, fmt.Errorf("%w: 参数值必须用双引号括起", ErrBadStructuredData)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			// 只有 "、\ 和 ] 需要转义，其他字符前的反斜杠按原样保留
+			if i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+				i++
+				b.WriteByte(s[i])
+			} else {
+				b.WriteByte(c)
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		case ']':
+			return "", 0, fmt.Errorf("%w: 参数值中的 ] 必须转义为 \\]", ErrBadStructuredData)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("%w: 参数值缺少结束的双引号", ErrBadStructuredData)
+}
+
+// ParseStructuredData 解析RFC5424的STRUCTURED-DATA字段
+// 参数：
+//   - sd: 结构化数据原文，连续的 [id name="value" ...] 元素，"-" 或空字符串表示没有结构化数据
+//
+// 返回值：
+//   - []SDElement: 按原文顺序排列的元素，没有结构化数据时为nil
+//   - error: 元素格式无效或同一SD-ID出现多次（RFC5424 6.3.2）时返回错误
+func ParseStructuredData(sd string) ([]SDElement, error) {
+	if sd == "" || sd == "-" {
+		return nil, nil
+	}
+
+	var elements []SDElement
+	seen := make(map[string]bool)
+	for rest := sd; rest != ""; {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("%w: 元素必须以 [ 开头: %s", ErrBadStructuredData, rest)
+		}
+		end := sdElementEnd(rest)
+		if end < 0 {
+			return nil, fmt.Errorf("%w: 缺少 ]: %s", ErrBadStructuredData, rest)
+		}
+		element, err := ParseSDElement(rest[:end+1])
+		if err != nil {
+			return nil, err
+		}
+		if seen[element.ID] {
+			return nil, fmt.Errorf("%w: SD-ID %s 重复", ErrBadStructuredData, element.ID)
+		}
+		seen[element.ID] = true
+		elements = append(elements, element)
+		rest = rest[end+1:]
+	}
+	return elements, nil
+}
+
+// SetStructuredData 按SD-ID到参数的映射设置消息的结构化数据，替换已有的结构化数据
+// 参数：
+//   - data: SD-ID（如 exampleSDID@32473）到 参数名->参数值 的映射，为空时输出 "-"
+//
+// 返回值：
+//   - error: SD-ID或参数名无效时返回错误，此时消息不做修改
+//
+// 映射没有顺序，元素按SD-ID、参数按参数名排序输出，保证同样的数据总是得到同样的文本；
+// 参数值中的 "、\ 和 ] 自动转义。需要指定顺序时使用SDElement.String和AddStructuredData
+func (m *Message) SetStructuredData(data map[string]map[string]string) error {
+	ids := make([]string, 0, len(data))
+	for id := range data {
+		if err := ValidateSDID(id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var b strings.Builder
+	for _, id := range ids {
+		names := make([]string, 0, len(data[id]))
+		for name := range data[id] {
+			if err := ValidateSDName(name); err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		element := SDElement{ID: id, Params: make([]SDParam, 0, len(names))}
+		for _, name := range names {
+			element.Params = append(element.Params, SDParam{Name: name, Value: data[id][name]})
+		}
+		b.WriteString(element.String())
+	}
+	m.StructuredData = b.String()
+	return nil
+}
+
+// StructuredDataMap 解析消息的结构化数据，返回SD-ID到 参数名->参数值 的映射
+// 参数值已去除转义；没有结构化数据时返回nil。ParseRFC5424只保存结构化数据原文，
+// 读取解析后的消息中的参数时使用此方法
+func (m *Message) StructuredDataMap() (map[string]map[string]string, error) {
+	elements, err := ParseStructuredData(m.StructuredData)
+	if err != nil || elements == nil {
+		return nil, err
+	}
+	data := make(map[string]map[string]string, len(elements))
+	for _, e := range elements {
+		params := make(map[string]string, len(e.Params))
+		for _, p := range e.Params {
+			params[p.Name] = p.Value
+		}
+		data[e.ID] = params
+	}
+	return data, nil
+}
@@ -0,0 +1,195 @@
+package syslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestSDParamValueEscaping 参数值中的 ]、" 和 \ 格式化时转义，解析时还原
+func TestSDParamValueEscaping(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "value", `[id@32473 k="value"]`},
+		{"bracket", "a]b", `[id@32473 k="a\]b"]`},
+		{"quote", `say "hi"`, `[id@32473 k="say \"hi\""]`},
+		{"backslash", `C:\logs`, `[id@32473 k="C:\\logs"]`},
+		{"all", `\"]`, `[id@32473 k="\\\"\]"]`},
+		{"trailing backslash", `a\`, `[id@32473 k="a\\"]`},
+		{"opening bracket", "[x", `[id@32473 k="[x"]`},
+		{"empty", "", `[id@32473 k=""]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			element := SDElement{ID: "id@32473", Params: []SDParam{{Name: "k", Value: tt.value}}}
+			got := element.String()
+			if got != tt.want {
+				t.Fatalf("格式化为 %s，期望 %s", got, tt.want)
+			}
+
+			parsed, err := ParseStructuredData(got)
+			if err != nil {
+				t.Fatalf("解析 %s 失败: %v", got, err)
+			}
+			if len(parsed) != 1 || len(parsed[0].Params) != 1 || parsed[0].Params[0].Value != tt.value {
+				t.Fatalf("解析 %s 得到 %+v，期望参数值 %q", got, parsed, tt.value)
+			}
+		})
+	}
+}
+
+// TestParseSDValueBackslash 不需要转义的字符前的反斜杠按原样保留
+func TestParseSDValueBackslash(t *testing.T) {
+	elements, err := ParseStructuredData(`[id@32473 path="C:\temp" esc="\n"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SDParam{{Name: "path", Value: `C:\temp`}, {Name: "esc", Value: `\n`}}
+	if !reflect.DeepEqual(elements[0].Params, want) {
+		t.Fatalf("参数为 %+v，期望 %+v", elements[0].Params, want)
+	}
+}
+
+// TestParseStructuredDataOrder 多个元素和参数按原文顺序返回，转义的 ] 不结束元素
+func TestParseStructuredDataOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		sd   string
+		want []SDElement
+	}{
+		{"none", "-", nil},
+		{"empty", "", nil},
+		{
+			"single",
+			`[exampleSDID@32473 iut="3" eventSource="Application"]`,
+			[]SDElement{{ID: "exampleSDID@32473", Params: []SDParam{{"iut", "3"}, {"eventSource", "Application"}}}},
+		},
+		{
+			"multiple",
+			`[zeta@32473 b="2" a="1"][meta sequenceId="7"][alpha@32473 x="y"]`,
+			[]SDElement{
+				{ID: "zeta@32473", Params: []SDParam{{"b", "2"}, {"a", "1"}}},
+				{ID: "meta", Params: []SDParam{{"sequenceId", "7"}}},
+				{ID: "alpha@32473", Params: []SDParam{{"x", "y"}}},
+			},
+		},
+		{
+			"escaped bracket between elements",
+			`[first@32473 v="x\]y"][second@32473 v="\"q\" \\"]`,
+			[]SDElement{
+				{ID: "first@32473", Params: []SDParam{{"v", "x]y"}}},
+				{ID: "second@32473", Params: []SDParam{{"v", `"q" \`}}},
+			},
+		},
+		{
+			"no params",
+			`[origin][timeQuality tzKnown="1"]`,
+			[]SDElement{
+				{ID: "origin"},
+				{ID: "timeQuality", Params: []SDParam{{"tzKnown", "1"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStructuredData(tt.sd)
+			if err != nil {
+				t.Fatalf("解析 %s 失败: %v", tt.sd, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("解析 %s 得到 %+v，期望 %+v", tt.sd, got, tt.want)
+			}
+
+			// 按顺序重新格式化得到原文
+			var text string
+			for _, e := range got {
+				text += e.String()
+			}
+			if tt.want != nil && text != tt.sd {
+				t.Fatalf("重新格式化为 %s，期望 %s", text, tt.sd)
+			}
+		})
+	}
+}
+
+// TestParseStructuredDataInvalid 格式无效的结构化数据返回ErrBadStructuredData
+func TestParseStructuredDataInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		sd   string
+	}{
+		{"unescaped bracket", `[id@32473 v="a]b"]`},
+		{"unterminated value", `[id@32473 v="abc]`},
+		{"missing bracket", `[id@32473 v="abc"`},
+		{"duplicate id", `[id@32473 a="1"][id@32473 b="2"]`},
+		{"duplicate param", `[id@32473 a="1" a="2"]`},
+		{"unquoted value", `[id@32473 a=1]`},
+		{"unregistered id", `[custom a="1"]`},
+		{"text between elements", `[id@32473 a="1"] [meta b="2"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseStructuredData(tt.sd); !errors.Is(err, ErrBadStructuredData) {
+				t.Fatalf("解析 %s 返回 %v，期望 ErrBadStructuredData", tt.sd, err)
+			}
+		})
+	}
+}
+
+// TestSetStructuredDataRoundTrip SetStructuredData按SD-ID和参数名排序输出并转义，StructuredDataMap还原
+func TestSetStructuredDataRoundTrip(t *testing.T) {
+	data := map[string]map[string]string{
+		"req@32473":  {"path": `/a]b`, "agent": `"curl" \ 8`},
+		"meta":       {"sequenceId": "1"},
+		"auth@32473": {"user": "alice"},
+	}
+	m := NewMessage(14, "host", "app", "msg", RFC5424)
+	if err := m.SetStructuredData(data); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[auth@32473 user="alice"][meta sequenceId="1"][req@32473 agent="\"curl\" \\ 8" path="/a\]b"]`
+	if m.StructuredData != want {
+		t.Fatalf("结构化数据为 %s，期望 %s", m.StructuredData, want)
+	}
+
+	got, err := m.StructuredDataMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Fatalf("StructuredDataMap 返回 %v，期望 %v", got, data)
+	}
+
+	if err := m.SetStructuredData(map[string]map[string]string{"bad id": {"k": "v"}}); err == nil {
+		t.Fatal("无效的SD-ID应返回错误")
+	}
+	if m.StructuredData != want {
+		t.Fatalf("返回错误时不应修改消息，结构化数据为 %s", m.StructuredData)
+	}
+}
+
+// TestParseRFC5424StructuredData 解析完整消息时保留结构化数据原文，包括转义和元素顺序
+func TestParseRFC5424StructuredData(t *testing.T) {
+	sd := `[b@32473 v="x\]y"][a@32473 q="\"\\"]`
+	m, err := ParseRFC5424(`<14>1 2024-01-02T03:04:05Z host app 123 ID1 ` + sd + ` hello`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.StructuredData != sd || m.Content != "hello" {
+		t.Fatalf("结构化数据为 %s，内容为 %q", m.StructuredData, m.Content)
+	}
+	elements, err := ParseStructuredData(m.StructuredData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elements) != 2 || elements[0].ID != "b@32473" || elements[1].Params[0].Value != `"\` {
+		t.Fatalf("解析结构化数据得到 %+v", elements)
+	}
+}
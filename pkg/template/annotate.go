@@ -0,0 +1,26 @@
+package template
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AnnotationPrefix mock --annotate 写在生成结果末尾的说明行的前缀
+// 发送器读取数据文件时跳过以此开头的行，带说明行的数据集可以直接用 send -D 重放
+const AnnotationPrefix = "# generated "
+
+// FormatAnnotation 生成数据集的说明行，记录生成数量、随机种子和模板，便于重新生成相同的数据
+// 格式: # generated N lines seed=S template="..."，模板按Go字符串字面量加引号，保证说明只占一行
+// 参数：
+//   - count: 生成的消息数量
+//   - seed: 生成时使用的随机种子
+//   - tmpl: 消息模板或模板文件的来源描述
+func FormatAnnotation(count int, seed int64, tmpl string) string {
+	return AnnotationPrefix + strconv.Itoa(count) + " lines seed=" + strconv.FormatInt(seed, 10) +
+		" template=" + strconv.Quote(tmpl)
+}
+
+// IsAnnotation 判断一行是否为FormatAnnotation生成的说明行
+func IsAnnotation(line string) bool {
+	return strings.HasPrefix(line, AnnotationPrefix)
+}
@@ -2,6 +2,7 @@ package template
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -9,12 +10,42 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultConfigFile 默认的自定义变量配置文件，相对于当前工作目录
+const DefaultConfigFile = "template.yml"
+
+// ResolveConfigPath 确定自定义变量配置文件路径
+// 参数：
+//   - path: 用户指定的路径，支持绝对路径和相对路径
+//
+// 返回值：
+//   - string: 实际使用的配置文件路径，没有可用配置时为空字符串
+//   - error: 显式指定的文件不存在时返回错误
+//
+// 说明：
+//
+//	未指定路径时，若当前目录存在 DefaultConfigFile 则使用它
+func ResolveConfigPath(path string) (string, error) {
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("自定义变量配置文件不可用: %w", err)
+		}
+		return path, nil
+	}
+
+	if _, err := os.Stat(DefaultConfigFile); err == nil {
+		return DefaultConfigFile, nil
+	}
+	return "", nil
+}
+
 // Engine 模板引擎结构体，负责处理消息模板和变量替换
 type Engine struct {
 	templateCache map[string]string    // 模板缓存，存储已加载的模板内容
 	parser       *VariableParser      // 变量解析器，用于解析和替换模板中的变量
 	configPath   string              // 自定义变量配置文件路径
 	verbose     bool                // 是否显示详细日志信息
+	out         io.Writer           // 详细日志的输出目标，默认为标准输出
+	loadErr     error               // 加载自定义变量配置文件的错误，加载成功或未提供配置文件时为nil
 }
 
 // NewEngine 创建新的模板引擎实例
@@ -24,8 +55,24 @@ type Engine struct {
 // 返回值：
 //   - *Engine: 创建的模板引擎实例
 func NewEngine(configPath string, verbose bool) *Engine {
+	return NewEngineWithOutput(configPath, verbose, os.Stdout)
+}
+
+// NewEngineWithOutput 创建详细日志输出到指定Writer的模板引擎，便于作为库嵌入其他程序
+// 参数：
+//   - configPath: 自定义变量配置文件路径
+//   - verbose: 是否启用详细日志输出
+//   - out: 详细日志的输出目标，为nil时使用标准输出
+// 返回值：
+//   - *Engine: 创建的模板引擎实例
+func NewEngineWithOutput(configPath string, verbose bool, out io.Writer) *Engine {
+	if out == nil {
+		out = os.Stdout
+	}
+
 	// 创建变量解析器实例
 	parser := NewVariableParser(verbose)
+	parser.out = out
 
 	// 初始化引擎实例
 	e := &Engine{
@@ -33,27 +80,40 @@ func NewEngine(configPath string, verbose bool) *Engine {
 		parser:       parser,
 		configPath:   configPath,
 		verbose:     verbose,
+		out:         out,
 	}
 	
 	// 如果提供了配置文件路径，尝试加载自定义变量
 	if configPath != "" {
 		if e.verbose {
-			fmt.Printf("正在加载配置文件: %s\n", configPath)
+			fmt.Fprintf(e.out, "正在加载配置文件: %s\n", configPath)
 		}
 		if err := e.loadCustomVariables(configPath); err != nil {
+			e.loadErr = err
 			if e.verbose {
-				fmt.Printf("警告: 加载自定义变量配置失败: %v\n", err)
+				fmt.Fprintf(e.out, "警告: 加载自定义变量配置失败: %v\n", err)
 			}
 		} else if e.verbose {
-			fmt.Printf("成功加载自定义变量配置\n")
+			fmt.Fprintf(e.out, "成功加载自定义变量配置\n")
 		}
 	} else if e.verbose {
-		fmt.Printf("未提供配置文件路径\n")
+		fmt.Fprintf(e.out, "未提供配置文件路径\n")
 	}
 	
 	return e
 }
 
+// SetSeed 使用固定种子生成随机变量，便于重复生成相同的数据集，见VariableParser.SetSeed
+func (e *Engine) SetSeed(seed int64) {
+	e.parser.SetSeed(seed)
+}
+
+// LoadError 返回加载自定义变量配置文件的错误
+// 创建引擎时加载失败只在详细模式下提示，需要确认配置有效的调用方（如重新加载模板）据此报告错误
+func (e *Engine) LoadError() error {
+	return e.loadErr
+}
+
 // LoadTemplate 加载模板到缓存
 // 参数：
 //   - name: 模板名称，用于标识模板
@@ -62,6 +122,70 @@ func (e *Engine) LoadTemplate(name, content string) {
 	e.templateCache[name] = content
 }
 
+// StructuredTemplate 结构化模板文件结构（YAML或JSON）
+// 示例：
+//
+//	format: "src=${src} dst=${dst} user=${user}"
+//	fields:
+//	  src: "RANDOM_IP:internal"
+//	  dst: "RANDOM_IP:external"
+//	  user: "CUSTOM_USER"
+type StructuredTemplate struct {
+	Format string            `yaml:"format" json:"format"` // 消息格式，使用 ${字段名} 引用字段
+	Fields map[string]string `yaml:"fields" json:"fields"` // 字段名到变量表达式（VARIABLE[:PARAMS]）的映射
+}
+
+// structuredFieldRegex 匹配结构化模板格式中的字段引用 ${字段名}
+var structuredFieldRegex = regexp.MustCompile(`\$\{\s*([A-Za-z0-9_.-]+)\s*\}`)
+
+// LoadStructuredTemplate 从YAML或JSON文件加载结构化模板
+// 参数：
+//   - name: 模板名称，用于标识模板
+//   - path: 模板文件路径
+//
+// 返回值：
+//   - error: 读取、解析失败或格式中引用了未定义字段时返回错误
+//
+// 说明：
+//
+//	格式中的每个 ${字段名} 会被替换为对应字段的变量表达式 {{VARIABLE[:PARAMS]}}，
+//	生成消息时每个字段都会重新求值。JSON是YAML的子集，因此两种格式都可使用。
+func (e *Engine) LoadStructuredTemplate(name, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取模板文件失败: %w", err)
+	}
+
+	var st StructuredTemplate
+	if err := yaml.Unmarshal(content, &st); err != nil {
+		return fmt.Errorf("解析模板文件失败: %w", err)
+	}
+	if st.Format == "" {
+		return fmt.Errorf("模板文件缺少format字段")
+	}
+
+	// 将字段引用展开为变量表达式
+	var missing []string
+	template := structuredFieldRegex.ReplaceAllStringFunc(st.Format, func(match string) string {
+		field := structuredFieldRegex.FindStringSubmatch(match)[1]
+		expr, ok := st.Fields[field]
+		if !ok {
+			missing = append(missing, field)
+			return match
+		}
+		return "{{" + strings.TrimSpace(expr) + "}}"
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf("format中引用了未定义的字段: %s", strings.Join(missing, ", "))
+	}
+
+	if e.verbose {
+		fmt.Fprintf(e.out, "已加载结构化模板[%s]: %s\n", name, template)
+	}
+	e.LoadTemplate(name, template)
+	return nil
+}
+
 // GenerateMessage 根据模板名称生成消息
 // 参数：
 //   - templateName: 模板名称
@@ -93,36 +217,16 @@ func (e *Engine) SetVariableParser(parser *VariableParser) {
 //   - string: 处理后的字符串，所有变量表达式都被替换为实际值
 //   - error: 处理过程中的错误，如果处理成功则为nil
 // 说明：
-//   变量表达式格式：{{变量名:参数}}
+//   变量表达式格式：{{变量名:参数}}，参数中可以嵌套表达式（见VariableParser.expand）
 //   示例：
 //   - {{timestamp}}
 //   - {{random_int:1,100}}
-//   - {{random_string:10}}
+//   - {{REPEAT:3:{{ENUM:a,b}}}}
 func (e *Engine) processTemplate(template string) (string, error) {
-	// 匹配变量表达式 {{变量名:参数}}
-	varRegex := regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
-
 	// 替换所有变量表达式
-	var lastErr error
-	result := varRegex.ReplaceAllStringFunc(template, func(match string) string {
-		// 提取变量表达式（去除{{}}和空白字符）
-		expr := varRegex.FindStringSubmatch(match)[1]
-
-		// 使用变量解析器生成实际值
-		value, err := e.parser.Parse(expr)
-		if err != nil {
-			// 记录错误信息
-			lastErr = fmt.Errorf("解析变量[%s]失败: %w", expr, err)
-			// 解析失败时保留原始表达式
-			return match
-		}
-
-		return value
-	})
-
-	// 如果处理过程中出现错误，返回错误信息
-	if lastErr != nil {
-		return "", lastErr
+	result, err := e.parser.expand(template)
+	if err != nil {
+		return "", err
 	}
 
 	// 去除结果中的首尾空白字符
@@ -131,11 +235,13 @@ func (e *Engine) processTemplate(template string) (string, error) {
 
 // CustomVariable 自定义变量配置结构
 type CustomVariable struct {
-	Type   string   `yaml:"type"`              // 变量类型（如random_int、random_string等）
-	Values []string `yaml:"values,omitempty"`  // 可选值列表，用于random_choice类型
-	Min    int      `yaml:"min,omitempty"`     // 最小值，用于random_int类型
-	Max    int      `yaml:"max,omitempty"`     // 最大值，用于random_int类型
-	Length int      `yaml:"length,omitempty"`  // 字符串长度，用于random_string类型
+	Type      string   `yaml:"type"`                // 变量类型（如random_int、random_string等）
+	Values    []string `yaml:"values,omitempty"`    // 可选值列表，用于random_choice类型
+	Min       float64  `yaml:"min,omitempty"`       // 最小值，用于random_int和random_float类型
+	Max       float64  `yaml:"max,omitempty"`       // 最大值，用于random_int和random_float类型
+	Length    int      `yaml:"length,omitempty"`    // 字符串长度，用于random_string类型
+	Precision int      `yaml:"precision,omitempty"` // 小数位数，用于random_float类型，未设置时为2
+	Pattern   string   `yaml:"pattern,omitempty"`   // 生成模式，用于pattern类型，如 [A-Z]{3}-[0-9]{4}
 }
 
 // CustomVariableConfig 自定义变量配置文件结构
@@ -154,9 +260,11 @@ type CustomVariableConfig struct {
 //     变量名:
 //       type: 变量类型
 //       values: [可选值列表]  # 用于random_choice类型
-//       min: 最小值          # 用于random_int类型
-//       max: 最大值          # 用于random_int类型
+//       min: 最小值          # 用于random_int和random_float类型
+//       max: 最大值          # 用于random_int和random_float类型
 //       length: 字符串长度    # 用于random_string类型
+//       precision: 小数位数   # 用于random_float类型
+//       pattern: 生成模式     # 用于pattern类型
 func (e *Engine) loadCustomVariables(configPath string) error {
 	// 读取配置文件内容
 	content, err := os.ReadFile(configPath)
@@ -174,7 +282,7 @@ func (e *Engine) loadCustomVariables(configPath string) error {
 	for name, variable := range config.Variables {
 		if err := e.parser.RegisterCustomVariable(name, variable); err != nil {
 			if e.verbose {
-				fmt.Printf("警告: 注册自定义变量[%s]失败: %v\n", name, err)
+				fmt.Fprintf(e.out, "警告: 注册自定义变量[%s]失败: %v\n", name, err)
 			}
 		}
 	}
@@ -0,0 +1,156 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxNestingDepth 变量表达式允许的最大嵌套层数，如 {{REPEAT:2:{{REPEAT:3:x}}}} 为两层
+const maxNestingDepth = 8
+
+// maxRepeatCount REPEAT允许的最大重复次数
+const maxRepeatCount = 1 << 20
+
+// maxRepeatLength REPEAT生成的值的最大字节数，防止嵌套重复生成过大的消息
+const maxRepeatLength = 1 << 20
+
+// expand 替换文本中的变量表达式 {{变量名:参数}}
+// 表达式按花括号配对查找，参数中可以嵌套完整的表达式（如 {{REPEAT:3:{{ENUM:a,b}}}}），
+// 嵌套的表达式由外层变量自行求值。生成的值不会再次展开，值中的 {{ 原样保留，
+// 因此展开总会结束。求值失败的表达式保留原文，返回最后一个错误
+func (p *VariableParser) expand(text string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	var sb strings.Builder
+	var lastErr error
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			break
+		}
+		n, err := matchExpression(text[start:])
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			// 不是完整的表达式，跳过一个字符继续查找（如 {{{X}}} 中的第一个花括号）
+			sb.WriteString(text[:start+1])
+			text = text[start+1:]
+			continue
+		}
+
+		match := text[start : start+n]
+		expr := strings.TrimSpace(match[2 : n-2])
+		sb.WriteString(text[:start])
+		if value, err := p.Parse(expr); err != nil {
+			lastErr = fmt.Errorf("解析变量[%s]失败: %w", expr, err)
+			sb.WriteString(match)
+		} else {
+			sb.WriteString(value)
+		}
+		text = text[start+n:]
+	}
+	sb.WriteString(text)
+
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return sb.String(), nil
+}
+
+// matchExpression 返回以 {{ 开头的s中第一个完整表达式的长度（包括首尾花括号）
+// 表达式内不能出现单独的花括号，也不能为空或以花括号开头，此时返回0；嵌套超过上限时返回错误
+func matchExpression(s string) (int, error) {
+	if inner := strings.TrimSpace(s[2:]); inner == "" || inner[0] == '{' || inner[0] == '}' {
+		return 0, nil
+	}
+
+	depth := 0
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			depth++
+			if depth > maxNestingDepth {
+				return 0, fmt.Errorf("变量表达式嵌套超过 %d 层", maxNestingDepth)
+			}
+			i += 2
+		case strings.HasPrefix(s[i:], "}}"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i, nil
+			}
+		case s[i] == '{' || s[i] == '}':
+			return 0, nil
+		default:
+			i++
+		}
+	}
+	return 0, nil
+}
+
+// generateRepeat 将内容重复指定次数
+// 参数格式: "次数[,once|each]:内容"
+// 示例:
+//   - "5:X" - 生成 XXXXX
+//   - "3:{{ENUM:a,b}}" - 内容中的表达式只求值一次，重复同一个值，如 aaa
+//   - "3,each:{{ENUM:a,b}}" - 每次重复都重新求值，如 aba
+//
+// 参数:
+//   - params: 重复次数、求值方式和内容，内容可以包含嵌套的变量表达式
+//
+// 返回值:
+//   - string: 重复后的内容
+//   - error: 参数格式错误、内容求值失败或结果超过长度上限
+func (p *VariableParser) generateRepeat(params string) (string, error) {
+	spec, content, ok := strings.Cut(params, ":")
+	if !ok {
+		return "", fmt.Errorf("missing content for REPEAT, expected REPEAT:count:content")
+	}
+
+	countStr, mode, _ := strings.Cut(spec, ",")
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil || count < 0 {
+		return "", fmt.Errorf("invalid REPEAT count: %s", countStr)
+	}
+	if count > maxRepeatCount {
+		return "", fmt.Errorf("REPEAT count %d exceeds the limit of %d", count, maxRepeatCount)
+	}
+
+	each := false
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "once":
+	case "each":
+		each = true
+	default:
+		return "", fmt.Errorf("invalid REPEAT mode: %s, expected once or each", mode)
+	}
+
+	// 内容不含表达式时每次求值的结果都相同，按once处理
+	if !each || !strings.Contains(content, "{{") {
+		value, err := p.expand(content)
+		if err != nil {
+			return "", err
+		}
+		if int64(len(value))*int64(count) > maxRepeatLength {
+			return "", fmt.Errorf("REPEAT result exceeds %d bytes", maxRepeatLength)
+		}
+		return strings.Repeat(value, count), nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < count; i++ {
+		value, err := p.expand(content)
+		if err != nil {
+			return "", err
+		}
+		if sb.Len()+len(value) > maxRepeatLength {
+			return "", fmt.Errorf("REPEAT result exceeds %d bytes", maxRepeatLength)
+		}
+		sb.WriteString(value)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,161 @@
+// Package netgen 生成模板变量使用的IPv4地址和MAC地址
+// 所有随机生成函数都使用调用方传入的随机数生成器，相同种子得到相同的结果；
+// 内网、外网和网段内随机地址共用同一套RFC1918地址段和主机号规则
+package netgen
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// privateIPv4Blocks RFC1918定义的内网地址段
+var privateIPv4Blocks = []struct {
+	network uint32
+	mask    int
+}{
+	{10 << 24, 8},
+	{172<<24 | 16<<16, 12},
+	{192<<24 | 168<<16, 16},
+}
+
+// inIPv4Block 判断地址是否属于 network/mask 网段
+func inIPv4Block(ip, network uint32, mask int) bool {
+	shift := uint(32 - mask)
+	return ip>>shift == network>>shift
+}
+
+// IsPrivateIPv4 判断地址是否属于RFC1918内网地址段
+func IsPrivateIPv4(ip uint32) bool {
+	for _, private := range privateIPv4Blocks {
+		if inIPv4Block(ip, private.network, private.mask) {
+			return true
+		}
+	}
+	return false
+}
+
+// InPrivateNetwork 判断网段是否整个位于某个RFC1918内网地址段之内
+// 网段的掩码不能比所在内网地址段更短，如 10.1/16 属于内网，10/7 不属于
+func InPrivateNetwork(network uint32, hostBits int) bool {
+	for _, private := range privateIPv4Blocks {
+		if 32-hostBits >= private.mask && inIPv4Block(network, private.network, private.mask) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIPv4CIDR 解析IPv4 CIDR
+// 地址部分允许省略末尾的段（如 10/8、172.16/12），省略的段按0补齐
+//
+// 返回值:
+//   - uint32: 网络地址（已按掩码清零主机位）
+//   - int: 主机位数
+//   - error: 格式错误时返回错误
+func ParseIPv4CIDR(cidr string) (uint32, int, error) {
+	// 分割IP地址和掩码长度
+	addr, maskText, found := strings.Cut(strings.TrimSpace(cidr), "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid CIDR format")
+	}
+
+	// 解析掩码
+	mask, err := strconv.Atoi(maskText)
+	if err != nil || mask < 0 || mask > 32 {
+		return 0, 0, fmt.Errorf("invalid network mask in CIDR")
+	}
+
+	// 解析IP地址，省略的段按0补齐
+	octets := strings.Split(addr, ".")
+	if len(octets) > 4 {
+		return 0, 0, fmt.Errorf("invalid IP address in CIDR")
+	}
+	var baseIP uint32
+	for i := 0; i < 4; i++ {
+		n := 0
+		if i < len(octets) {
+			n, err = strconv.Atoi(octets[i])
+			if err != nil || n < 0 || n > 255 {
+				return 0, 0, fmt.Errorf("invalid IP address: %s", addr)
+			}
+		}
+		baseIP = baseIP<<8 | uint32(n)
+	}
+
+	hostBits := 32 - mask
+	if hostBits == 32 {
+		return 0, hostBits, nil
+	}
+	return baseIP & (uint32(0xFFFFFFFF) << uint(hostBits)), hostBits, nil
+}
+
+// FormatIPv4 将32位整数转换为点分十进制格式
+func FormatIPv4(ip uint32) string {
+	return fmt.Sprintf("%d.%d.%d.%d",
+		(ip>>24)&255,
+		(ip>>16)&255,
+		(ip>>8)&255,
+		ip&255)
+}
+
+// RandomIPv4 生成完全随机的IPv4地址，每段取值范围为[0,255]
+func RandomIPv4(random *rand.Rand) string {
+	return fmt.Sprintf("%d.%d.%d.%d",
+		random.Intn(256),
+		random.Intn(256),
+		random.Intn(256),
+		random.Intn(256))
+}
+
+// RandomIPv4InNetwork 在网段内随机选择一个地址
+// 网段大于/31时避开网络地址和广播地址，主机号范围为 [1, 2^hostBits-2]
+func RandomIPv4InNetwork(random *rand.Rand, network uint32, hostBits int) string {
+	ip := network
+	if hostBits > 1 {
+		ip |= uint32(random.Int63n(int64(1)<<uint(hostBits)-2)) + 1
+	} else if hostBits == 1 {
+		ip |= uint32(random.Intn(2))
+	}
+	return FormatIPv4(ip)
+}
+
+// InternalIPv4 生成随机的内网IP地址
+// 从三种内网地址段中等概率选择一个，再在段内随机生成（避开网络地址和广播地址）：
+//   - 10.0.0.0/8 (10.0.0.0 - 10.255.255.255)
+//   - 172.16.0.0/12 (172.16.0.0 - 172.31.255.255)
+//   - 192.168.0.0/16 (192.168.0.0 - 192.168.255.255)
+func InternalIPv4(random *rand.Rand) string {
+	block := privateIPv4Blocks[random.Intn(len(privateIPv4Blocks))]
+	return RandomIPv4InNetwork(random, block.network, 32-block.mask)
+}
+
+// ExternalIPv4 生成随机的外网IP地址
+// 生成规则：
+//  1. 第一段: 1-223，排除0(保留)和127(回环地址)
+//  2. 排除RFC1918内网地址段（10/8、172.16/12、192.168/16）
+//  3. 最后一段: 1-254，排除0和255
+func ExternalIPv4(random *rand.Rand) string {
+	// 循环生成直到得到有效的外网IP地址
+	for {
+		// 生成第一段，范围1-223
+		// 排除0(保留地址)和127(回环地址)
+		a := random.Intn(223) + 1
+		if a == 127 {
+			continue
+		}
+
+		// 生成剩余段
+		b := random.Intn(256)     // 第二段: 0-255
+		c := random.Intn(256)     // 第三段: 0-255
+		d := random.Intn(254) + 1 // 第四段: 1-254，避免使用0和255
+
+		// 排除所有内网地址段
+		ip := uint32(a)<<24 | uint32(b)<<16 | uint32(c)<<8 | uint32(d)
+		if IsPrivateIPv4(ip) {
+			continue
+		}
+		return FormatIPv4(ip)
+	}
+}
@@ -0,0 +1,50 @@
+package netgen
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// DefaultMACOUI 顺序生成MAC地址未指定OUI时使用的前三个字节，
+// 第一个字节设置了本地管理位，不会与真实厂商的地址冲突
+var DefaultMACOUI = [3]byte{0x02, 0x00, 0x00}
+
+// RandomMAC 生成随机的MAC地址
+// 格式: XX:XX:XX:XX:XX:XX，其中X为十六进制数字，如 12:34:56:78:9a:bc
+func RandomMAC(random *rand.Rand) string {
+	// 生成6字节的随机数据作为MAC地址
+	mac := make([]byte, 6)
+	random.Read(mac)
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x",
+		mac[0], mac[1], mac[2], mac[3], mac[4], mac[5])
+}
+
+// SequentialMAC 生成以oui为前三个字节、序号n为后三个字节的MAC地址
+// 后三个字节共16777216个地址，n超出后从头循环
+func SequentialMAC(oui [3]byte, n int64) string {
+	suffix := uint32(n % (1 << 24))
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x",
+		oui[0], oui[1], oui[2], byte(suffix>>16), byte(suffix>>8), byte(suffix))
+}
+
+// ParseMACOUI 解析MAC地址的前三个字节，如 aa:bb:cc 或 AA-BB-CC
+func ParseMACOUI(s string) ([3]byte, error) {
+	var oui [3]byte
+	octets := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '-' })
+	if len(octets) != 3 {
+		return oui, fmt.Errorf("invalid MAC OUI: %s, expected three octets like aa:bb:cc", s)
+	}
+	for i, octet := range octets {
+		if len(octet) != 2 {
+			return oui, fmt.Errorf("invalid MAC OUI: %s, expected three octets like aa:bb:cc", s)
+		}
+		b, err := strconv.ParseUint(octet, 16, 8)
+		if err != nil {
+			return oui, fmt.Errorf("invalid MAC OUI: %s, expected three octets like aa:bb:cc", s)
+		}
+		oui[i] = byte(b)
+	}
+	return oui, nil
+}
@@ -0,0 +1,230 @@
+package netgen
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testNetwork 192.0.2.0/24（RFC5737文档地址段）
+const testNetwork = 192<<24 | 2<<8
+
+// TestSeededSequence 固定种子下按相同顺序调用得到相同的结果
+// 修改生成规则（如随机数的抽取次数或顺序）会改变这些值，需要确认是有意的行为变化
+func TestSeededSequence(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name     string
+		generate func() string
+		want     string
+	}{
+		{"internal", func() string { return InternalIPv4(random) }, "192.168.0.40"},
+		{"internal", func() string { return InternalIPv4(random) }, "192.168.69.200"},
+		{"internal", func() string { return InternalIPv4(random) }, "172.29.209.237"},
+		{"external", func() string { return ExternalIPv4(random) }, "140.172.72.123"},
+		{"external", func() string { return ExternalIPv4(random) }, "209.175.162.32"},
+		{"external", func() string { return ExternalIPv4(random) }, "36.26.139.142"},
+		{"network", func() string { return RandomIPv4InNetwork(random, testNetwork, 8) }, "192.0.2.149"},
+		{"network", func() string { return RandomIPv4InNetwork(random, testNetwork, 8) }, "192.0.2.94"},
+		{"network", func() string { return RandomIPv4InNetwork(random, testNetwork, 8) }, "192.0.2.216"},
+		{"mac", func() string { return RandomMAC(random) }, "44:86:15:bb:da:08"},
+		{"mac", func() string { return RandomMAC(random) }, "31:3f:6a:8e:b6:68"},
+		{"any", func() string { return RandomIPv4(random) }, "146.127.43.47"},
+		{"any", func() string { return RandomIPv4(random) }, "248.54.247.53"},
+	}
+
+	for i, tt := range tests {
+		if got := tt.generate(); got != tt.want {
+			t.Fatalf("第 %d 次调用 (%s) 得到 %s，期望 %s", i+1, tt.name, got, tt.want)
+		}
+	}
+}
+
+// parseIPv4 将测试生成的点分十进制地址转换为32位整数
+func parseIPv4(t *testing.T, s string) uint32 {
+	t.Helper()
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		t.Fatalf("地址格式无效: %s", s)
+	}
+	var ip uint32
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || n > 255 {
+			t.Fatalf("地址格式无效: %s", s)
+		}
+		ip = ip<<8 | uint32(n)
+	}
+	return ip
+}
+
+// TestInternalIPv4 内网地址覆盖三个RFC1918地址段，不生成网络地址和广播地址
+func TestInternalIPv4(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	blocks := make(map[int]int)
+	for i := 0; i < 3000; i++ {
+		s := InternalIPv4(random)
+		ip := parseIPv4(t, s)
+		if !IsPrivateIPv4(ip) {
+			t.Fatalf("%s 不是内网地址", s)
+		}
+		for j, private := range privateIPv4Blocks {
+			if inIPv4Block(ip, private.network, private.mask) {
+				blocks[j]++
+				hostMask := uint32(1)<<uint(32-private.mask) - 1
+				if host := ip & hostMask; host == 0 || host == hostMask {
+					t.Fatalf("%s 是网络地址或广播地址", s)
+				}
+			}
+		}
+	}
+	// 三个地址段等概率选择
+	for j := range privateIPv4Blocks {
+		if blocks[j] < 800 {
+			t.Fatalf("3000次中第 %d 个地址段只出现 %d 次: %v", j+1, blocks[j], blocks)
+		}
+	}
+}
+
+// TestExternalIPv4 外网地址不在内网地址段、不是0和127开头，最后一段为1-254
+func TestExternalIPv4(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	for i := 0; i < 3000; i++ {
+		s := ExternalIPv4(random)
+		ip := parseIPv4(t, s)
+		first, last := ip>>24, ip&255
+		if IsPrivateIPv4(ip) || first == 0 || first == 127 || first > 223 || last == 0 || last == 255 {
+			t.Fatalf("%s 不是有效的外网地址", s)
+		}
+	}
+}
+
+// TestRandomIPv4InNetwork 随机地址在网段内，大于/31时避开网络地址和广播地址
+func TestRandomIPv4InNetwork(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  uint32
+		hostBits int
+		min, max uint32
+	}{
+		{"/24", testNetwork, 8, testNetwork + 1, testNetwork + 254},
+		{"/30", testNetwork, 2, testNetwork + 1, testNetwork + 2},
+		{"/31", testNetwork, 1, testNetwork, testNetwork + 1},
+		{"/32", testNetwork + 7, 0, testNetwork + 7, testNetwork + 7},
+	}
+
+	random := rand.New(rand.NewSource(4))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 200; i++ {
+				s := RandomIPv4InNetwork(random, tt.network, tt.hostBits)
+				if ip := parseIPv4(t, s); ip < tt.min || ip > tt.max {
+					t.Fatalf("%s 不在 %s - %s 之内", s, FormatIPv4(tt.min), FormatIPv4(tt.max))
+				}
+			}
+		})
+	}
+}
+
+// TestParseIPv4CIDR CIDR地址部分可以省略末尾的段，主机位清零
+func TestParseIPv4CIDR(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		network  string
+		hostBits int
+		wantErr  bool
+	}{
+		{"192.0.2.0/24", "192.0.2.0", 8, false},
+		{"192.0.2.77/24", "192.0.2.0", 8, false},
+		{"10/8", "10.0.0.0", 24, false},
+		{"172.16/12", "172.16.0.0", 20, false},
+		{" 10.1.2.3/32 ", "10.1.2.3", 0, false},
+		{"1.2.3.4/0", "0.0.0.0", 32, false},
+		{"10.0.0.0", "", 0, true},
+		{"10.0.0.0/33", "", 0, true},
+		{"10.0.0.256/8", "", 0, true},
+		{"1.2.3.4.5/8", "", 0, true},
+		{"a.b.c.d/8", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		network, hostBits, err := ParseIPv4CIDR(tt.cidr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q 应返回错误", tt.cidr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q 解析失败: %v", tt.cidr, err)
+			continue
+		}
+		if FormatIPv4(network) != tt.network || hostBits != tt.hostBits {
+			t.Errorf("%q 解析为 %s（主机位 %d），期望 %s（主机位 %d）",
+				tt.cidr, FormatIPv4(network), hostBits, tt.network, tt.hostBits)
+		}
+	}
+}
+
+// TestInPrivateNetwork 子网必须整个位于某个内网地址段之内
+func TestInPrivateNetwork(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want bool
+	}{
+		{"10/8", true},
+		{"10.1/16", true},
+		{"172.16/12", true},
+		{"172.20.5.0/24", true},
+		{"192.168.10.0/24", true},
+		{"10/7", false},
+		{"172.16/11", false},
+		{"172.32/16", false},
+		{"192.0.2.0/24", false},
+	}
+	for _, tt := range tests {
+		network, hostBits, err := ParseIPv4CIDR(tt.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := InPrivateNetwork(network, hostBits); got != tt.want {
+			t.Errorf("InPrivateNetwork(%s) = %v，期望 %v", tt.cidr, got, tt.want)
+		}
+	}
+}
+
+// TestSequentialMAC 顺序MAC地址的后三个字节按序号递增，用完后循环
+func TestSequentialMAC(t *testing.T) {
+	tests := []struct {
+		oui  [3]byte
+		n    int64
+		want string
+	}{
+		{DefaultMACOUI, 0, "02:00:00:00:00:00"},
+		{DefaultMACOUI, 1, "02:00:00:00:00:01"},
+		{DefaultMACOUI, 256, "02:00:00:00:01:00"},
+		{[3]byte{0xaa, 0xbb, 0xcc}, 1<<24 - 1, "aa:bb:cc:ff:ff:ff"},
+		{[3]byte{0xaa, 0xbb, 0xcc}, 1 << 24, "aa:bb:cc:00:00:00"},
+	}
+	for _, tt := range tests {
+		if got := SequentialMAC(tt.oui, tt.n); got != tt.want {
+			t.Errorf("SequentialMAC(%x, %d) = %s，期望 %s", tt.oui, tt.n, got, tt.want)
+		}
+	}
+}
+
+// TestParseMACOUI OUI可以用冒号或连字符分隔，大小写均可
+func TestParseMACOUI(t *testing.T) {
+	for _, s := range []string{"aa:bb:cc", "AA-BB-CC", "aA:Bb-cc"} {
+		oui, err := ParseMACOUI(s)
+		if err != nil || oui != [3]byte{0xaa, 0xbb, 0xcc} {
+			t.Errorf("ParseMACOUI(%q) = %x, %v", s, oui, err)
+		}
+	}
+	for _, s := range []string{"", "aa:bb", "aa:bb:cc:dd", "a:bb:cc", "gg:00:00", "aabbcc"} {
+		if _, err := ParseMACOUI(s); err == nil {
+			t.Errorf("ParseMACOUI(%q) 应返回错误", s)
+		}
+	}
+}
@@ -7,32 +7,79 @@ import (
 	cryptorand "crypto/rand"
 	// encoding/binary 用于字节序列的二进制转换
 	"encoding/binary"
+	// encoding/json 用于JSON字符串转义
+	"encoding/json"
 	// fmt 用于格式化输出和错误处理
 	"fmt"
+	// io 用于详细日志的输出目标
+	"io"
+	// math 用于浮点数判断
+	"math"
 	// math/rand 用于生成伪随机数
 	"math/rand"
+	// os 用于读取环境变量和FILE变量的文件
+	"os"
 	// strconv 用于字符串和基本数据类型之间的转换
 	"strconv"
 	// strings 用于字符串处理
 	"strings"
+	// sync 用于保护FILE变量的文件缓存
+	"sync"
 	// sync/atomic 用于原子操作
 	"sync/atomic"
 	// time 用于时间相关操作
 	"time"
+
+	// syslog 用于解析PRI指令中的Facility和Severity
+	"syslog_go/pkg/syslog"
+	// netgen 用于生成IPv4地址和MAC地址
+	"syslog_go/pkg/template/netgen"
 )
 
-// globalCounter 用于生成连续IP地址的全局计数器
+// globalCounter 为每次生成的随机数生成器提供不同种子的全局计数器
 // 通过原子操作确保在并发环境下的安全性
 var globalCounter int64
 
+// rangeCounters RANGE_IP顺序生成使用的计数器，键为变量参数（如 "192.168.1.0/24"），值为*int64
+// 每个范围独立计数，同一个 {{RANGE_IP:...}} 总是从范围起点依次生成，
+// 不受同一模板中其他变量（包括其他范围的RANGE_IP）的影响；参数相同的变量共用一个计数器
+var rangeCounters sync.Map
+
+// nextRangeIndex 返回参数对应范围的下一个序号，从0开始
+func nextRangeIndex(params string) int64 {
+	counter, ok := rangeCounters.Load(params)
+	if !ok {
+		counter, _ = rangeCounters.LoadOrStore(params, new(int64))
+	}
+	return atomic.AddInt64(counter.(*int64), 1) - 1
+}
+
+// macCounter MAC:seq 顺序生成MAC地址的计数器，与globalCounter相互独立，
+// 保证同一次运行中生成的MAC地址唯一且有序
+var macCounter int64
+
+// seqCounter SEQ变量使用的消息序号计数器，与globalCounter相互独立，
+// 只在生成SEQ时递增，保证序号连续，供接收端检测丢包和乱序
+var seqCounter int64
+
 // VariableParser 变量解析器结构体，负责处理模板中的变量替换
 type VariableParser struct {
 	// random 随机数生成器，用于生成各种随机值
 	random *rand.Rand
 	// customVariables 存储注册的自定义变量，键为变量名（大写），值为变量配置
 	customVariables map[string]CustomVariable
+	// patterns 存储pattern类型自定义变量编译后的生成单元，键为变量名（大写）
+	patterns map[string][]patternToken
 	// verbose 是否启用详细日志输出
 	verbose bool
+	// out 详细日志的输出目标，默认为标准输出
+	out io.Writer
+	// fileLines FILE变量读取的文件内容缓存，键为文件路径，值为文件中的非空行
+	fileLines map[string][]string
+	// fileMu 保护fileLines，多个发送协程可能同时首次读取文件
+	fileMu sync.Mutex
+	// seeded 是否使用固定种子，为true时所有随机数都由random派生，相同种子生成相同的序列
+	seeded bool
 }
 
 // NewVariableParser 创建并初始化一个新的变量解析器实例
@@ -45,13 +92,25 @@ func NewVariableParser(verbose bool) *VariableParser {
 	return &VariableParser{
 		// 初始化自定义变量映射
 		customVariables: make(map[string]CustomVariable),
+		// 初始化pattern类型变量的编译结果
+		patterns: make(map[string][]patternToken),
+		// 初始化FILE变量的文件缓存
+		fileLines: make(map[string][]string),
 		// 使用当前时间戳作为种子初始化随机数生成器
 		random: rand.New(rand.NewSource(time.Now().UnixNano())),
 		// 设置日志输出级别
 		verbose: verbose,
+		out:     os.Stdout,
 	}
 }
 
+// SetSeed 使用固定种子初始化随机数生成器，相同种子和模板按相同顺序生成相同的随机值
+// 时间、SEQ等与随机数无关的变量不受影响；使用固定种子时解析器不能被多个协程并发使用
+func (p *VariableParser) SetSeed(seed int64) {
+	p.random = rand.New(rand.NewSource(seed))
+	p.seeded = true
+}
+
 // RegisterCustomVariable 注册一个自定义变量到解析器中
 // 参数:
 //   - name: 变量名，将被自动转换为大写
@@ -64,6 +123,8 @@ func NewVariableParser(verbose bool) *VariableParser {
 //   - random_choice: 从给定的值列表中随机选择一个
 //   - random_int: 生成指定范围内的随机整数
 //   - random_string: 生成指定长度的随机字符串
+//   - random_float: 生成指定范围内的随机浮点数，按precision保留小数位
+//   - pattern: 根据简化的正则模式（字符类+量词）生成结构化字符串
 func (p *VariableParser) RegisterCustomVariable(name string, variable CustomVariable) error {
 	// 验证变量配置
 	switch variable.Type {
@@ -77,11 +138,31 @@ func (p *VariableParser) RegisterCustomVariable(name string, variable CustomVari
 		if variable.Min >= variable.Max {
 			return fmt.Errorf("random_int类型变量的min必须小于max")
 		}
+		// min和max必须为整数
+		if variable.Min != math.Trunc(variable.Min) || variable.Max != math.Trunc(variable.Max) {
+			return fmt.Errorf("random_int类型变量的min和max必须为整数")
+		}
 	case "random_string":
 		// 确保random_string类型变量的长度大于0
 		if variable.Length <= 0 {
 			return fmt.Errorf("random_string类型变量的length必须大于0")
 		}
+	case "random_float":
+		// 确保random_float类型变量的最小值小于最大值
+		if variable.Min >= variable.Max {
+			return fmt.Errorf("random_float类型变量的min必须小于max")
+		}
+		// 小数位数不能为负数
+		if variable.Precision < 0 {
+			return fmt.Errorf("random_float类型变量的precision不能为负数")
+		}
+	case "pattern":
+		// 确保pattern类型变量的模式可以被编译
+		tokens, err := compilePattern(variable.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern类型变量的模式无效: %w", err)
+		}
+		p.patterns[strings.ToUpper(name)] = tokens
 	default:
 		// 不支持的变量类型
 		return fmt.Errorf("不支持的变量类型: %s", variable.Type)
@@ -92,7 +173,7 @@ func (p *VariableParser) RegisterCustomVariable(name string, variable CustomVari
 	p.customVariables[name] = variable
 	// 如果启用了详细日志，输出注册信息
 	if p.verbose {
-		fmt.Printf("注册自定义变量: %s, 类型: %s\n", name, variable.Type)
+		fmt.Fprintf(p.out, "注册自定义变量: %s, 类型: %s\n", name, variable.Type)
 	}
 	return nil
 }
@@ -104,6 +185,11 @@ func (p *VariableParser) RegisterCustomVariable(name string, variable CustomVari
 // 返回值:
 //   - *rand.Rand: 初始化后的随机数生成器
 func (p *VariableParser) newRandom() *rand.Rand {
+	// 使用固定种子时从主生成器派生，保证结果可重复
+	if p.seeded {
+		return rand.New(rand.NewSource(p.random.Int63()))
+	}
+
 	// 尝试使用crypto/rand生成真随机数作为种子
 	seed := make([]byte, 8)
 	_, err := cryptorand.Read(seed)
@@ -129,6 +215,9 @@ func (p *VariableParser) newRandom() *rand.Rand {
 //   - RANDOM_STRING:10 - 生成长度为10的随机字符串
 //   - RANDOM_INT:1,100 - 生成1到100之间的随机整数
 //   - ENUM:apple,banana,orange - 从给定列表中随机选择一个值
+//   - FILE:/path/to/list.txt - 从文件中随机选择一行
+//   - PRI:local0.err - 消息优先级指令，原样保留，由发送器识别并移除（见CutPriDirective）
+//   - LEVEL - 与消息Severity对应的日志级别（如ERROR），原样保留，由发送器确定优先级后替换（见FillLevel）
 //   - CUSTOM_VAR - 使用自定义变量配置生成值
 //
 // 参数:
@@ -151,65 +240,55 @@ func (p *VariableParser) Parse(expr string) (string, error) {
 	}
 
 	// 优先检查是否是自定义变量
-	if variable, ok := p.customVariables[varName]; ok {
+	if _, ok := p.customVariables[varName]; ok {
 		// 根据自定义变量类型生成值
-		switch variable.Type {
-		case "random_choice":
-			// 从预定义的值列表中随机选择一个
-			return variable.Values[p.random.Intn(len(variable.Values))], nil
-		case "random_int":
-			// 生成指定范围内的随机整数
-			return fmt.Sprintf("%d", p.random.Intn(variable.Max-variable.Min)+variable.Min), nil
-		case "random_string":
-			// 生成指定长度的随机字符串
-			return p.generateRandomString(fmt.Sprintf("%d", variable.Length))
-		default:
-			// 不支持的变量类型
-			return "", fmt.Errorf("不支持的变量类型: %s", variable.Type)
-		}
+		return p.generateCustomVariable(varName)
 	}
 
-	// 根据变量类型生成值
-	switch varName {
-	case "RANDOM_STRING":
-		return p.generateRandomString(params)
-	case "RANDOM_INT":
-		return p.generateRandomInt(params)
-	case "ENUM":
-		return p.generateEnum(params)
-	case "MAC":
-		return p.generateMAC()
-	case "RANGE_IP":
-		// 自动识别IPv6地址
-		if strings.Contains(params, ":") {
-			return p.generateRangeIPv6(params)
-		}
-		// IPv4地址
-		return p.generateRangeIP(params)
-	case "RANDOM_IP", "RANDOM_IPV4":
-		if params == "internal" {
-			return p.generateInternalIP()
-		} else if params == "external" {
-			return p.generateExternalIP()
+	// 按内置变量注册表生成值（见variables.go）
+	if v, ok := builtinIndex[varName]; ok {
+		if strings.Contains(params, "{{") && !nestedParamVariables[v.Name] {
+			var err error
+			if params, err = p.expand(params); err != nil {
+				return "", err
+			}
 		}
-		return p.generateRandomIP(params)
-	case "RANDOM_IPV6":
-		return p.generateRandomIPv6(params)
-	case "PROTOCOL":
-		return p.generateProtocol()
-	case "HTTP_METHOD":
-		return p.generateHTTPMethod()
-	case "HTTP_STATUS":
-		return p.generateHTTPStatus()
-	case "EMAIL":
-		return p.generateEmail()
-	case "DOMAIN":
-		return p.generateDomain()
-	case "URL_PATH":
-		return p.generateURLPath()
-	default:
-		return "", fmt.Errorf("unsupported variable: %s", varName)
+		return v.generate(p, params)
+	}
+	return "", fmt.Errorf("unsupported variable: %s", varName)
+}
+
+// generateRangeIPAny 按范围顺序生成IP地址，参数中包含冒号时按IPv6处理
+func (p *VariableParser) generateRangeIPAny(params string) (string, error) {
+	if strings.Contains(params, ":") {
+		return p.generateRangeIPv6(params)
+	}
+	return p.generateRangeIP(params)
+}
+
+// generateRandomIPAny 按参数生成随机IPv4地址
+// 参数为 internal、external、internal:CIDR、CIDR 或 "起始IP,结束IP"，为空时生成任意地址
+func (p *VariableParser) generateRandomIPAny(params string) (string, error) {
+	if params == "internal" {
+		return p.generateInternalIP()
+	} else if params == "external" {
+		return p.generateExternalIP()
+	} else if cidr, ok := strings.CutPrefix(params, "internal:"); ok {
+		// 只在指定的内网子网中随机生成，如 internal:10/8
+		return p.generateInternalIPFromCIDR(cidr)
+	} else if strings.Contains(params, "/") {
+		// CIDR格式在网段内随机生成（RANGE_IP为顺序生成）
+		return p.generateRandomIPFromCIDR(params)
 	}
+	return p.generateRandomIP(params)
+}
+
+// generateHTTPStatusAny 生成HTTP状态码，有参数时按真实流量分布生成
+func (p *VariableParser) generateHTTPStatusAny(params string) (string, error) {
+	if params != "" {
+		return p.generateWeightedHTTPStatus(params)
+	}
+	return p.generateHTTPStatus()
 }
 
 // generateCustomVariable 根据自定义变量配置生成变量值
@@ -233,16 +312,231 @@ func (p *VariableParser) generateCustomVariable(name string) (string, error) {
 		return variable.Values[p.random.Intn(len(variable.Values))], nil
 	case "random_int":
 		// 生成指定范围内的随机整数
-		return fmt.Sprintf("%d", p.random.Intn(variable.Max-variable.Min)+variable.Min), nil
+		min, max := int(variable.Min), int(variable.Max)
+		return fmt.Sprintf("%d", p.random.Intn(max-min)+min), nil
 	case "random_string":
 		// 生成指定长度的随机字符串
 		return p.generateRandomString(fmt.Sprintf("%d", variable.Length))
+	case "random_float":
+		// 生成指定范围内的随机浮点数，未设置precision时保留2位小数
+		precision := variable.Precision
+		if precision == 0 {
+			precision = 2
+		}
+		value := variable.Min + p.random.Float64()*(variable.Max-variable.Min)
+		return strconv.FormatFloat(value, 'f', precision, 64), nil
+	case "pattern":
+		// 根据编译后的模式生成字符串
+		return generateFromPattern(p.patterns[name], p.newRandom()), nil
 	default:
 		// 不支持的变量类型
 		return "", fmt.Errorf("不支持的变量类型: %s", variable.Type)
 	}
 }
 
+// generateEnv 读取环境变量的值
+// 参数格式: "变量名[:默认值]"
+// 示例:
+//   - "BUILD_ID" 返回环境变量BUILD_ID的值，未设置时返回空字符串
+//   - "BUILD_ID:unknown" 未设置BUILD_ID时返回"unknown"
+//
+// 说明:
+//
+//	环境变量名区分大小写；变量已设置但值为空时返回空字符串而不是默认值
+//
+// 参数:
+//   - params: 环境变量名和可选的默认值
+//
+// 返回值:
+//   - string: 环境变量的值或默认值
+//   - error: 缺少变量名时返回错误
+func (p *VariableParser) generateEnv(params string) (string, error) {
+	name, defaultValue, _ := strings.Cut(params, ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("missing environment variable name for ENV")
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	return defaultValue, nil
+}
+
+// priDirectivePrefix PRI指令的开头
+const priDirectivePrefix = "{{PRI:"
+
+// CutPriDirective 识别并移除消息开头的优先级指令
+// 指令形如 {{PRI:local0.err}}，用于让模板或数据文件中的某一行使用自己的Facility和Severity，
+// 指令之后的空格一并移除
+//
+// 参数:
+//   - content: 消息内容
+//
+// 返回值:
+//   - int: 指令指定的优先级（Facility * 8 + Severity）
+//   - string: 移除指令后的消息内容，没有指令时为原内容
+//   - bool: 消息是否以指令开头
+//   - error: 指令中的优先级无效时返回错误
+func CutPriDirective(content string) (int, string, bool, error) {
+	if !strings.HasPrefix(content, priDirectivePrefix) {
+		return 0, content, false, nil
+	}
+	end := strings.Index(content, "}}")
+	if end < 0 {
+		return 0, content, false, nil
+	}
+	priority, err := syslog.ParsePriorityString(content[len(priDirectivePrefix):end])
+	if err != nil {
+		return 0, content, true, fmt.Errorf("PRI指令无效: %w", err)
+	}
+	return priority, strings.TrimLeft(content[end+2:], " "), true, nil
+}
+
+// levelPlaceholder LEVEL变量在生成的消息中的占位符
+const levelPlaceholder = "{{LEVEL}}"
+
+// levelNames Severity到应用日志级别的映射，emerg/alert/crit都对应FATAL，notice对应INFO
+var levelNames = [8]string{"FATAL", "FATAL", "FATAL", "ERROR", "WARN", "INFO", "INFO", "DEBUG"}
+
+// LevelName 返回Severity对应的应用日志级别（DEBUG/INFO/WARN/ERROR/FATAL）
+func LevelName(severity int) string {
+	if severity < 0 || severity >= len(levelNames) {
+		return "INFO"
+	}
+	return levelNames[severity]
+}
+
+// FillLevel 将消息中的LEVEL占位符替换为Severity对应的日志级别
+// 使消息正文中的级别与syslog头部的优先级一致
+//
+// 参数:
+//   - content: 消息内容
+//   - severity: 本条消息最终使用的Severity
+//
+// 返回值:
+//   - string: 替换后的消息内容
+func FillLevel(content string, severity int) string {
+	if !strings.Contains(content, levelPlaceholder) {
+		return content
+	}
+	return strings.ReplaceAll(content, levelPlaceholder, LevelName(severity))
+}
+
+// generateFileLine 从文件中随机选择一行
+// 参数格式: "文件路径"
+// 示例:
+//   - "/data/user_agents.txt" 返回文件中随机的一行
+//
+// 说明:
+//
+//	文件在首次使用时读入并缓存，之后不再重新读取；空行被忽略，行尾的\r会被去掉
+//
+// 参数:
+//   - params: 文件路径
+//
+// 返回值:
+//   - string: 随机选择的一行
+//   - error: 缺少路径、文件无法读取或没有非空行时返回错误
+func (p *VariableParser) generateFileLine(params string) (string, error) {
+	if params == "" {
+		return "", fmt.Errorf("missing file path for FILE")
+	}
+	lines, err := p.loadFileLines(params)
+	if err != nil {
+		return "", err
+	}
+	return lines[p.random.Intn(len(lines))], nil
+}
+
+// loadFileLines 读取文件中的非空行，结果按路径缓存
+func (p *VariableParser) loadFileLines(path string) ([]string, error) {
+	p.fileMu.Lock()
+	defer p.fileMu.Unlock()
+
+	if lines, ok := p.fileLines[path]; ok {
+		return lines, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file for FILE: %v", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("file for FILE has no lines: %s", path)
+	}
+	p.fileLines[path] = lines
+	if p.verbose {
+		fmt.Fprintf(p.out, "已加载FILE变量文件 %s，共 %d 行\n", path, len(lines))
+	}
+	return lines, nil
+}
+
+// generateJSON 生成JSON对象片段，每个值由子变量表达式求值得到
+// 参数格式: "键1=变量1,键2=变量2[:参数],..."
+// 示例:
+//   - "src=RANDOM_IP,user=ENUM:alice,bob" 生成 {"src":"1.2.3.4","user":"alice"}
+//
+// 说明:
+//
+//	不含"="的片段视为上一个值的一部分，因此子变量参数中可以包含逗号（如ENUM的选项列表）。
+//	键和值都按JSON字符串转义，键的顺序与参数中的顺序一致。
+//
+// 参数:
+//   - params: 键与子变量表达式的列表
+//
+// 返回值:
+//   - string: 生成的JSON对象
+//   - error: 参数格式错误或子变量求值失败
+func (p *VariableParser) generateJSON(params string) (string, error) {
+	if params == "" {
+		return "", fmt.Errorf("missing parameters for JSON")
+	}
+
+	// 解析键值对，将不含"="的片段合并到上一个值
+	var keys, exprs []string
+	for _, segment := range strings.Split(params, ",") {
+		key, expr, ok := strings.Cut(segment, "=")
+		if !ok {
+			if len(exprs) == 0 {
+				return "", fmt.Errorf("invalid JSON field: %s, expected key=VARIABLE", segment)
+			}
+			exprs[len(exprs)-1] += "," + segment
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return "", fmt.Errorf("empty key in JSON field: %s", segment)
+		}
+		keys = append(keys, key)
+		exprs = append(exprs, expr)
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, key := range keys {
+		value, err := p.Parse(strings.TrimSpace(exprs[i]))
+		if err != nil {
+			return "", fmt.Errorf("JSON field %s: %w", key, err)
+		}
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		// json.Marshal对字符串不会失败
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(value)
+		sb.Write(k)
+		sb.WriteByte(':')
+		sb.Write(v)
+	}
+	sb.WriteByte('}')
+	return sb.String(), nil
+}
+
 // generateRandomString 生成随机字符串，支持带权重的选项
 // 参数格式: "选项1[:权重1],选项2[:权重2],..."
 // 示例:
@@ -393,17 +687,36 @@ func (p *VariableParser) generateEnum(params string) (string, error) {
 //   - string: 生成的MAC地址，格式为六组由冒号分隔的两位十六进制数
 //   - error: 生成过程中的错误，一般不会发生错误
 func (p *VariableParser) generateMAC() (string, error) {
-	// 创建新的随机数生成器，确保随机性
-	random := p.newRandom()
+	return netgen.RandomMAC(p.newRandom()), nil
+}
+
+// generateMACAny 按参数生成MAC地址
+// 参数格式:
+//   - 空字符串: 随机MAC地址
+//   - "seq": 以02:00:00为OUI，后三个字节按计数器顺序递增
+//   - "seq,aa:bb:cc": 以指定的OUI为前三个字节顺序递增，OUI也可以用"-"分隔
+//
+// 顺序生成时后三个字节共16777216个地址，用完后从头循环
+func (p *VariableParser) generateMACAny(params string) (string, error) {
+	if params == "" {
+		return p.generateMAC()
+	}
 
-	// 生成6字节的随机数据作为MAC地址
-	mac := make([]byte, 6)
-	random.Read(mac)
+	parts := strings.Split(params, ",")
+	if !strings.EqualFold(strings.TrimSpace(parts[0]), "seq") || len(parts) > 2 {
+		return "", fmt.Errorf("invalid MAC parameters: %s, expected seq or seq,OUI", params)
+	}
+	oui := netgen.DefaultMACOUI
+	if len(parts) == 2 {
+		parsed, err := netgen.ParseMACOUI(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return "", err
+		}
+		oui = parsed
+	}
 
-	// 格式化为标准MAC地址格式
-	// 使用%02x确保每个字节都被格式化为两位十六进制数
-	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x",
-		mac[0], mac[1], mac[2], mac[3], mac[4], mac[5]), nil
+	counter := atomic.AddInt64(&macCounter, 1) - 1
+	return netgen.SequentialMAC(oui, counter), nil
 }
 
 // generateRandomIP 生成随机IPv4地址
@@ -427,12 +740,7 @@ func (p *VariableParser) generateRandomIP(params string) (string, error) {
 
 	// 无参数时生成完全随机的IP地址
 	if params == "" {
-		// 每段取值范围为[0,255]
-		return fmt.Sprintf("%d.%d.%d.%d",
-			random.Intn(256),
-			random.Intn(256),
-			random.Intn(256),
-			random.Intn(256)), nil
+		return netgen.RandomIPv4(random), nil
 	}
 
 	// 解析IP范围参数
@@ -486,80 +794,23 @@ func (p *VariableParser) generateRandomIP(params string) (string, error) {
 }
 
 // generateInternalIP 生成随机的内网IP地址
-// 支持三种内网地址段：
-//   - 192.168.0.0/16 (192.168.0.0 - 192.168.255.255)
-//   - 172.16.0.0/12 (172.16.0.0 - 172.31.255.255)
-//   - 10.0.0.0/8 (10.0.0.0 - 10.255.255.255)
+// 从三种RFC1918内网地址段中等概率选择一个，再在段内随机生成（避开网络地址和广播地址），见netgen.InternalIPv4
 //
 // 返回值:
 //   - string: 生成的内网IP地址
 //   - error: 生成过程中的错误，一般不会发生错误
 func (p *VariableParser) generateInternalIP() (string, error) {
-	// 创建新的随机数生成器，确保随机性
-	random := p.newRandom()
-
-	// 随机选择一个内网IP范围
-	switch random.Intn(3) {
-	case 0: // 192.168.0.0/16 私有网络地址段
-		return fmt.Sprintf("192.168.%d.%d",
-				random.Intn(256),    // 第三段: 0-255
-				random.Intn(254)+1), // 第四段: 1-254，避免使用0和255
-			nil
-	case 1: // 172.16.0.0/12 私有网络地址段
-		return fmt.Sprintf("172.%d.%d.%d",
-				16+random.Intn(16),  // 第二段: 16-31，确保在172.16-172.31范围内
-				random.Intn(256),    // 第三段: 0-255
-				random.Intn(254)+1), // 第四段: 1-254，避免使用0和255
-			nil
-	default: // 10.0.0.0/8 私有网络地址段
-		return fmt.Sprintf("10.%d.%d.%d",
-				random.Intn(256),    // 第二段: 0-255
-				random.Intn(256),    // 第三段: 0-255
-				random.Intn(254)+1), // 第四段: 1-254，避免使用0和255
-			nil
-	}
+	return netgen.InternalIPv4(p.newRandom()), nil
 }
 
 // generateExternalIP 生成随机的外网IP地址
-// 生成规则：
-//  1. 第一段: 1-223，排除0(保留)和127(回环地址)
-//  2. 排除私有网络地址段：
-//     - 10.0.0.0/8
-//     - 172.16.0.0/12
-//     - 192.168.0.0/16
-//  3. 最后一段: 1-254，排除0和255
+// 第一段为1-223（排除127），排除RFC1918内网地址段，最后一段为1-254，见netgen.ExternalIPv4
 //
 // 返回值:
 //   - string: 生成的外网IP地址
 //   - error: 生成过程中的错误，一般不会发生错误
 func (p *VariableParser) generateExternalIP() (string, error) {
-	// 创建新的随机数生成器，确保随机性
-	random := p.newRandom()
-
-	// 循环生成直到得到有效的外网IP地址
-	for {
-		// 生成第一段，范围1-223
-		// 排除0(保留地址)和127(回环地址)
-		a := random.Intn(223) + 1
-		if a == 127 {
-			continue
-		}
-
-		// 生成剩余段
-		b := random.Intn(256)     // 第二段: 0-255
-		c := random.Intn(256)     // 第三段: 0-255
-		d := random.Intn(254) + 1 // 第四段: 1-254，避免使用0和255
-
-		// 排除所有私有网络地址段
-		if (a == 10) || // 10.0.0.0/8 私有网络
-			(a == 172 && b >= 16 && b <= 31) || // 172.16.0.0/12 私有网络
-			(a == 192 && b == 168) { // 192.168.0.0/16 私有网络
-			continue
-		}
-
-		// 返回有效的外网IP地址
-		return fmt.Sprintf("%d.%d.%d.%d", a, b, c, d), nil
-	}
+	return netgen.ExternalIPv4(p.newRandom()), nil
 }
 
 // generateRangeIP 生成指定范围内的IPv4地址
@@ -629,21 +880,16 @@ func (p *VariableParser) generateRangeIP(params string) (string, error) {
 		return "", fmt.Errorf("start IP must be less than end IP")
 	}
 
-	// 使用全局计数器实现连续生成
+	// 使用该范围自己的计数器实现连续生成
 	// 获取当前计数器值并递增
-	counter := atomic.AddInt64(&globalCounter, 1) - 1
+	counter := nextRangeIndex(params)
 	// 计算IP地址范围内的总地址数
 	totalIPs := endNum - startNum + 1
 	// 使用计数器值对总地址数取模，确保生成的IP在范围内循环
 	num := startNum + int(counter%int64(totalIPs))
 
 	// 将32位整数转换回点分十进制格式
-	return fmt.Sprintf("%d.%d.%d.%d",
-			(num>>24)&255, // 提取第一段
-			(num>>16)&255, // 提取第二段
-			(num>>8)&255,  // 提取第三段
-			num&255),      // 提取第四段
-		nil
+	return netgen.FormatIPv4(uint32(num)), nil
 }
 
 // generateIPFromCIDR 从CIDR格式生成随机IP地址
@@ -660,60 +906,69 @@ func (p *VariableParser) generateRangeIP(params string) (string, error) {
 //   - string: 生成的IP地址
 //   - error: 生成过程中的错误，如CIDR格式错误
 func (p *VariableParser) generateIPFromCIDR(cidr string) (string, error) {
-	// 分割IP地址和掩码长度
-	parts := strings.Split(cidr, "/")
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid CIDR format")
-	}
-
-	// 解析IP地址
-	ip := strings.Split(parts[0], ".")
-	if len(ip) != 4 {
-		return "", fmt.Errorf("invalid IP address in CIDR")
-	}
-
-	// 解析掩码
-	mask, err := strconv.Atoi(parts[1])
-	if err != nil || mask < 0 || mask > 32 {
-		return "", fmt.Errorf("invalid network mask in CIDR")
-	}
-
-	// 计算网络地址
-	baseIP := 0
-	for i := 0; i < 4; i++ {
-		n, err := strconv.Atoi(ip[i])
-		if err != nil || n < 0 || n > 255 {
-			return "", fmt.Errorf("invalid IP address: %s", parts[0])
-		}
-		baseIP = baseIP*256 + n
+	network, hostBits, err := netgen.ParseIPv4CIDR(cidr)
+	if err != nil {
+		return "", err
 	}
-
-	// 计算可用IP范围
-	hostBits := 32 - mask
 	if hostBits <= 0 {
-		return "", fmt.Errorf("invalid network mask: /%d", mask)
+		return "", fmt.Errorf("invalid network mask: /32")
 	}
 
-	// 获取当前计数器值并递增
-	counter := atomic.AddInt64(&globalCounter, 1) - 1
-	maskBits := uint32(0xFFFFFFFF) << uint(hostBits)
-	network := uint32(baseIP) & maskBits
+	// 获取该网段计数器的当前值并递增
+	counter := nextRangeIndex(cidr)
 	hostMax := uint32(1<<uint(hostBits)) - 1
 
 	// 避免网络地址和广播地址
 	if hostBits > 1 {
 		// 使用计数器值对可用主机数取模，实现连续生成
 		hostNum := uint32(counter%int64(hostMax-1)) + 1
-		ip := network | hostNum
+		return netgen.FormatIPv4(network | hostNum), nil
+	}
+
+	return "", fmt.Errorf("network mask is too restrictive: /%d", 32-hostBits)
+}
 
-		return fmt.Sprintf("%d.%d.%d.%d",
-			(ip>>24)&255,
-			(ip>>16)&255,
-			(ip>>8)&255,
-			ip&255), nil
+// generateRandomIPFromCIDR 在CIDR网段内随机生成IPv4地址
+// 与RANGE_IP的顺序生成不同，每次独立随机选择，网段大于/31时避开网络地址和广播地址
+// 示例：
+//   - 10.0.0.0/8 或简写 10/8
+//   - 172.16/12
+//
+// 参数:
+//   - cidr: CIDR格式的网络地址范围，省略的段按0补齐
+//
+// 返回值:
+//   - string: 生成的IP地址
+//   - error: CIDR格式错误时返回错误
+func (p *VariableParser) generateRandomIPFromCIDR(cidr string) (string, error) {
+	network, hostBits, err := netgen.ParseIPv4CIDR(cidr)
+	if err != nil {
+		return "", err
 	}
 
-	return "", fmt.Errorf("network mask is too restrictive: /%d", mask)
+	return netgen.RandomIPv4InNetwork(p.newRandom(), network, hostBits), nil
+}
+
+// generateInternalIPFromCIDR 在指定的内网子网中随机生成IPv4地址
+// 子网必须位于 10.0.0.0/8、172.16.0.0/12 或 192.168.0.0/16 之内
+//
+// 参数:
+//   - cidr: 内网子网，如 10/8、192.168.10.0/24
+//
+// 返回值:
+//   - string: 生成的内网IP地址
+//   - error: 子网格式错误或不是内网地址段时返回错误
+func (p *VariableParser) generateInternalIPFromCIDR(cidr string) (string, error) {
+	network, hostBits, err := netgen.ParseIPv4CIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+
+	// 子网的掩码不能比所在内网地址段更短
+	if netgen.InPrivateNetwork(network, hostBits) {
+		return netgen.RandomIPv4InNetwork(p.newRandom(), network, hostBits), nil
+	}
+	return "", fmt.Errorf("%s is not within a private network (10/8, 172.16/12, 192.168/16)", cidr)
 }
 
 // generateRangeIPv6 生成指定范围内的IPv6地址
@@ -778,8 +1033,8 @@ func (p *VariableParser) generateRangeIPv6(params string) (string, error) {
 			}
 		}
 
-		// 使用全局计数器实现连续生成
-		counter := atomic.AddInt64(&globalCounter, 1) - 1
+		// 使用该网段自己的计数器实现连续生成
+		counter := nextRangeIndex(params)
 		result := make([]string, 8)
 
 		// 保持网络部分不变（由掩码长度决定）
@@ -856,6 +1111,72 @@ func (p *VariableParser) generateEmail() (string, error) {
 	return fmt.Sprintf("%s@%s", string(username), domain), nil
 }
 
+// 域名生成使用的公司名称和顶级域名，DOMAIN和FQDN共用
+var (
+	// domainCompanies 常见公司或组织名称
+	domainCompanies = []string{
+		"google", "amazon", "microsoft", "apple", "meta", "oracle", "ibm",
+		"cisco", "intel", "amd", "nvidia", "dell", "hp", "lenovo", "huawei",
+		"github", "gitlab", "bitbucket", "docker", "kubernetes", "linux",
+	}
+
+	// domainTLDs 顶级域名列表
+	domainTLDs = []string{
+		// 通用顶级域名
+		"com", "org", "net", "edu", "gov", "mil", "int",
+		// 新通用顶级域名
+		"io", "cloud", "tech", "dev", "app", "ai", "co", "me",
+		// 国家和地区顶级域名
+		"cn", "us", "uk", "eu", "de", "fr", "jp", "kr",
+		// 安全相关顶级域名
+		"security", "protection", "defense", "secure", "trust",
+	}
+
+	// hostRoles 主机名的角色前缀
+	hostRoles = []string{
+		"web-server", "app-server", "db-server", "mail", "dns", "fw",
+		"proxy", "cache", "lb", "k8s-node", "log", "backup", "vpn", "dc",
+	}
+)
+
+// generateHostname 生成随机主机名
+// 格式为 角色-两位编号，如 web-server-01；指定域名后缀时生成 web-server-01.corp.local
+//
+// 参数:
+//   - domain: 域名后缀，为空时只生成主机名
+//
+// 返回值:
+//   - string: 生成的主机名
+//   - error: 生成过程中的错误，一般不会发生错误
+func (p *VariableParser) generateHostname(domain string) (string, error) {
+	random := p.newRandom()
+	host := fmt.Sprintf("%s-%02d", hostRoles[random.Intn(len(hostRoles))], random.Intn(99)+1)
+
+	domain = strings.Trim(strings.TrimSpace(domain), ".")
+	if domain == "" {
+		return host, nil
+	}
+	return host + "." + domain, nil
+}
+
+// generateFQDN 生成随机的完全限定域名
+// 随机主机名加上随机的组织域名，如 db-server-07.cisco.io；
+// 指定域名时与 HOSTNAME:域名 相同
+//
+// 参数:
+//   - domain: 域名后缀，为空时随机生成
+//
+// 返回值:
+//   - string: 生成的完全限定域名
+//   - error: 生成过程中的错误，一般不会发生错误
+func (p *VariableParser) generateFQDN(domain string) (string, error) {
+	if strings.TrimSpace(domain) == "" {
+		random := p.newRandom()
+		domain = domainCompanies[random.Intn(len(domainCompanies))] + "." + domainTLDs[random.Intn(len(domainTLDs))]
+	}
+	return p.generateHostname(domain)
+}
+
 // generateDomain 生成随机的域名
 // 生成规则：
 //  1. 域名前缀：从预定义的常见前缀中随机选择
@@ -890,24 +1211,8 @@ func (p *VariableParser) generateDomain() (string, error) {
 		"proxy", "gateway", "cluster", "node", "host", "client",
 	}
 
-	// 常见公司或组织名称
-	companies := []string{
-		"google", "amazon", "microsoft", "apple", "meta", "oracle", "ibm",
-		"cisco", "intel", "amd", "nvidia", "dell", "hp", "lenovo", "huawei",
-		"github", "gitlab", "bitbucket", "docker", "kubernetes", "linux",
-	}
-
-	// 顶级域名列表
-	tlds := []string{
-		// 通用顶级域名
-		"com", "org", "net", "edu", "gov", "mil", "int",
-		// 新通用顶级域名
-		"io", "cloud", "tech", "dev", "app", "ai", "co", "me",
-		// 国家和地区顶级域名
-		"cn", "us", "uk", "eu", "de", "fr", "jp", "kr",
-		// 安全相关顶级域名
-		"security", "protection", "defense", "secure", "trust",
-	}
+	companies := domainCompanies
+	tlds := domainTLDs
 
 	// 生成域名的方式
 	domainType := random.Intn(4)
@@ -1044,27 +1349,116 @@ func (p *VariableParser) generateHTTPMethod() (string, error) {
 	return methods[random.Intn(len(methods))], nil
 }
 
-// generateHTTPStatus 生成HTTP状态码
+// processNames 常见的守护进程和服务程序名称，PROCESS和PROCESS_PID变量从中选择
+var processNames = []string{
+	"sshd", "sudo", "su", "cron", "CRON", "systemd", "systemd-logind", "kernel",
+	"nginx", "httpd", "haproxy", "postfix/smtpd", "dovecot", "named", "dnsmasq", "dhclient",
+	"ntpd", "chronyd", "rsyslogd", "auditd", "mysqld", "postgres", "redis-server", "dockerd",
+	"containerd", "kubelet", "snmpd", "vsftpd", "smbd", "squid",
+}
+
+// 生成的进程号范围，与Linux默认的pid_max（32768）一致，跳过系统启动时占用的小进程号
+const (
+	minProcessPID = 300
+	maxProcessPID = 32767
+)
+
+// generateProcess 生成随机的进程名称
+func (p *VariableParser) generateProcess() (string, error) {
+	random := p.newRandom()
+	return processNames[random.Intn(len(processNames))], nil
+}
+
+// generateProcessPID 生成RFC3164 TAG形式的进程名称和进程号，如 nginx[12345]
+func (p *VariableParser) generateProcessPID() (string, error) {
+	random := p.newRandom()
+	name := processNames[random.Intn(len(processNames))]
+	pid := minProcessPID + random.Intn(maxProcessPID-minProcessPID+1)
+	return fmt.Sprintf("%s[%d]", name, pid), nil
+}
+
+// generateHTTPStatus 生成HTTP状态码，在所有状态码中等概率选择
 func (p *VariableParser) generateHTTPStatus() (string, error) {
-	// HTTP状态码列表
-	statuses := []struct {
-		code int
-		desc string
-	}{
-		{200, "OK"}, {201, "Created"}, {202, "Accepted"},
-		{204, "No Content"}, {301, "Moved Permanently"},
-		{302, "Found"}, {304, "Not Modified"},
-		{400, "Bad Request"}, {401, "Unauthorized"},
-		{403, "Forbidden"}, {404, "Not Found"},
-		{405, "Method Not Allowed"}, {408, "Request Timeout"},
-		{429, "Too Many Requests"}, {500, "Internal Server Error"},
-		{501, "Not Implemented"}, {502, "Bad Gateway"},
-		{503, "Service Unavailable"}, {504, "Gateway Timeout"},
+	// 创建新的随机数生成器
+	random := p.newRandom()
+	i := random.Intn(len(httpSuccessStatuses) + len(httpErrorStatuses))
+	status := httpErrorStatuses[0]
+	if i < len(httpSuccessStatuses) {
+		status = httpSuccessStatuses[i]
+	} else {
+		status = httpErrorStatuses[i-len(httpSuccessStatuses)]
+	}
+	return fmt.Sprintf("%d %s", status.code, status.desc), nil
+}
+
+// httpStatus HTTP状态码及其在真实流量中的相对权重
+type httpStatus struct {
+	code   int
+	desc   string
+	weight int
+}
+
+// httpSuccessStatuses 成功和重定向状态码，权重参考常见访问日志的分布，200占绝大多数
+var httpSuccessStatuses = []httpStatus{
+	{200, "OK", 85}, {201, "Created", 2}, {202, "Accepted", 1},
+	{204, "No Content", 1}, {301, "Moved Permanently", 2},
+	{302, "Found", 3}, {304, "Not Modified", 6},
+}
+
+// httpErrorStatuses 客户端和服务端错误状态码，404最常见
+var httpErrorStatuses = []httpStatus{
+	{400, "Bad Request", 10}, {401, "Unauthorized", 8},
+	{403, "Forbidden", 8}, {404, "Not Found", 40},
+	{405, "Method Not Allowed", 2}, {408, "Request Timeout", 2},
+	{429, "Too Many Requests", 5}, {500, "Internal Server Error", 12},
+	{501, "Not Implemented", 1}, {502, "Bad Gateway", 5},
+	{503, "Service Unavailable", 5}, {504, "Gateway Timeout", 2},
+}
+
+// defaultHTTPErrorRate HTTP_STATUS:weighted 使用的错误率
+const defaultHTTPErrorRate = 0.05
+
+// generateWeightedHTTPStatus 按真实流量的分布生成HTTP状态码
+// 参数:
+//   - params: "weighted" 使用默认错误率5%；"error=N%" 指定4xx/5xx的比例，如 error=5%、error=0.5%
+//
+// 返回值:
+//   - string: 状态码和描述，如 "200 OK"
+//   - error: 参数无效时返回错误
+//
+// 先按错误率决定是否为错误状态码，再在对应的列表中按权重选择
+func (p *VariableParser) generateWeightedHTTPStatus(params string) (string, error) {
+	errorRate := defaultHTTPErrorRate
+	if params != "weighted" {
+		value, ok := strings.CutPrefix(params, "error=")
+		if !ok {
+			return "", fmt.Errorf("invalid HTTP_STATUS parameter: %s (expected weighted or error=N%%)", params)
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return "", fmt.Errorf("invalid HTTP_STATUS error rate: %s (expected 0-100%%)", value)
+		}
+		errorRate = percent / 100
 	}
 
-	// 创建新的随机数生成器
 	random := p.newRandom()
-	status := statuses[random.Intn(len(statuses))]
+	statuses := httpSuccessStatuses
+	if random.Float64() < errorRate {
+		statuses = httpErrorStatuses
+	}
+
+	total := 0
+	for _, status := range statuses {
+		total += status.weight
+	}
+	n := random.Intn(total)
+	for _, status := range statuses {
+		if n < status.weight {
+			return fmt.Sprintf("%d %s", status.code, status.desc), nil
+		}
+		n -= status.weight
+	}
+	status := statuses[len(statuses)-1]
 	return fmt.Sprintf("%d %s", status.code, status.desc), nil
 }
 
@@ -0,0 +1,81 @@
+package template
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// TestSeededNetworkVariables 固定种子下IP和MAC变量按相同顺序生成相同的值
+// 这些变量由netgen生成，解析器只负责解析参数和派生随机数生成器
+func TestSeededNetworkVariables(t *testing.T) {
+	p := NewVariableParser(false)
+	p.SetSeed(42)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"RANDOM_IP", "213.177.123.253"},
+		{"RANDOM_IP:internal", "10.65.93.176"},
+		{"RANDOM_IP:external", "67.10.201.219"},
+		{"RANDOM_IP:internal:10.1/16", "10.1.219.186"},
+		{"RANDOM_IP:198.51.100.0/24", "198.51.100.87"},
+		{"RANDOM_IP:192.168.1.10,192.168.1.20", "192.168.1.15"},
+		{"MAC", "25:ad:51:e8:65:79"},
+		{"RANDOM_IP:internal", "172.26.175.72"},
+		{"RANDOM_IP:external", "198.140.225.56"},
+		{"MAC", "e3:65:6b:30:6c:2f"},
+	}
+	for i, tt := range tests {
+		got, err := p.Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("第 %d 个变量 %s 解析失败: %v", i+1, tt.expr, err)
+		}
+		if got != tt.want {
+			t.Fatalf("第 %d 个变量 %s 生成 %s，期望 %s", i+1, tt.expr, got, tt.want)
+		}
+	}
+}
+
+// TestInternalIPFromCIDRRejectsPublic internal:CIDR 只接受内网子网
+func TestInternalIPFromCIDRRejectsPublic(t *testing.T) {
+	p := NewVariableParser(false)
+	for _, expr := range []string{"RANDOM_IP:internal:192.0.2.0/24", "RANDOM_IP:internal:10/7"} {
+		if _, err := p.Parse(expr); err == nil {
+			t.Errorf("%s 应返回错误", expr)
+		}
+	}
+}
+
+// TestRangeIPIndependentCounters 同一模板中的多个RANGE_IP各自从范围起点开始，每次渲染递增1，
+// 不受其他范围和随机变量的影响
+// 计数器是进程级的，这里使用其他测试不会用到的地址范围
+func TestRangeIPIndependentCounters(t *testing.T) {
+	p := NewVariableParser(false)
+	p.SetSeed(7)
+	text := "{{RANGE_IP:198.18.0.10-198.18.0.200}} {{RANDOM_IPV6}} {{RANGE_IP:198.19.5.0/24}} {{RANDOM_IPV6}}"
+
+	for i := 0; i < 5; i++ {
+		got, err := p.expand(text)
+		if err != nil {
+			t.Fatalf("第 %d 次渲染失败: %v", i+1, err)
+		}
+		fields := strings.Fields(got)
+		if len(fields) != 4 {
+			t.Fatalf("第 %d 次渲染结果为 %q", i+1, got)
+		}
+		if want := fmt.Sprintf("198.18.0.%d", 10+i); fields[0] != want {
+			t.Fatalf("第 %d 次渲染范围a为 %s，期望 %s", i+1, fields[0], want)
+		}
+		if want := fmt.Sprintf("198.19.5.%d", 1+i); fields[2] != want {
+			t.Fatalf("第 %d 次渲染范围b为 %s，期望 %s", i+1, fields[2], want)
+		}
+		for _, v6 := range []string{fields[1], fields[3]} {
+			if ip := net.ParseIP(v6); ip == nil || ip.To4() != nil {
+				t.Fatalf("第 %d 次渲染 RANDOM_IPV6 为 %s，不是IPv6地址", i+1, v6)
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package template
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// patternToken 模式中的一个生成单元
+// 由一个字符集合和重复次数范围组成
+type patternToken struct {
+	chars []byte // 可选字符集合，字面字符时只有一个元素
+	min   int    // 最少重复次数
+	max   int    // 最多重复次数
+}
+
+// 预定义字符类
+const (
+	patternDigits = "0123456789"
+	patternUpper  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	patternLower  = "abcdefghijklmnopqrstuvwxyz"
+	patternWord   = patternUpper + patternLower + patternDigits + "_"
+)
+
+// maxPatternRepeat 单个单元允许的最大重复次数，防止生成过长的字符串
+const maxPatternRepeat = 1024
+
+// compilePattern 将简化的正则模式编译为生成单元列表
+// 支持的语法（正则表达式的子集）：
+//   - 字面字符，如 "-"、"A"
+//   - 字符类 [A-Z0-9_]，支持范围和单个字符
+//   - 转义 \d（数字）、\w（单词字符）以及 \[ \{ \\ 等字面转义
+//   - 量词 {n}、{n,m} 和 ?
+//
+// 示例：
+//   - "[A-Z]{3}-[0-9]{4}" 生成 "QWE-0421"
+//   - "LIC-\d{4}-[A-F0-9]{8}" 生成 "LIC-1234-0A9F3B2C"
+//
+// 参数:
+//   - pattern: 模式字符串
+//
+// 返回值:
+//   - []patternToken: 编译后的生成单元
+//   - error: 模式语法错误
+func compilePattern(pattern string) ([]patternToken, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("模式不能为空")
+	}
+
+	var tokens []patternToken
+	for i := 0; i < len(pattern); {
+		var chars []byte
+		switch c := pattern[i]; c {
+		case '[':
+			// 查找字符类结束位置
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("位置%d的字符类缺少 ]", i)
+			}
+			class, err := expandCharClass(pattern[i+1 : i+1+end])
+			if err != nil {
+				return nil, err
+			}
+			chars = class
+			i += end + 2
+		case '\\':
+			if i+1 >= len(pattern) {
+				return nil, fmt.Errorf("模式不能以 \\ 结尾")
+			}
+			switch pattern[i+1] {
+			case 'd':
+				chars = []byte(patternDigits)
+			case 'w':
+				chars = []byte(patternWord)
+			default:
+				chars = []byte{pattern[i+1]}
+			}
+			i += 2
+		case '{', '}', '?', ']':
+			return nil, fmt.Errorf("位置%d的 %c 前缺少可重复的字符", i, c)
+		default:
+			chars = []byte{c}
+			i++
+		}
+
+		// 解析紧随其后的量词
+		token := patternToken{chars: chars, min: 1, max: 1}
+		if i < len(pattern) {
+			switch pattern[i] {
+			case '?':
+				token.min = 0
+				i++
+			case '{':
+				end := strings.IndexByte(pattern[i:], '}')
+				if end < 0 {
+					return nil, fmt.Errorf("位置%d的量词缺少 }", i)
+				}
+				min, max, err := parseQuantifier(pattern[i+1 : i+end])
+				if err != nil {
+					return nil, err
+				}
+				token.min, token.max = min, max
+				i += end + 1
+			}
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// expandCharClass 展开字符类内容，如 "A-Z0-9_"
+func expandCharClass(class string) ([]byte, error) {
+	if class == "" {
+		return nil, fmt.Errorf("字符类不能为空")
+	}
+
+	var chars []byte
+	for i := 0; i < len(class); i++ {
+		c := class[i]
+		if c == '\\' && i+1 < len(class) {
+			// 字符类内的转义
+			i++
+			switch class[i] {
+			case 'd':
+				chars = append(chars, patternDigits...)
+			case 'w':
+				chars = append(chars, patternWord...)
+			default:
+				chars = append(chars, class[i])
+			}
+			continue
+		}
+		if i+2 < len(class) && class[i+1] == '-' {
+			// 字符范围，如 A-Z
+			end := class[i+2]
+			if c > end {
+				return nil, fmt.Errorf("无效的字符范围: %c-%c", c, end)
+			}
+			for r := c; ; r++ {
+				chars = append(chars, r)
+				if r == end {
+					break
+				}
+			}
+			i += 2
+			continue
+		}
+		chars = append(chars, c)
+	}
+	return chars, nil
+}
+
+// parseQuantifier 解析量词内容，如 "3" 或 "2,5"
+func parseQuantifier(q string) (int, int, error) {
+	parts := strings.SplitN(q, ",", 2)
+	min, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || min < 0 {
+		return 0, 0, fmt.Errorf("无效的量词: {%s}", q)
+	}
+	max := min
+	if len(parts) == 2 {
+		max, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || max < min {
+			return 0, 0, fmt.Errorf("无效的量词: {%s}", q)
+		}
+	}
+	if max > maxPatternRepeat {
+		return 0, 0, fmt.Errorf("量词重复次数不能超过%d: {%s}", maxPatternRepeat, q)
+	}
+	return min, max, nil
+}
+
+// generateFromPattern 根据编译后的生成单元生成随机字符串
+func generateFromPattern(tokens []patternToken, random *rand.Rand) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		count := token.min
+		if token.max > token.min {
+			count += random.Intn(token.max - token.min + 1)
+		}
+		for j := 0; j < count; j++ {
+			sb.WriteByte(token.chars[random.Intn(len(token.chars))])
+		}
+	}
+	return sb.String()
+}
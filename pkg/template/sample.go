@@ -0,0 +1,97 @@
+package template
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sampleVariable 示例配置中的一个自定义变量及其说明
+type sampleVariable struct {
+	name     string
+	comment  string
+	variable CustomVariable
+}
+
+// sampleVariables 示例配置包含的变量，覆盖每种自定义变量类型
+var sampleVariables = []sampleVariable{
+	{"CUSTOM_STATUS", "random_choice: 从values中随机选择一个，使用 {{CUSTOM_STATUS}}",
+		CustomVariable{Type: "random_choice", Values: []string{"正常", "警告", "错误", "严重"}}},
+	{"CUSTOM_SCORE", "random_int: [min, max) 范围内的随机整数（不含max），min和max必须为整数且min小于max",
+		CustomVariable{Type: "random_int", Min: 1, Max: 100}},
+	{"CUSTOM_ID", "random_string: length为字符串长度，必须大于0",
+		CustomVariable{Type: "random_string", Length: 8}},
+	{"CUSTOM_LATENCY", "random_float: [min, max) 范围内的随机浮点数，保留precision位小数（默认2位）",
+		CustomVariable{Type: "random_float", Min: 0.5, Max: 250, Precision: 3}},
+	{"CUSTOM_TICKET", "pattern: 按模式生成，支持字符类（如 [A-Z]）和量词（如 {4}）",
+		CustomVariable{Type: "pattern", Pattern: "[A-Z]{3}-[0-9]{4}"}},
+	{"CUSTOM_SERVER_IP", "random_choice 也可用作自定义的IP地址池或端口列表",
+		CustomVariable{Type: "random_choice", Values: []string{"192.168.1.10", "192.168.1.11", "192.168.1.12"}}},
+}
+
+// SampleConfig 返回示例自定义变量配置，每种变量类型至少包含一个变量
+func SampleConfig() *CustomVariableConfig {
+	config := &CustomVariableConfig{Variables: make(map[string]CustomVariable, len(sampleVariables))}
+	for _, v := range sampleVariables {
+		config.Variables[v.name] = v.variable
+	}
+	return config
+}
+
+// WriteSampleConfig 将带注释的示例配置写入w
+// 配置由SampleConfig经yaml序列化得到，字段与CustomVariable结构保持一致；
+// 写出前逐个注册示例变量，保证生成的文件能被 --vars-file 直接加载
+// 参数：
+//   - w: 输出目标，如新建的 template.yml 文件
+//
+// 返回值：
+//   - error: 示例变量无效或写入失败时返回错误
+func WriteSampleConfig(w io.Writer) error {
+	parser := NewVariableParser(false)
+	for _, v := range sampleVariables {
+		if err := parser.RegisterCustomVariable(v.name, v.variable); err != nil {
+			return fmt.Errorf("示例变量[%s]无效: %w", v.name, err)
+		}
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(SampleConfig()); err != nil {
+		return fmt.Errorf("序列化示例配置失败: %w", err)
+	}
+	doc.HeadComment = "自定义变量配置示例\n" +
+		"变量名不区分大小写，在模板中以 {{变量名}} 引用，如 mock -m \"状态: {{CUSTOM_STATUS}}\"\n" +
+		"send 和 mock 默认加载当前目录下的 template.yml，其他路径用 --vars-file 指定"
+
+	// 为每个变量添加说明注释，yaml按变量名排序输出
+	comments := make(map[string]string, len(sampleVariables))
+	for _, v := range sampleVariables {
+		comments[v.name] = v.comment
+	}
+	if variables := mappingValue(&doc, "variables"); variables != nil {
+		for i := 0; i+1 < len(variables.Content); i += 2 {
+			key := variables.Content[i]
+			key.HeadComment = comments[key.Value]
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("写入示例配置失败: %w", err)
+	}
+	return encoder.Close()
+}
+
+// mappingValue 返回映射节点中指定键对应的值节点，不存在时返回nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"syslog_go/pkg/syslog"
+)
+
+// VariableInfo 模板变量的说明，用于列出支持的变量
+type VariableInfo struct {
+	Name        string   // 变量名
+	Aliases     []string // 别名
+	Usage       string   // 用法，如 "RANDOM_INT:最小值-最大值"
+	Description string   // 一句话说明
+	Example     string   // 示例表达式，如 "{{RANDOM_INT:1-100}}"
+}
+
+// builtinVariable 内置变量的注册项
+type builtinVariable struct {
+	VariableInfo
+	generate func(p *VariableParser, params string) (string, error) // 按参数生成变量值
+}
+
+// noParams 将不需要参数的生成方法适配为注册表使用的形式
+func noParams(generate func(p *VariableParser) (string, error)) func(*VariableParser, string) (string, error) {
+	return func(p *VariableParser, _ string) (string, error) {
+		return generate(p)
+	}
+}
+
+// builtinVariables 内置变量注册表，Parse按此表分发，--list-variables按此表列出
+// 新增内置变量时在此登记，说明和示例随之出现在列表中
+var builtinVariables = []builtinVariable{
+	// 网络
+	{VariableInfo{Name: "RANDOM_IP", Aliases: []string{"RANDOM_IPV4"}, Usage: "RANDOM_IP[:internal|external|internal:CIDR|CIDR|起始IP,结束IP]",
+		Description: "随机IPv4地址，可限定内网、外网或网段", Example: "{{RANDOM_IP:internal:10/8}}"},
+		(*VariableParser).generateRandomIPAny},
+	{VariableInfo{Name: "RANGE_IP", Usage: "RANGE_IP:起始IP-结束IP|CIDR",
+		Description: "在范围或网段内按顺序生成地址，支持IPv6网段", Example: "{{RANGE_IP:192.168.1.0/24}}"},
+		(*VariableParser).generateRangeIPAny},
+	{VariableInfo{Name: "RANDOM_IPV6", Usage: "RANDOM_IPV6[:internal|external|compressed]",
+		Description: "随机IPv6地址，internal为fd00::/8，external为2000::/3", Example: "{{RANDOM_IPV6:compressed}}"},
+		(*VariableParser).generateRandomIPv6},
+	{VariableInfo{Name: "MAC", Usage: "MAC[:seq[,OUI]]",
+		Description: "随机MAC地址，seq时按OUI（默认02:00:00）顺序生成不重复的地址", Example: "{{MAC:seq,aa:bb:cc}}"},
+		(*VariableParser).generateMACAny},
+	{VariableInfo{Name: "PROTOCOL", Usage: "PROTOCOL",
+		Description: "随机网络协议名称", Example: "{{PROTOCOL}}"},
+		noParams((*VariableParser).generateProtocol)},
+	{VariableInfo{Name: "HTTP_METHOD", Usage: "HTTP_METHOD",
+		Description: "随机HTTP请求方法", Example: "{{HTTP_METHOD}}"},
+		noParams((*VariableParser).generateHTTPMethod)},
+	{VariableInfo{Name: "HTTP_STATUS", Usage: "HTTP_STATUS[:weighted|error=百分比]",
+		Description: "HTTP状态码，默认等概率，有参数时按真实流量分布（200为主）", Example: "{{HTTP_STATUS:error=1%}}"},
+		(*VariableParser).generateHTTPStatusAny},
+	{VariableInfo{Name: "URL_PATH", Usage: "URL_PATH",
+		Description: "随机URL路径", Example: "{{URL_PATH}}"},
+		noParams((*VariableParser).generateURLPath)},
+
+	// 主机与身份
+	{VariableInfo{Name: "HOSTNAME", Usage: "HOSTNAME[:域名]",
+		Description: "随机主机名，指定域名时追加在后面", Example: "{{HOSTNAME:corp.local}}"},
+		(*VariableParser).generateHostname},
+	{VariableInfo{Name: "FQDN", Usage: "FQDN[:域名]",
+		Description: "随机主机名加组织域名的完全限定域名", Example: "{{FQDN}}"},
+		(*VariableParser).generateFQDN},
+	{VariableInfo{Name: "DOMAIN", Usage: "DOMAIN",
+		Description: "随机域名", Example: "{{DOMAIN}}"},
+		noParams((*VariableParser).generateDomain)},
+	{VariableInfo{Name: "EMAIL", Usage: "EMAIL",
+		Description: "随机邮箱地址", Example: "{{EMAIL}}"},
+		noParams((*VariableParser).generateEmail)},
+	{VariableInfo{Name: "PROCESS", Usage: "PROCESS",
+		Description: "随机的常见守护进程名称，如 sshd、nginx", Example: "{{PROCESS}}"},
+		noParams((*VariableParser).generateProcess)},
+	{VariableInfo{Name: "PROCESS_PID", Usage: "PROCESS_PID",
+		Description: "RFC3164 TAG形式的进程名称和进程号（300-32767）", Example: "转发自 {{PROCESS_PID}}: session opened"},
+		noParams((*VariableParser).generateProcessPID)},
+
+	// 随机数据
+	{VariableInfo{Name: "RANDOM_INT", Usage: "RANDOM_INT:最小值-最大值",
+		Description: "指定范围内的随机整数", Example: "{{RANDOM_INT:1-100}}"},
+		(*VariableParser).generateRandomInt},
+	{VariableInfo{Name: "RANDOM_STRING", Usage: "RANDOM_STRING:选项1[:权重1],选项2[:权重2],...",
+		Description: "从选项中按权重随机选择一个", Example: "{{RANDOM_STRING:GET:3,POST:1}}"},
+		(*VariableParser).generateRandomString},
+	{VariableInfo{Name: "ENUM", Usage: "ENUM:选项1,选项2,...",
+		Description: "从选项中等概率随机选择一个", Example: "{{ENUM:alice,bob,carol}}"},
+		(*VariableParser).generateEnum},
+	{VariableInfo{Name: "FILE", Usage: "FILE:文件路径",
+		Description: "从文件中随机选择一行，文件首次使用时读入并缓存", Example: "{{FILE:/data/user_agents.txt}}"},
+		(*VariableParser).generateFileLine},
+	{VariableInfo{Name: "SEQ", Usage: "SEQ",
+		Description: "从1开始连续递增的消息序号，供接收端检测丢包和乱序", Example: "seq={{SEQ}}"},
+		func(*VariableParser, string) (string, error) {
+			return strconv.FormatInt(atomic.AddInt64(&seqCounter, 1), 10), nil
+		}},
+
+	// 结构化片段与运行环境
+	{VariableInfo{Name: "JSON", Usage: "JSON:键1=变量1,键2=变量2[:参数]",
+		Description: "JSON对象，每个值由子变量表达式求值并转义", Example: "{{JSON:src=RANDOM_IP,user=ENUM:alice,bob}}"},
+		(*VariableParser).generateJSON},
+	{VariableInfo{Name: "REPEAT", Usage: "REPEAT:次数[,once|each]:内容",
+		Description: "将内容重复指定次数，内容可嵌套变量表达式，默认求值一次后重复，each时每次重新求值", Example: "{{REPEAT:3,each:{{RANDOM_INT:0-9}}}}"},
+		(*VariableParser).generateRepeat},
+	{VariableInfo{Name: "ENV", Usage: "ENV:变量名[:默认值]",
+		Description: "环境变量的值，未设置时使用默认值", Example: "{{ENV:BUILD_ID:unknown}}"},
+		(*VariableParser).generateEnv},
+
+	// 优先级
+	{VariableInfo{Name: "PRI", Usage: "PRI:Facility.Severity",
+		Description: "写在消息开头时设置该条消息的优先级，发送时移除", Example: "{{PRI:local0.err}} 磁盘故障"},
+		func(_ *VariableParser, params string) (string, error) {
+			// 只校验参数，指令本身原样输出，由发送器在格式化消息前识别并移除
+			if _, err := syslog.ParsePriorityString(params); err != nil {
+				return "", err
+			}
+			return priDirectivePrefix + params + "}}", nil
+		}},
+	{VariableInfo{Name: "LEVEL", Usage: "LEVEL",
+		Description: "与该条消息Severity对应的日志级别 DEBUG/INFO/WARN/ERROR/FATAL", Example: "[{{LEVEL}}] 请求失败"},
+		func(*VariableParser, string) (string, error) {
+			// Severity可能由PRI指令决定，生成模板时还不确定，由发送器在确定优先级后替换
+			return levelPlaceholder, nil
+		}},
+}
+
+// nestedParamVariables 自行对参数中的子表达式求值的变量
+// 这些变量的参数中嵌套的表达式原样传入，由变量决定何时、求值几次（如REPEAT的each）；
+// 其他变量的参数中嵌套的表达式先展开，如 {{PRI:{{ENUM:local0.err,local0.info}}}}
+var nestedParamVariables = map[string]bool{
+	"REPEAT": true,
+	"JSON":   true,
+}
+
+// builtinIndex 变量名（包括别名）到注册项的索引
+var builtinIndex map[string]*builtinVariable
+
+func init() {
+	builtinIndex = make(map[string]*builtinVariable, len(builtinVariables))
+	for i := range builtinVariables {
+		v := &builtinVariables[i]
+		for _, name := range append([]string{v.Name}, v.Aliases...) {
+			if _, ok := builtinIndex[name]; ok {
+				panic(fmt.Sprintf("重复注册的模板变量: %s", name))
+			}
+			builtinIndex[name] = v
+		}
+	}
+}
+
+// BuiltinVariables 返回所有内置变量的说明，按注册表中的分组顺序排列
+func BuiltinVariables() []VariableInfo {
+	infos := make([]VariableInfo, 0, len(builtinVariables))
+	for _, v := range builtinVariables {
+		infos = append(infos, v.VariableInfo)
+	}
+	return infos
+}
+
+// CustomVariables 返回从自定义变量配置文件加载的变量说明，按名称排序
+func (e *Engine) CustomVariables() []VariableInfo {
+	names := make([]string, 0, len(e.parser.customVariables))
+	for name := range e.parser.customVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	infos := make([]VariableInfo, 0, len(names))
+	for _, name := range names {
+		v := e.parser.customVariables[name]
+		infos = append(infos, VariableInfo{
+			Name:        name,
+			Usage:       name,
+			Description: customDescription(v),
+			Example:     "{{" + name + "}}",
+		})
+	}
+	return infos
+}
+
+// customDescription 根据自定义变量的类型和配置生成一句话说明
+func customDescription(v CustomVariable) string {
+	switch v.Type {
+	case "random_choice":
+		return fmt.Sprintf("random_choice: 从 %s 中随机选择", strings.Join(v.Values, ", "))
+	case "random_int":
+		return fmt.Sprintf("random_int: %g 到 %g 之间的随机整数", v.Min, v.Max)
+	case "random_float":
+		return fmt.Sprintf("random_float: %g 到 %g 之间的随机小数", v.Min, v.Max)
+	case "random_string":
+		return fmt.Sprintf("random_string: 长度为 %d 的随机字符串", v.Length)
+	case "pattern":
+		return fmt.Sprintf("pattern: 按模式 %s 生成", v.Pattern)
+	default:
+		return v.Type
+	}
+}
@@ -383,7 +383,7 @@ func (ui *InteractiveUI) startSending() {
 
 	// 开始发送
 	fmt.Println("\n开始发送，按 Ctrl+C 停止...")
-	if err := s.Start(); err != nil {
+	if _, err := s.Start(); err != nil {
 		fmt.Printf("发送失败: %v\n", err)
 	}
 }